@@ -5,6 +5,8 @@ go 1.23.6
 require (
 	github.com/cloudwego/eino v0.3.15
 	github.com/cloudwego/eino-ext/components/model/ollama v0.0.0-20250313022425-9e78531cd328
+	github.com/getkin/kin-openapi v0.118.0
+	go.etcd.io/bbolt v1.3.10
 )
 
 require (
@@ -13,7 +15,6 @@ require (
 	github.com/cloudwego/base64x v0.1.4 // indirect
 	github.com/cloudwego/iasm v0.2.0 // indirect
 	github.com/dustin/go-humanize v1.0.1 // indirect
-	github.com/getkin/kin-openapi v0.118.0 // indirect
 	github.com/go-openapi/jsonpointer v0.19.5 // indirect
 	github.com/go-openapi/swag v0.19.5 // indirect
 	github.com/goph/emperror v0.17.2 // indirect
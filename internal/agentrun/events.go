@@ -0,0 +1,378 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package agentrun
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/flow/agent"
+	"github.com/cloudwego/eino/schema"
+)
+
+// EventType is the type of an Event.
+type EventType string
+
+const (
+	// EventModelStart is sent when a model turn starts.
+	EventModelStart EventType = "model_start"
+	// EventToken is a chunk of the content of a model turn, including the turns that call tools.
+	EventToken EventType = "token"
+	// EventToolCallRequested is sent for every tool call of a model turn, before the tools run.
+	EventToolCallRequested EventType = "tool_call_requested"
+	// EventToolResult is the result of a tool call. Errors the tool middleware reports to the
+	// model are results too.
+	EventToolResult EventType = "tool_result"
+	// EventToolError is sent when a tool call fails and fails the run.
+	EventToolError EventType = "tool_error"
+	// EventFinal is the last event of a successful run.
+	EventFinal EventType = "final"
+	// EventError is the last event of a failed run.
+	EventError EventType = "error"
+)
+
+// Event is one step of the progress of a run. Only the fields of its type are set.
+type Event struct {
+	Type EventType `json:"type"`
+	Seq  int       `json:"seq"` // from 1, in the order the events were sent
+	Time time.Time `json:"time"`
+	// Turn is the model turn the event belongs to, from 1
+	Turn int `json:"turn,omitempty"`
+
+	Content string `json:"content,omitempty"` // token
+
+	Tool       string `json:"tool,omitempty"`
+	ToolCallID string `json:"tool_call_id,omitempty"`
+	Arguments  string `json:"arguments,omitempty"`
+	Result     string `json:"result,omitempty"` // tool result
+
+	Error string `json:"error,omitempty"` // tool error, error
+
+	Message *schema.Message `json:"message,omitempty"` // final
+	// Trace is the result of the run, set on the final and the error event
+	Trace *Result `json:"trace,omitempty"`
+}
+
+// Events runs the agent in streaming mode and sends its progress on the returned channel.
+// The channel is closed after the final or the error event. The caller must read it to the
+// end or cancel ctx, which also stops the run.
+//
+// The events of a node are sent before the events of the nodes started after it, so all
+// tokens and tool calls of a model turn come before the results of those tool calls.
+func Events(ctx context.Context, a Agent, input []*schema.Message, opts ...agent.AgentOption) <-chan Event {
+	e := &emitter{ctx: ctx, ch: make(chan Event, 64), sent: make(chan struct{})}
+	close(e.sent)
+	opts = append(opts[:len(opts):len(opts)], agent.WithComposeOptions(compose.WithCallbacks(e.handler())))
+
+	go func() {
+		defer close(e.ch)
+
+		res, err := Stream(ctx, a, input, nil, opts...)
+		e.models.wait()
+		e.tools.wait()
+		if err != nil {
+			e.emit(Event{Type: EventError, Error: err.Error(), Trace: res})
+			return
+		}
+		e.emit(Event{Type: EventFinal, Message: res.Message, Trace: res})
+	}()
+	return e.ch
+}
+
+type emitter struct {
+	ctx context.Context
+	ch  chan Event
+	// 还在读的流式输出, 工具开始前只需要等模型的输出
+	models, tools inflight
+
+	mu      sync.Mutex
+	seq     int
+	sent    chan struct{} // 最后一个编号的事件发送后关闭, 下一个事件等它关闭再发送
+	turn    int
+	pending []schema.ToolCall // 模型请求了但还没有开始的工具调用
+}
+
+// toolRun 放在工具调用的 ctx 里, 结束时用来生成事件.
+type toolRun struct {
+	name, args, id string
+	turn           int
+}
+
+// turnKey, toolKey 每个 emitter 自己的 key, 嵌套的 agent 有自己的 emitter.
+type turnKey struct{ e *emitter }
+
+type toolKey struct{ e *emitter }
+
+// emit 在锁内编号, 在锁外按编号的顺序发送, 读取方慢的时候不会挡住其他需要锁的 callback.
+func (e *emitter) emit(ev Event) {
+	e.mu.Lock()
+	e.seq++
+	ev.Seq = e.seq
+	ev.Time = time.Now()
+	prev, sent := e.sent, make(chan struct{})
+	e.sent = sent
+	e.mu.Unlock()
+
+	defer close(sent)
+	select {
+	case <-prev:
+	case <-e.ctx.Done():
+		return
+	}
+	select {
+	case e.ch <- ev:
+	case <-e.ctx.Done():
+	}
+}
+
+func (e *emitter) handler() callbacks.Handler {
+	return callbacks.NewHandlerBuilder().
+		OnStartFn(func(ctx context.Context, info *callbacks.RunInfo, input callbacks.CallbackInput) context.Context {
+			var args string
+			if in := tool.ConvCallbackInput(input); in != nil {
+				args = in.ArgumentsInJSON
+			}
+			return e.start(ctx, info, args)
+		}).
+		OnStartWithStreamInputFn(func(ctx context.Context, info *callbacks.RunInfo, input *schema.StreamReader[callbacks.CallbackInput]) context.Context {
+			input.Close()
+			return e.start(ctx, info, "")
+		}).
+		OnEndFn(func(ctx context.Context, info *callbacks.RunInfo, output callbacks.CallbackOutput) context.Context {
+			if turn, ok := ctx.Value(turnKey{e}).(int); ok {
+				if out := model.ConvCallbackOutput(output); out != nil && out.Message != nil {
+					e.modelEnd(turn, out.Message.Content, out.Message.ToolCalls)
+				}
+			}
+			if run, _ := ctx.Value(toolKey{e}).(*toolRun); run != nil {
+				if out := tool.ConvCallbackOutput(output); out != nil {
+					e.emit(run.event(EventToolResult, out.Response))
+				}
+			}
+			return ctx
+		}).
+		OnEndWithStreamOutputFn(func(ctx context.Context, info *callbacks.RunInfo, output *schema.StreamReader[callbacks.CallbackOutput]) context.Context {
+			turn, isModel := ctx.Value(turnKey{e}).(int)
+			run, _ := ctx.Value(toolKey{e}).(*toolRun)
+			isTool := run != nil
+			if !isModel && !isTool {
+				output.Close()
+				return ctx
+			}
+
+			f := &e.tools
+			if isModel {
+				f = &e.models
+			}
+			f.add()
+			go func() {
+				defer f.done()
+				defer output.Close()
+				if isModel {
+					e.modelStream(turn, output)
+				} else {
+					e.toolStream(run, output)
+				}
+			}()
+			return ctx
+		}).
+		OnErrorFn(func(ctx context.Context, info *callbacks.RunInfo, err error) context.Context {
+			if run, _ := ctx.Value(toolKey{e}).(*toolRun); run != nil {
+				ev := run.event(EventToolError, "")
+				ev.Error = err.Error()
+				e.emit(ev)
+			}
+			return ctx
+		}).
+		Build()
+}
+
+// start 在模型或工具开始前等之前节点的流式输出读完, 这样事件的顺序和节点的顺序一致;
+// 并行的工具互相不等. 工具里运行的 agent 的模型和工具不发事件, 工具结束时才发 tool_result.
+func (e *emitter) start(ctx context.Context, info *callbacks.RunInfo, args string) context.Context {
+	if info == nil || (info.Component != components.ComponentOfChatModel && info.Component != components.ComponentOfTool) {
+		return ctx
+	}
+	if _, nested := ctx.Value(toolKey{e}).(*toolRun); nested {
+		return context.WithValue(ctx, toolKey{e}, (*toolRun)(nil))
+	}
+	e.models.wait()
+	if info.Component == components.ComponentOfChatModel {
+		e.tools.wait()
+	}
+
+	e.mu.Lock()
+	turn := e.turn
+	if info.Component == components.ComponentOfChatModel {
+		e.turn++
+		turn = e.turn
+		e.pending = nil
+	}
+	e.mu.Unlock()
+
+	if info.Component == components.ComponentOfChatModel {
+		e.emit(Event{Type: EventModelStart, Turn: turn})
+		return context.WithValue(ctx, turnKey{e}, turn)
+	}
+
+	run := &toolRun{name: info.Name, args: args, turn: turn, id: e.match(info.Name, args)}
+	return context.WithValue(ctx, toolKey{e}, run)
+}
+
+// match 工具的 callback 没有 tool call ID, 按工具名和参数在这一轮请求的调用中找.
+func (e *emitter) match(name, args string) string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for i, tc := range e.pending {
+		if tc.Function.Name == name && tc.Function.Arguments == args {
+			e.pending = append(e.pending[:i:i], e.pending[i+1:]...)
+			return tc.ID
+		}
+	}
+	return ""
+}
+
+func (e *emitter) modelEnd(turn int, content string, calls []schema.ToolCall) {
+	if content != "" {
+		e.emit(Event{Type: EventToken, Turn: turn, Content: content})
+	}
+	e.requested(turn, calls)
+}
+
+func (e *emitter) requested(turn int, calls []schema.ToolCall) {
+	e.mu.Lock()
+	e.pending = append(e.pending, calls...)
+	e.mu.Unlock()
+
+	for _, tc := range calls {
+		e.emit(Event{
+			Type:       EventToolCallRequested,
+			Turn:       turn,
+			Tool:       tc.Function.Name,
+			ToolCallID: tc.ID,
+			Arguments:  tc.Function.Arguments,
+		})
+	}
+}
+
+func (e *emitter) modelStream(turn int, output *schema.StreamReader[callbacks.CallbackOutput]) {
+	var calls []*schema.Message
+	for {
+		frame, err := output.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return
+		}
+
+		out := model.ConvCallbackOutput(frame)
+		if out == nil || out.Message == nil {
+			continue
+		}
+		if out.Message.Content != "" {
+			e.emit(Event{Type: EventToken, Turn: turn, Content: out.Message.Content})
+		}
+		if len(out.Message.ToolCalls) > 0 {
+			// tool calls may be split across chunks, only they are concatenated
+			calls = append(calls, &schema.Message{Role: schema.Assistant, ToolCalls: out.Message.ToolCalls})
+		}
+	}
+
+	if len(calls) == 0 {
+		return
+	}
+	msg, err := schema.ConcatMessages(calls)
+	if err != nil {
+		return
+	}
+	e.requested(turn, msg.ToolCalls)
+}
+
+func (e *emitter) toolStream(run *toolRun, output *schema.StreamReader[callbacks.CallbackOutput]) {
+	var b strings.Builder
+	for {
+		frame, err := output.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			ev := run.event(EventToolError, "")
+			ev.Error = err.Error()
+			e.emit(ev)
+			return
+		}
+		if out := tool.ConvCallbackOutput(frame); out != nil {
+			b.WriteString(out.Response)
+		}
+	}
+	e.emit(run.event(EventToolResult, b.String()))
+}
+
+func (r *toolRun) event(typ EventType, result string) Event {
+	return Event{
+		Type:       typ,
+		Turn:       r.turn,
+		Tool:       r.name,
+		ToolCallID: r.id,
+		Arguments:  r.args,
+		Result:     result,
+	}
+}
+
+// inflight 和 sync.WaitGroup 一样计数, 但允许在 wait 的同时 add.
+type inflight struct {
+	mu   sync.Mutex
+	n    int
+	idle chan struct{} // n 为 0 时为 nil
+}
+
+func (f *inflight) add() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.n == 0 {
+		f.idle = make(chan struct{})
+	}
+	f.n++
+}
+
+func (f *inflight) done() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.n--; f.n == 0 {
+		close(f.idle)
+		f.idle = nil
+	}
+}
+
+func (f *inflight) wait() {
+	f.mu.Lock()
+	idle := f.idle
+	f.mu.Unlock()
+	if idle != nil {
+		<-idle
+	}
+}
@@ -0,0 +1,53 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package agentrun
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestEmitOrderWithoutLock(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	e := &emitter{ctx: ctx, ch: make(chan Event), sent: make(chan struct{})}
+	close(e.sent)
+
+	for range 3 {
+		go e.emit(Event{Type: EventToken})
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	// 没有人读取时, 其他 callback 仍然可以拿到锁
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		e.match("query_dishes", "{}")
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("emit holds the lock while it waits for the reader")
+	}
+
+	for want := 1; want <= 3; want++ {
+		if ev := <-e.ch; ev.Seq != want {
+			t.Errorf("got seq %d, want %d", ev.Seq, want)
+		}
+	}
+}
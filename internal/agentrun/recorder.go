@@ -0,0 +1,348 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package agentrun
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+)
+
+// Recorder builds the trace of a run from the callbacks of its chat model and tools.
+// A recorder is used for one run, see Generate and Stream.
+type Recorder struct {
+	start time.Time
+
+	mu    sync.Mutex
+	steps []*Step
+	wg    sync.WaitGroup // 还在读的流式输出
+}
+
+// NewRecorder creates a recorder, the run is timed from now.
+func NewRecorder() *Recorder {
+	return &Recorder{start: time.Now()}
+}
+
+// stepKey 每个 recorder 自己的 key, 嵌套的 agent 有自己的 recorder.
+type stepKey struct{ r *Recorder }
+
+// toolFrameKey 最内层正在记录的工具调用, 见 AttachTrace.
+type toolFrameKey struct{}
+
+type toolFrame struct {
+	r    *Recorder
+	step *Step
+}
+
+// Handler returns the callback handler to pass with compose.WithCallbacks.
+//
+// Callbacks reach the handlers of the enclosing runs too. The model turns and tool calls
+// of an agent run inside a tool call are not steps of this run, the tool gets their
+// trace with AttachTrace.
+func (r *Recorder) Handler() callbacks.Handler {
+	return callbacks.NewHandlerBuilder().
+		OnStartFn(func(ctx context.Context, info *callbacks.RunInfo, input callbacks.CallbackInput) context.Context {
+			if r.nested(ctx) {
+				return context.WithValue(ctx, stepKey{r}, (*Step)(nil))
+			}
+			step := r.begin(info)
+			if step == nil {
+				return ctx
+			}
+			if step.Kind == StepTool {
+				if in := tool.ConvCallbackInput(input); in != nil {
+					step.Arguments = in.ArgumentsInJSON
+				}
+			}
+			return r.withStep(ctx, step)
+		}).
+		OnStartWithStreamInputFn(func(ctx context.Context, info *callbacks.RunInfo, input *schema.StreamReader[callbacks.CallbackInput]) context.Context {
+			input.Close()
+			if r.nested(ctx) {
+				return context.WithValue(ctx, stepKey{r}, (*Step)(nil))
+			}
+			if step := r.begin(info); step != nil {
+				return r.withStep(ctx, step)
+			}
+			return ctx
+		}).
+		OnEndFn(func(ctx context.Context, info *callbacks.RunInfo, output callbacks.CallbackOutput) context.Context {
+			step, _ := ctx.Value(stepKey{r}).(*Step)
+			if step == nil {
+				return ctx
+			}
+			r.end(step, output, 0)
+			return ctx
+		}).
+		OnEndWithStreamOutputFn(func(ctx context.Context, info *callbacks.RunInfo, output *schema.StreamReader[callbacks.CallbackOutput]) context.Context {
+			step, _ := ctx.Value(stepKey{r}).(*Step)
+			if step == nil {
+				output.Close()
+				return ctx
+			}
+
+			// the handler gets its own copy of the stream, the step ends when it's drained
+			r.wg.Add(1)
+			go func() {
+				defer r.wg.Done()
+				defer output.Close()
+				r.endStream(step, output)
+			}()
+			return ctx
+		}).
+		OnErrorFn(func(ctx context.Context, info *callbacks.RunInfo, err error) context.Context {
+			step, _ := ctx.Value(stepKey{r}).(*Step)
+			if step == nil {
+				return ctx
+			}
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			step.Duration = time.Since(r.start) - step.Offset
+			step.Error = err.Error()
+			return ctx
+		}).
+		Build()
+}
+
+// nested 只有工具调用的 ctx 里有这个 recorder 的 step, 在其中开始的节点属于工具里运行的 agent.
+func (r *Recorder) nested(ctx context.Context) bool {
+	_, ok := ctx.Value(stepKey{r}).(*Step)
+	return ok
+}
+
+func (r *Recorder) withStep(ctx context.Context, step *Step) context.Context {
+	ctx = context.WithValue(ctx, stepKey{r}, step)
+	if step.Kind == StepTool {
+		ctx = context.WithValue(ctx, toolFrameKey{}, &toolFrame{r: r, step: step})
+	}
+	return ctx
+}
+
+// AttachTrace records res as the trace of the tool call running on ctx. Tools which run
+// an agent themselves call it, see agenttool. It returns false when no recorder records
+// the call.
+func AttachTrace(ctx context.Context, res *Result) bool {
+	f, _ := ctx.Value(toolFrameKey{}).(*toolFrame)
+	if f == nil || res == nil {
+		return false
+	}
+	f.r.mu.Lock()
+	defer f.r.mu.Unlock()
+	f.step.Trace = res
+	return true
+}
+
+// begin 为模型和工具的调用添加一步, 其他节点 (graph, lambda 等) 不记录.
+func (r *Recorder) begin(info *callbacks.RunInfo) *Step {
+	if info == nil {
+		return nil
+	}
+
+	var kind StepKind
+	switch info.Component {
+	case components.ComponentOfChatModel:
+		kind = StepModel
+	case components.ComponentOfTool:
+		kind = StepTool
+	default:
+		return nil
+	}
+
+	name := info.Name
+	if kind == StepModel && info.Type != "" {
+		name = info.Type
+	}
+
+	step := &Step{Kind: kind, Name: name, Offset: time.Since(r.start)}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.steps = append(r.steps, step)
+	return step
+}
+
+func (r *Recorder) end(step *Step, output callbacks.CallbackOutput, firstChunk time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	step.Duration = time.Since(r.start) - step.Offset
+	step.FirstChunk = firstChunk
+	switch step.Kind {
+	case StepModel:
+		if out := model.ConvCallbackOutput(output); out != nil {
+			step.Message = out.Message
+			step.Usage = usageOf(out.Message, out.TokenUsage)
+		}
+	case StepTool:
+		if out := tool.ConvCallbackOutput(output); out != nil {
+			step.Result = out.Response
+		}
+	}
+}
+
+func (r *Recorder) endStream(step *Step, output *schema.StreamReader[callbacks.CallbackOutput]) {
+	var (
+		firstChunk time.Duration
+		chunks     []*schema.Message
+		usage      *model.TokenUsage
+		result     strings.Builder
+	)
+	for {
+		frame, err := output.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			r.mu.Lock()
+			step.Duration = time.Since(r.start) - step.Offset
+			step.Error = err.Error()
+			r.mu.Unlock()
+			return
+		}
+		if firstChunk == 0 {
+			firstChunk = time.Since(r.start) - step.Offset
+		}
+
+		switch step.Kind {
+		case StepModel:
+			if out := model.ConvCallbackOutput(frame); out != nil {
+				if out.Message != nil {
+					chunks = append(chunks, copyMeta(out.Message))
+				}
+				if out.TokenUsage != nil {
+					usage = out.TokenUsage
+				}
+			}
+		case StepTool:
+			if out := tool.ConvCallbackOutput(frame); out != nil {
+				result.WriteString(out.Response)
+			}
+		}
+	}
+
+	if step.Kind == StepTool {
+		r.end(step, &tool.CallbackOutput{Response: result.String()}, firstChunk)
+		return
+	}
+
+	out := &model.CallbackOutput{TokenUsage: usage}
+	if len(chunks) > 0 {
+		msg, err := schema.ConcatMessages(chunks)
+		if err != nil {
+			r.mu.Lock()
+			step.Error = err.Error()
+			r.mu.Unlock()
+		}
+		out.Message = msg
+	}
+	r.end(step, out, firstChunk)
+}
+
+// copyMeta 复制 chunk 的 ResponseMeta, ConcatMessages 会修改第一个 chunk 的 ResponseMeta,
+// 而 chunk 和 agent 读到的是同一个.
+func copyMeta(msg *schema.Message) *schema.Message {
+	if msg.ResponseMeta == nil {
+		return msg
+	}
+	c := *msg
+	meta := *msg.ResponseMeta
+	if meta.Usage != nil {
+		usage := *meta.Usage
+		meta.Usage = &usage
+	}
+	c.ResponseMeta = &meta
+	return &c
+}
+
+// usageOf 优先使用消息 ResponseMeta 中的用量, 没有时使用 callback 报告的用量.
+func usageOf(msg *schema.Message, fallback *model.TokenUsage) *schema.TokenUsage {
+	var u schema.TokenUsage
+	switch {
+	case msg != nil && msg.ResponseMeta != nil && msg.ResponseMeta.Usage != nil:
+		u = *msg.ResponseMeta.Usage
+	case fallback != nil:
+		u = schema.TokenUsage{
+			PromptTokens:     fallback.PromptTokens,
+			CompletionTokens: fallback.CompletionTokens,
+			TotalTokens:      fallback.TotalTokens,
+		}
+	default:
+		return nil
+	}
+	if u.TotalTokens == 0 {
+		u.TotalTokens = u.PromptTokens + u.CompletionTokens
+	}
+	return &u
+}
+
+// Result waits for the streamed outputs still being read and returns the result with the
+// final message msg.
+func (r *Recorder) Result(msg *schema.Message) *Result {
+	r.wg.Wait()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	res := &Result{
+		Message:  msg,
+		Steps:    append([]*Step(nil), r.steps...),
+		Duration: time.Since(r.start),
+	}
+	for _, s := range res.Steps {
+		u := s.Usage
+		if s.Trace != nil {
+			u = &s.Trace.Usage
+		}
+		if u != nil {
+			res.Usage.PromptTokens += u.PromptTokens
+			res.Usage.CompletionTokens += u.CompletionTokens
+			res.Usage.TotalTokens += u.TotalTokens
+		}
+	}
+	matchToolCalls(res.Steps)
+	return res
+}
+
+// matchToolCalls 工具的 callback 没有 tool call ID, 按工具名和参数在之前最近的模型输出中找对应的调用.
+func matchToolCalls(steps []*Step) {
+	var pending []schema.ToolCall
+	for _, s := range steps {
+		switch s.Kind {
+		case StepModel:
+			pending = nil
+			if s.Message != nil {
+				pending = append(pending, s.Message.ToolCalls...)
+			}
+		case StepTool:
+			for i, tc := range pending {
+				if tc.Function.Name == s.Name && tc.Function.Arguments == s.Arguments {
+					s.ToolCallID = tc.ID
+					pending = append(pending[:i:i], pending[i+1:]...)
+					break
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,151 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package agentrun runs an agent and returns the final message together with a trace of
+// the model turns and tool calls, their durations and the token usage.
+package agentrun
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// StepKind is the kind of a step in the trace.
+type StepKind string
+
+const (
+	StepModel StepKind = "model"
+	StepTool  StepKind = "tool"
+)
+
+// Step is one model turn or one tool call.
+type Step struct {
+	Kind StepKind `json:"kind"`
+	// Name is the tool name, or the type of the chat model
+	Name string `json:"name"`
+	// Offset is when the step started, relative to the start of the run
+	Offset   time.Duration `json:"offset"`
+	Duration time.Duration `json:"duration"`
+	// FirstChunk is the time to the first chunk of a streamed model turn
+	FirstChunk time.Duration `json:"first_chunk,omitempty"`
+	Error      string        `json:"error,omitempty"`
+
+	// model turns
+	Message *schema.Message    `json:"message,omitempty"`
+	Usage   *schema.TokenUsage `json:"usage,omitempty"`
+
+	// tool calls
+	ToolCallID string `json:"tool_call_id,omitempty"` // matched from the tool calls of the model turn before
+	Arguments  string `json:"arguments,omitempty"`
+	Result     string `json:"result,omitempty"`
+	// Trace is the run of the agent behind the tool, see AttachTrace
+	Trace *Result `json:"trace,omitempty"`
+}
+
+// Result is the outcome of one agent run.
+type Result struct {
+	// Message is the final answer
+	Message  *schema.Message   `json:"message"`
+	Steps    []*Step           `json:"steps"`
+	Usage    schema.TokenUsage `json:"usage"` // summed over the model turns that reported it, nested traces included
+	Duration time.Duration     `json:"duration"`
+}
+
+// ModelTurns returns the model steps in order.
+func (r *Result) ModelTurns() []*Step {
+	return r.steps(StepModel)
+}
+
+// ToolCalls returns the tool steps in order.
+func (r *Result) ToolCalls() []*Step {
+	return r.steps(StepTool)
+}
+
+func (r *Result) steps(kind StepKind) []*Step {
+	var res []*Step
+	for _, s := range r.Steps {
+		if s.Kind == kind {
+			res = append(res, s)
+		}
+	}
+	return res
+}
+
+// String formats the trace for the terminal, one line per step. The traces of agents
+// run by tools are indented under their tool call.
+func (r *Result) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "trace: %d model turns, %d tool calls, %d tokens (prompt %d, completion %d) in %s\n",
+		len(r.ModelTurns()), len(r.ToolCalls()), r.Usage.TotalTokens, r.Usage.PromptTokens, r.Usage.CompletionTokens, round(r.Duration))
+	r.writeSteps(&b, "  ")
+	return b.String()
+}
+
+// writeSteps 每步一行, 嵌套的 trace 多缩进一层, offset 相对于各自的 run.
+func (r *Result) writeSteps(b *strings.Builder, indent string) {
+	for i, s := range r.Steps {
+		fmt.Fprintf(b, "%s%2d  +%-8s %-6s %-8s ", indent, i+1, round(s.Offset), s.Kind, round(s.Duration))
+		switch s.Kind {
+		case StepModel:
+			b.WriteString(describeTurn(s))
+		case StepTool:
+			fmt.Fprintf(b, "%s(%s)", s.Name, s.Arguments)
+			if s.Error == "" {
+				fmt.Fprintf(b, " -> %d bytes", len(s.Result))
+			}
+		}
+		if s.Error != "" {
+			fmt.Fprintf(b, " error: %s", s.Error)
+		}
+		b.WriteByte('\n')
+
+		if t := s.Trace; t != nil {
+			fmt.Fprintf(b, "%s      %d model turns, %d tool calls, %d tokens\n",
+				indent, len(t.ModelTurns()), len(t.ToolCalls()), t.Usage.TotalTokens)
+			t.writeSteps(b, indent+"      ")
+		}
+	}
+}
+
+// describeTurn 模型这一轮做了什么: 调用了哪些工具, 或者回答了多少字.
+func describeTurn(s *Step) string {
+	var parts []string
+	if s.Message != nil {
+		if n := len(s.Message.ToolCalls); n > 0 {
+			names := make([]string, 0, n)
+			for _, tc := range s.Message.ToolCalls {
+				names = append(names, tc.Function.Name)
+			}
+			parts = append(parts, "calls "+strings.Join(names, ", "))
+		} else {
+			parts = append(parts, fmt.Sprintf("answer of %d chars", len([]rune(s.Message.Content))))
+		}
+	}
+	if s.FirstChunk > 0 {
+		parts = append(parts, "first chunk after "+round(s.FirstChunk))
+	}
+	if s.Usage != nil {
+		parts = append(parts, fmt.Sprintf("%d tokens", s.Usage.TotalTokens))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func round(d time.Duration) string {
+	return d.Round(time.Millisecond).String()
+}
@@ -0,0 +1,93 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package agentrun
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/flow/agent"
+	"github.com/cloudwego/eino/schema"
+)
+
+// Agent is implemented by react.Agent.
+type Agent interface {
+	Generate(ctx context.Context, input []*schema.Message, opts ...agent.AgentOption) (*schema.Message, error)
+	Stream(ctx context.Context, input []*schema.Message, opts ...agent.AgentOption) (*schema.StreamReader[*schema.Message], error)
+}
+
+// Generate runs the agent and returns the answer with the trace of the run.
+// The trace is returned up to the failed step when the run fails.
+func Generate(ctx context.Context, a Agent, input []*schema.Message, opts ...agent.AgentOption) (*Result, error) {
+	rec := NewRecorder()
+	msg, err := a.Generate(ctx, input, withRecorder(rec, opts)...)
+	if err != nil {
+		return rec.Result(nil), fmt.Errorf("failed to generate: %w", err)
+	}
+	return rec.Result(msg), nil
+}
+
+// Stream runs the agent in streaming mode, calls onChunk with each chunk of the answer
+// as it arrives, and returns the concatenated answer with the trace of the run.
+func Stream(ctx context.Context, a Agent, input []*schema.Message, onChunk func(*schema.Message), opts ...agent.AgentOption) (*Result, error) {
+	rec := NewRecorder()
+	sr, err := a.Stream(ctx, input, withRecorder(rec, opts)...)
+	if err != nil {
+		return rec.Result(nil), fmt.Errorf("failed to stream: %w", err)
+	}
+
+	chunks, err := drain(sr, onChunk)
+	if err != nil {
+		return rec.Result(nil), fmt.Errorf("failed to recv: %w", err)
+	}
+
+	if len(chunks) == 0 {
+		return rec.Result(schema.AssistantMessage("", nil)), nil
+	}
+	msg, err := schema.ConcatMessages(chunks)
+	if err != nil {
+		return rec.Result(nil), err
+	}
+	return rec.Result(msg), nil
+}
+
+// drain 读完并关闭流; 先关闭再等 Recorder, 这样出错时模型的流式输出也会结束.
+func drain(sr *schema.StreamReader[*schema.Message], onChunk func(*schema.Message)) ([]*schema.Message, error) {
+	defer sr.Close()
+
+	var chunks []*schema.Message
+	for {
+		chunk, err := sr.Recv()
+		if errors.Is(err, io.EOF) {
+			return chunks, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if onChunk != nil {
+			onChunk(chunk)
+		}
+		chunks = append(chunks, chunk)
+	}
+}
+
+func withRecorder(rec *Recorder, opts []agent.AgentOption) []agent.AgentOption {
+	return append(opts[:len(opts):len(opts)], agent.WithComposeOptions(compose.WithCallbacks(rec.Handler())))
+}
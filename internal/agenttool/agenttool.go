@@ -0,0 +1,205 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package agenttool wraps an agent as a tool, so that a coordinator agent can delegate a
+// task to it.
+//
+// The agent runs on the context of the tool call: cancelling the coordinator's run stops
+// it, and the callback handlers of the coordinator's run see its model turns and tool
+// calls. Its trace is attached to the tool call in the coordinator's trace, see
+// agentrun.AttachTrace.
+package agenttool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/flow/agent"
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/galihrivanto/eino-exp/internal/agentrun"
+	"github.com/galihrivanto/eino-exp/internal/logs"
+)
+
+// RequestParam is the parameter of the default input schema, the task in the coordinator's words.
+const RequestParam = "request"
+
+// Config configures the tool.
+type Config struct {
+	// Name and Desc are what the coordinator's model sees, Desc should say what the agent can do
+	Name string
+	Desc string
+	// Params is the input schema, default a single required string RequestParam
+	Params *schema.ParamsOneOf
+
+	// Input turns the arguments into the agent's input, default DefaultInput
+	Input func(ctx context.Context, argumentsInJSON string) ([]*schema.Message, error)
+	// Output turns the agent's result into the tool result, default the content of the answer
+	Output func(ctx context.Context, res *agentrun.Result) (string, error)
+
+	// Begin prepares the context of each run, e.g. to start a guard run so that the agent
+	// has its own budget instead of the coordinator's
+	Begin func(ctx context.Context) context.Context
+	// Options are passed to every run of the agent
+	Options []agent.AgentOption
+}
+
+// New wraps the agent, e.g. a react.Agent, as a tool.
+func New(a agentrun.Agent, conf *Config) (tool.InvokableTool, error) {
+	if a == nil {
+		return nil, errors.New("agenttool: agent is nil")
+	}
+	if conf == nil || conf.Name == "" || conf.Desc == "" {
+		return nil, errors.New("agenttool: name and description are required")
+	}
+
+	c := *conf
+	if c.Params == nil {
+		c.Params = schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			RequestParam: {
+				Type:     schema.String,
+				Desc:     "the task for the agent, with everything it needs to know in one message",
+				Required: true,
+			},
+		})
+	}
+	if c.Input == nil {
+		c.Input = DefaultInput
+	}
+	if c.Output == nil {
+		c.Output = func(_ context.Context, res *agentrun.Result) (string, error) {
+			if res.Message == nil || strings.TrimSpace(res.Message.Content) == "" {
+				return "", errors.New("the agent gave no answer")
+			}
+			return res.Message.Content, nil
+		}
+	}
+	return &agentTool{agent: a, conf: &c}, nil
+}
+
+// NewGraph wraps a compiled graph from messages to the answer as a tool, the agent
+// options are passed to it as compose options.
+func NewGraph(r compose.Runnable[[]*schema.Message, *schema.Message], conf *Config) (tool.InvokableTool, error) {
+	if r == nil {
+		return nil, errors.New("agenttool: graph is nil")
+	}
+	return New(graphAgent{r}, conf)
+}
+
+// DefaultInput makes one user message of the arguments: the RequestParam string, followed
+// by the other arguments as "name: value" lines.
+func DefaultInput(_ context.Context, argumentsInJSON string) ([]*schema.Message, error) {
+	var args map[string]any
+	if err := json.Unmarshal([]byte(argumentsInJSON), &args); err != nil {
+		return nil, fmt.Errorf("the arguments are not a JSON object: %w", err)
+	}
+
+	var lines []string
+	if req, ok := args[RequestParam].(string); ok {
+		lines = append(lines, req)
+		delete(args, RequestParam)
+	}
+
+	names := make([]string, 0, len(args))
+	for name := range args {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		switch v := args[name].(type) {
+		case nil:
+		case string:
+			lines = append(lines, name+": "+v)
+		default:
+			b, _ := json.Marshal(v)
+			lines = append(lines, name+": "+string(b))
+		}
+	}
+
+	if len(lines) == 0 {
+		return nil, errors.New("the arguments have no request")
+	}
+	return []*schema.Message{schema.UserMessage(strings.Join(lines, "\n"))}, nil
+}
+
+// agentTool 在工具调用的 ctx 上运行 agent.
+type agentTool struct {
+	agent agentrun.Agent
+	conf  *Config
+}
+
+func (t *agentTool) Info(_ context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{Name: t.conf.Name, Desc: t.conf.Desc, ParamsOneOf: t.conf.Params}, nil
+}
+
+// InvokableRun reports the failures of the agent to the model, only the errors of ctx are
+// returned.
+func (t *agentTool) InvokableRun(ctx context.Context, argumentsInJSON string, _ ...tool.Option) (string, error) {
+	input, err := t.conf.Input(ctx, argumentsInJSON)
+	if err != nil {
+		return t.report("invalid_arguments", err, "call the tool again with the arguments of its parameters"), nil
+	}
+
+	runCtx := ctx
+	if t.conf.Begin != nil {
+		runCtx = t.conf.Begin(ctx)
+	}
+	res, err := agentrun.Generate(runCtx, t.agent, input, t.conf.Options...)
+	agentrun.AttachTrace(ctx, res)
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		logs.Errorf("agent tool %s: %v", t.conf.Name, err)
+		return t.report("agent_failed", err, "try again with a simpler request, or answer without it"), nil
+	}
+
+	out, err := t.conf.Output(ctx, res)
+	if err != nil {
+		return t.report("agent_failed", err, "try again with a clearer request, or answer without it"), nil
+	}
+	return out, nil
+}
+
+// report renders the error as a tool result the model can read.
+func (t *agentTool) report(kind string, err error, hint string) string {
+	b, _ := json.Marshal(map[string]string{
+		"error":   kind,
+		"tool":    t.conf.Name,
+		"message": err.Error(),
+		"hint":    hint,
+	})
+	return string(b)
+}
+
+// graphAgent 把编译后的 graph 当作 agent 运行.
+type graphAgent struct {
+	r compose.Runnable[[]*schema.Message, *schema.Message]
+}
+
+func (g graphAgent) Generate(ctx context.Context, input []*schema.Message, opts ...agent.AgentOption) (*schema.Message, error) {
+	return g.r.Invoke(ctx, input, agent.GetComposeOptions(opts...)...)
+}
+
+func (g graphAgent) Stream(ctx context.Context, input []*schema.Message, opts ...agent.AgentOption) (*schema.StreamReader[*schema.Message], error) {
+	return g.r.Stream(ctx, input, agent.GetComposeOptions(opts...)...)
+}
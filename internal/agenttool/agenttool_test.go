@@ -0,0 +1,308 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package agenttool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/flow/agent"
+	"github.com/cloudwego/eino/flow/agent/react"
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/galihrivanto/eino-exp/internal/agentrun"
+)
+
+// scriptModel 没有工具结果时调用 call, 有了之后回答 answer; 每轮报告 usage 个 token.
+type scriptModel struct {
+	call   schema.ToolCall
+	answer string
+	usage  int
+	// block 时在调用工具之前等到 ctx 结束, cancelled 记录是否因此返回
+	block     bool
+	cancelled atomic.Bool
+}
+
+func (m *scriptModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	var msg *schema.Message
+	if last := input[len(input)-1]; last.Role == schema.Tool || m.call.Function.Name == "" {
+		msg = schema.AssistantMessage(m.answer, nil)
+		if t := model.GetCommonOptions(nil, opts...).Temperature; t != nil {
+			msg.Content += " (temperature set)"
+		}
+	} else {
+		if m.block {
+			<-ctx.Done()
+			m.cancelled.Store(true)
+			return nil, ctx.Err()
+		}
+		msg = schema.AssistantMessage("", []schema.ToolCall{m.call})
+	}
+	msg.ResponseMeta = &schema.ResponseMeta{Usage: &schema.TokenUsage{PromptTokens: m.usage, CompletionTokens: m.usage}}
+	return msg, nil
+}
+
+func (m *scriptModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	msg, err := m.Generate(ctx, input, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return schema.StreamReaderFromArray([]*schema.Message{msg}), nil
+}
+
+func (m *scriptModel) BindTools([]*schema.ToolInfo) error { return nil }
+
+func toolCall(name, args string) schema.ToolCall {
+	return schema.ToolCall{ID: "call_" + name, Function: schema.FunctionCall{Name: name, Arguments: args}}
+}
+
+type dishTool struct{}
+
+func (dishTool) Info(context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{Name: "query_dishes", Desc: "dishes of a restaurant"}, nil
+}
+
+func (dishTool) InvokableRun(context.Context, string, ...tool.Option) (string, error) {
+	return `[{"name":"mapo tofu","price":18}]`, nil
+}
+
+func newAgent(t *testing.T, m model.ChatModel, tools ...tool.BaseTool) *react.Agent {
+	t.Helper()
+	a, err := react.NewAgent(context.Background(), &react.AgentConfig{
+		Model:       m,
+		ToolsConfig: compose.ToolsNodeConfig{Tools: tools},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+// newCoordinator 的模型把问题交给 menu 工具, menu 背后的 agent 调用 query_dishes.
+func newCoordinator(t *testing.T, sub *scriptModel) *react.Agent {
+	t.Helper()
+	menu, err := New(newAgent(t, sub, dishTool{}), &Config{Name: "menu", Desc: "answers questions about the menu"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return newAgent(t, &scriptModel{
+		call:   toolCall("menu", `{"request":"what is cheap at Sichuan House?"}`),
+		answer: "The mapo tofu is 18 CNY.",
+		usage:  100,
+	}, menu)
+}
+
+func newSub() *scriptModel {
+	return &scriptModel{call: toolCall("query_dishes", `{"restaurant_id":"1001"}`), answer: "mapo tofu, 18 CNY", usage: 10}
+}
+
+func TestDefaultInput(t *testing.T) {
+	tests := []struct {
+		args, want string
+	}{
+		{`{"request":"find dishes"}`, "find dishes"},
+		{`{"request":"find dishes","city":"Beijing","budget":50,"tags":["spicy"],"note":null}`, "find dishes\nbudget: 50\ncity: Beijing\ntags: [\"spicy\"]"},
+		{`{"city":"Beijing"}`, "city: Beijing"},
+		{`{}`, ""},
+		{`{"note":null}`, ""},
+		{`["find dishes"]`, ""},
+	}
+	for _, tt := range tests {
+		msgs, err := DefaultInput(context.Background(), tt.args)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("DefaultInput(%s) = %v, want an error", tt.args, msgs)
+			}
+			continue
+		}
+		if err != nil || len(msgs) != 1 || msgs[0].Role != schema.User || msgs[0].Content != tt.want {
+			t.Errorf("DefaultInput(%s) = %v, %v, want %q", tt.args, msgs, err, tt.want)
+		}
+	}
+}
+
+// agentFunc 用函数实现 agentrun.Agent.
+type agentFunc func(ctx context.Context) (*schema.Message, error)
+
+func (f agentFunc) Generate(ctx context.Context, _ []*schema.Message, _ ...agent.AgentOption) (*schema.Message, error) {
+	return f(ctx)
+}
+
+func (f agentFunc) Stream(ctx context.Context, _ []*schema.Message, _ ...agent.AgentOption) (*schema.StreamReader[*schema.Message], error) {
+	msg, err := f(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return schema.StreamReaderFromArray([]*schema.Message{msg}), nil
+}
+
+func TestErrors(t *testing.T) {
+	if _, err := New(nil, &Config{Name: "menu", Desc: "menu"}); err == nil {
+		t.Error("no error for a nil agent")
+	}
+	if _, err := New(agentFunc(nil), &Config{Name: "menu"}); err == nil {
+		t.Error("no error without a description")
+	}
+	if _, err := NewGraph(nil, &Config{Name: "menu", Desc: "menu"}); err == nil {
+		t.Error("no error for a nil graph")
+	}
+
+	tests := []struct {
+		name, args, kind string
+		agent            agentFunc
+	}{
+		{"invalid arguments", `"dishes"`, "invalid_arguments", nil},
+		{"failed", `{"request":"dishes"}`, "agent_failed", func(context.Context) (*schema.Message, error) {
+			return nil, errors.New("model not found")
+		}},
+		{"no answer", `{"request":"dishes"}`, "agent_failed", func(context.Context) (*schema.Message, error) {
+			return schema.AssistantMessage(" ", nil), nil
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			it, err := New(tt.agent, &Config{Name: "menu", Desc: "menu"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			out, err := it.InvokableRun(context.Background(), tt.args)
+			var r map[string]string
+			if err != nil || json.Unmarshal([]byte(out), &r) != nil || r["error"] != tt.kind || r["tool"] != "menu" {
+				t.Errorf("got %q, %v, want a reported %s", out, err, tt.kind)
+			}
+		})
+	}
+
+	// ctx 的错误不报告给模型, 而是返回
+	t.Run("cancelled", func(t *testing.T) {
+		it, err := New(agentFunc(func(ctx context.Context) (*schema.Message, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}), &Config{Name: "menu", Desc: "menu"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		if out, err := it.InvokableRun(ctx, `{"request":"dishes"}`); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("got %q, %v, want the error of ctx", out, err)
+		}
+	})
+}
+
+func TestCancelStopsSubAgent(t *testing.T) {
+	sub := newSub()
+	sub.block = true
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := agentrun.Generate(ctx, newCoordinator(t, sub), []*schema.Message{schema.UserMessage("what is cheap?")})
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) || !sub.cancelled.Load() {
+			t.Errorf("got %v, sub-agent cancelled %v, want both cancelled", err, sub.cancelled.Load())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the sub-agent kept running after the parent was cancelled")
+	}
+}
+
+func TestNestedTrace(t *testing.T) {
+	res, err := agentrun.Generate(context.Background(), newCoordinator(t, newSub()),
+		[]*schema.Message{schema.UserMessage("what is cheap?")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	calls := res.ToolCalls()
+	if len(res.ModelTurns()) != 2 || len(calls) != 1 || calls[0].Name != "menu" {
+		t.Fatalf("parent trace:\n%s", res)
+	}
+	nested := calls[0].Trace
+	if nested == nil || len(nested.ModelTurns()) != 2 || len(nested.ToolCalls()) != 1 || nested.ToolCalls()[0].Name != "query_dishes" {
+		t.Fatalf("nested trace:\n%s", res)
+	}
+	if calls[0].Result != "mapo tofu, 18 CNY" {
+		t.Errorf("menu result %q", calls[0].Result)
+	}
+	// 两轮父模型各 200, 两轮子模型各 20
+	if nested.Usage.TotalTokens != 40 || res.Usage.TotalTokens != 440 {
+		t.Errorf("usage nested %+v, total %+v", nested.Usage, res.Usage)
+	}
+}
+
+func TestEventsLeaveOutSubAgent(t *testing.T) {
+	var (
+		starts int
+		tools  []string
+		final  *agentrun.Event
+	)
+	for ev := range agentrun.Events(context.Background(), newCoordinator(t, newSub()),
+		[]*schema.Message{schema.UserMessage("what is cheap?")}) {
+		switch ev.Type {
+		case agentrun.EventModelStart:
+			starts++
+		case agentrun.EventToolResult, agentrun.EventToolCallRequested:
+			tools = append(tools, ev.Tool)
+		case agentrun.EventFinal, agentrun.EventError:
+			final = &ev
+		}
+	}
+
+	if starts != 2 || strings.Join(tools, ",") != "menu,menu" {
+		t.Errorf("got %d model starts and tool events %v, want only those of the parent", starts, tools)
+	}
+	if final == nil || final.Type != agentrun.EventFinal || final.Trace.ToolCalls()[0].Trace == nil {
+		t.Errorf("final event %+v, want the trace with the nested one", final)
+	}
+}
+
+func TestNewGraph(t *testing.T) {
+	chain := compose.NewChain[[]*schema.Message, *schema.Message]()
+	chain.AppendChatModel(&scriptModel{answer: "mapo tofu"})
+	r, err := chain.Compile(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// agent 的选项作为 compose 的选项传给 graph
+	temperature := float32(0.2)
+	it, err := NewGraph(r, &Config{
+		Name:    "menu",
+		Desc:    "menu",
+		Options: []agent.AgentOption{agent.WithComposeOptions(compose.WithChatModelOption(model.WithTemperature(temperature)))},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := it.InvokableRun(context.Background(), `{"request":"what is cheap?"}`)
+	if err != nil || out != "mapo tofu (temperature set)" {
+		t.Errorf("got %q, %v", out, err)
+	}
+}
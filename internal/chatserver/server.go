@@ -0,0 +1,308 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package chatserver serves an agent over HTTP: POST /chat answers with JSON or streams the
+// progress of the run as Server-Sent Events, /healthz and /readyz are for probes.
+package chatserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cloudwego/eino/flow/agent"
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/galihrivanto/eino-exp/internal/agentrun"
+	"github.com/galihrivanto/eino-exp/internal/logs"
+	"github.com/galihrivanto/eino-exp/internal/session"
+)
+
+const defaultShutdownTimeout = 10 * time.Second
+
+// Config configures a Server.
+type Config struct {
+	Agent agentrun.Agent
+	// Sessions keeps the conversations of requests with a session ID, nil disables sessions
+	Sessions session.Store
+	// Model and Persona are recorded in the saved sessions
+	Model, Persona string
+
+	// Begin is called with the context of every run, e.g. to start a budget. Optional.
+	Begin func(ctx context.Context) context.Context
+	// Options are passed to every run.
+	Options []agent.AgentOption
+	// Ready reports whether the dependencies, such as the model server, are reachable. Optional.
+	Ready func(ctx context.Context) error
+	// Routes are served next to /chat, by pattern, e.g. "/v1/" for another API. Optional.
+	Routes map[string]http.Handler
+
+	// ShutdownTimeout is how long Serve waits for running requests when it stops, default 10s.
+	// Runs still going after it are cancelled.
+	ShutdownTimeout time.Duration
+}
+
+// Server is the HTTP front end of an agent.
+type Server struct {
+	conf     Config
+	draining atomic.Bool
+
+	mu    sync.Mutex
+	locks map[string]*sessionLock // 同一个会话的请求依次执行
+}
+
+type sessionLock struct {
+	sem  chan struct{} // 容量为 1, 持有锁时里面有一个值, 等待时可以放弃
+	refs int
+}
+
+// New creates a server.
+func New(conf *Config) (*Server, error) {
+	if conf.Agent == nil {
+		return nil, errors.New("chatserver: agent is required")
+	}
+	c := *conf
+	if c.ShutdownTimeout <= 0 {
+		c.ShutdownTimeout = defaultShutdownTimeout
+	}
+	return &Server{conf: c, locks: map[string]*sessionLock{}}, nil
+}
+
+// Handler returns the HTTP handler with all endpoints.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /chat", s.chat)
+	mux.HandleFunc("GET /healthz", s.healthz)
+	mux.HandleFunc("GET /readyz", s.readyz)
+	for pattern, h := range s.conf.Routes {
+		mux.Handle(pattern, h)
+	}
+	return mux
+}
+
+// Serve listens on addr until ctx is done, then stops accepting requests, reports not ready
+// and waits up to ShutdownTimeout for the running requests before cancelling them.
+func (s *Server) Serve(ctx context.Context, addr string) error {
+	// runs outlive ctx during the shutdown, they are cancelled through base after it
+	base, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancel()
+
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           s.Handler(),
+		BaseContext:       func(_ net.Listener) context.Context { return base },
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		logs.Infof("listening on %s", addr)
+		errCh <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	logs.Infof("shutting down, waiting up to %s for running requests", s.conf.ShutdownTimeout)
+	s.draining.Store(true)
+	sctx, scancel := context.WithTimeout(context.Background(), s.conf.ShutdownTimeout)
+	defer scancel()
+	if err := srv.Shutdown(sctx); err != nil {
+		logs.Errorf("requests still running after %s, cancelling them", s.conf.ShutdownTimeout)
+		cancel()
+		_ = srv.Close()
+	}
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func (s *Server) healthz(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (s *Server) readyz(w http.ResponseWriter, r *http.Request) {
+	if s.draining.Load() {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "shutting down"})
+		return
+	}
+	if s.conf.Ready != nil {
+		ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+		defer cancel()
+		if err := s.conf.Ready(ctx); err != nil {
+			writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "not ready", "error": err.Error()})
+			return
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ready"})
+}
+
+// ChatRequest is the body of POST /chat.
+type ChatRequest struct {
+	// SessionID continues a saved conversation, or starts it when it doesn't exist; "new"
+	// starts one with a random ID. Without it the request is answered from Messages alone.
+	SessionID string            `json:"session_id,omitempty"`
+	Messages  []*schema.Message `json:"messages"`
+	// Stream answers with Server-Sent Events, also chosen by Accept: text/event-stream
+	Stream bool `json:"stream,omitempty"`
+}
+
+// ChatResponse is the body of a non-streaming answer.
+type ChatResponse struct {
+	SessionID string            `json:"session_id,omitempty"`
+	Message   *schema.Message   `json:"message"`
+	Trace     *agentrun.Result  `json:"trace,omitempty"`
+	Usage     schema.TokenUsage `json:"usage"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func (s *Server) chat(w http.ResponseWriter, r *http.Request) {
+	var req ChatRequest
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20))
+	if err := dec.Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid request body: " + err.Error()})
+		return
+	}
+	if len(req.Messages) == 0 {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "messages must not be empty"})
+		return
+	}
+	for i, msg := range req.Messages {
+		if msg == nil || msg.Role == "" {
+			writeJSON(w, http.StatusBadRequest, errorResponse{Error: fmt.Sprintf("message %d has no role", i)})
+			return
+		}
+	}
+
+	ctx := r.Context()
+	sess, release, err := s.openSession(ctx, req.SessionID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, errBadSession) {
+			status = http.StatusBadRequest
+		}
+		writeJSON(w, status, errorResponse{Error: err.Error()})
+		return
+	}
+	defer release()
+
+	input := req.Messages
+	if sess != nil {
+		input = append(sess.Messages[:len(sess.Messages):len(sess.Messages)], req.Messages...)
+		w.Header().Set("X-Session-ID", sess.ID)
+	}
+
+	if s.conf.Begin != nil {
+		ctx = s.conf.Begin(ctx)
+	}
+
+	var answer *schema.Message
+	if req.Stream || strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		answer = s.stream(ctx, w, input)
+	} else {
+		answer = s.generate(ctx, w, input, sess)
+	}
+	if answer == nil || sess == nil {
+		return
+	}
+
+	// the answer was sent, a failure to save is only logged
+	if err = s.saveSession(context.WithoutCancel(ctx), sess, append(input, answer)); err != nil {
+		logs.Errorf("%v", err)
+	}
+}
+
+func (s *Server) generate(ctx context.Context, w http.ResponseWriter, input []*schema.Message, sess *session.Session) *schema.Message {
+	res, err := agentrun.Generate(ctx, s.conf.Agent, input, s.conf.Options...)
+	if err != nil {
+		if ctx.Err() != nil {
+			logs.Infof("request cancelled: %v", ctx.Err())
+			return nil
+		}
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: err.Error()})
+		return nil
+	}
+
+	resp := ChatResponse{Message: res.Message, Trace: res, Usage: res.Usage}
+	if sess != nil {
+		resp.SessionID = sess.ID
+	}
+	writeJSON(w, http.StatusOK, resp)
+	return res.Message
+}
+
+// stream 以 SSE 发送 agentrun.Events 的事件, 事件名是事件的类型; 客户端断开时 ctx 被取消, run 也随之停止.
+func (s *Server) stream(ctx context.Context, w http.ResponseWriter, input []*schema.Message) *schema.Message {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "streaming is not supported"})
+		return nil
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	var answer *schema.Message
+	for ev := range agentrun.Events(ctx, s.conf.Agent, input, s.conf.Options...) {
+		if ev.Type == agentrun.EventFinal {
+			answer = ev.Message
+		}
+		if err := writeEvent(w, string(ev.Type), ev); err != nil {
+			// the client is gone, ctx is cancelled and the run stops on its own
+			logs.Infof("client disconnected: %v", err)
+			continue
+		}
+		flusher.Flush()
+	}
+
+	if ctx.Err() != nil {
+		logs.Infof("request cancelled: %v", ctx.Err())
+		return nil
+	}
+	return answer
+}
+
+func writeEvent(w http.ResponseWriter, name string, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, b)
+	return err
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
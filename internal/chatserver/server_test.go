@@ -0,0 +1,543 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package chatserver
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/flow/agent/react"
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/galihrivanto/eino-exp/internal/agentrun"
+	"github.com/galihrivanto/eino-exp/internal/session"
+)
+
+// fakeModel 由 reply 决定回答的聊天模型, 流式时按空格分块.
+type fakeModel struct {
+	reply func(ctx context.Context, input []*schema.Message) (*schema.Message, error)
+}
+
+func (m *fakeModel) Generate(ctx context.Context, input []*schema.Message, _ ...model.Option) (*schema.Message, error) {
+	return m.reply(ctx, input)
+}
+
+func (m *fakeModel) Stream(ctx context.Context, input []*schema.Message, _ ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	msg, err := m.reply(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	if len(msg.ToolCalls) > 0 {
+		return schema.StreamReaderFromArray([]*schema.Message{msg}), nil
+	}
+
+	var chunks []*schema.Message
+	for _, word := range strings.SplitAfter(msg.Content, " ") {
+		chunks = append(chunks, schema.AssistantMessage(word, nil))
+	}
+	return schema.StreamReaderFromArray(chunks), nil
+}
+
+func (m *fakeModel) BindTools([]*schema.ToolInfo) error { return nil }
+
+// callThenAnswer 先调用 echo 工具, 收到工具结果后回答.
+func callThenAnswer(_ context.Context, input []*schema.Message) (*schema.Message, error) {
+	if last := input[len(input)-1]; last.Role == schema.Tool {
+		return schema.AssistantMessage("the tool said "+last.Content, nil), nil
+	}
+	return schema.AssistantMessage("", []schema.ToolCall{{
+		ID:       "call_1",
+		Type:     "function",
+		Function: schema.FunctionCall{Name: "echo", Arguments: `{"text":"hi"}`},
+	}}), nil
+}
+
+type echoTool struct{}
+
+func (echoTool) Info(context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{Name: "echo", Desc: "echo the arguments"}, nil
+}
+
+func (echoTool) InvokableRun(_ context.Context, argumentsInJSON string, _ ...tool.Option) (string, error) {
+	return "echo " + argumentsInJSON, nil
+}
+
+func newServer(t *testing.T, reply func(context.Context, []*schema.Message) (*schema.Message, error), store session.Store) *Server {
+	t.Helper()
+	a, err := react.NewAgent(context.Background(), &react.AgentConfig{
+		Model:       &fakeModel{reply: reply},
+		ToolsConfig: compose.ToolsNodeConfig{Tools: []tool.BaseTool{echoTool{}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := New(&Config{Agent: a, Sessions: store, Model: "fake", ShutdownTimeout: 5 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func chatBody(sessionID, question string, stream bool) *bytes.Reader {
+	b, _ := json.Marshal(ChatRequest{SessionID: sessionID, Messages: []*schema.Message{schema.UserMessage(question)}, Stream: stream})
+	return bytes.NewReader(b)
+}
+
+func TestChatJSON(t *testing.T) {
+	ts := httptest.NewServer(newServer(t, callThenAnswer, nil).Handler())
+	defer ts.Close()
+
+	resp, err := http.Post(ts.URL+"/chat", "application/json", chatBody("", "say hi", false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json") {
+		t.Fatalf("status %d, content type %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	var out ChatResponse
+	if err = json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Message == nil || out.Message.Content != `the tool said echo {"text":"hi"}` {
+		t.Errorf("message = %+v", out.Message)
+	}
+	if out.Trace == nil || !slices.ContainsFunc(out.Trace.Steps, func(s *agentrun.Step) bool { return s.Name == "echo" && s.Result != "" }) {
+		t.Errorf("want the echo call in the trace, got %+v", out.Trace)
+	}
+}
+
+func TestChatBadRequest(t *testing.T) {
+	ts := httptest.NewServer(newServer(t, callThenAnswer, nil).Handler())
+	defer ts.Close()
+
+	for name, body := range map[string]string{
+		"not json":           `{`,
+		"no messages":        `{"messages":[]}`,
+		"message no role":    `{"messages":[{"content":"hi"}]}`,
+		"sessions disabled":  `{"session_id":"new","messages":[{"role":"user","content":"hi"}]}`,
+		"unknown field type": `{"messages":"hi"}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			resp, err := http.Post(ts.URL+"/chat", "application/json", strings.NewReader(body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			var e errorResponse
+			if resp.StatusCode != http.StatusBadRequest || json.NewDecoder(resp.Body).Decode(&e) != nil || e.Error == "" {
+				t.Errorf("status %d error %q, want 400 with an error", resp.StatusCode, e.Error)
+			}
+		})
+	}
+}
+
+// sseEvent 一个 SSE 事件.
+type sseEvent struct {
+	name string
+	ev   agentrun.Event
+}
+
+func readEvents(t *testing.T, resp *http.Response) []sseEvent {
+	t.Helper()
+	var (
+		events []sseEvent
+		name   string
+	)
+	sc := bufio.NewScanner(resp.Body)
+	for sc.Scan() {
+		line := sc.Text()
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			var ev agentrun.Event
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &ev); err != nil {
+				t.Fatalf("event %s: %v", name, err)
+			}
+			events = append(events, sseEvent{name: name, ev: ev})
+		}
+	}
+	return events
+}
+
+func TestChatSSEOrder(t *testing.T) {
+	ts := httptest.NewServer(newServer(t, callThenAnswer, nil).Handler())
+	defer ts.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, ts.URL+"/chat", chatBody("", "say hi", false))
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("content type %q", ct)
+	}
+
+	var (
+		types   []string
+		content strings.Builder
+	)
+	for i, e := range readEvents(t, resp) {
+		if e.name != string(e.ev.Type) {
+			t.Errorf("event name %q, data type %q", e.name, e.ev.Type)
+		}
+		if e.ev.Seq != i+1 {
+			t.Errorf("event %d has seq %d", i+1, e.ev.Seq)
+		}
+		if e.ev.Type == agentrun.EventToken {
+			content.WriteString(e.ev.Content)
+		}
+		// 连续的 token 事件只记一次
+		if len(types) == 0 || types[len(types)-1] != e.name || e.name != string(agentrun.EventToken) {
+			types = append(types, e.name)
+		}
+	}
+
+	want := []string{"model_start", "tool_call_requested", "tool_result", "model_start", "token", "final"}
+	if !slices.Equal(types, want) {
+		t.Errorf("events %v, want %v", types, want)
+	}
+	if content.String() != `the tool said echo {"text":"hi"}` {
+		t.Errorf("tokens %q", content.String())
+	}
+}
+
+func TestChatCancelOnDisconnect(t *testing.T) {
+	for _, stream := range []bool{false, true} {
+		t.Run(fmt.Sprintf("stream=%v", stream), func(t *testing.T) {
+			started, stopped := make(chan struct{}), make(chan struct{})
+			s := newServer(t, func(ctx context.Context, _ []*schema.Message) (*schema.Message, error) {
+				close(started)
+				<-ctx.Done()
+				close(stopped)
+				return nil, ctx.Err()
+			}, nil)
+			ts := httptest.NewServer(s.Handler())
+			defer ts.Close()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			req, _ := http.NewRequestWithContext(ctx, http.MethodPost, ts.URL+"/chat", chatBody("", "wait", stream))
+			errCh := make(chan error, 1)
+			go func() {
+				resp, err := http.DefaultClient.Do(req)
+				if err == nil {
+					// SSE 的响应头已经发出, 读到断开为止
+					_, err = bufio.NewReader(resp.Body).ReadString(0)
+					resp.Body.Close()
+				}
+				errCh <- err
+			}()
+
+			select {
+			case <-started:
+			case <-time.After(5 * time.Second):
+				t.Fatal("the model was not called")
+			}
+			cancel()
+
+			select {
+			case <-stopped:
+			case <-time.After(5 * time.Second):
+				t.Fatal("the run went on after the client disconnected")
+			}
+			if err := <-errCh; !errors.Is(err, context.Canceled) {
+				t.Errorf("client got %v, want context.Canceled", err)
+			}
+		})
+	}
+}
+
+func get(t *testing.T, url string) int {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestReadyz(t *testing.T) {
+	s := newServer(t, callThenAnswer, nil)
+	var down atomic.Bool
+	down.Store(true)
+	s.conf.Ready = func(context.Context) error {
+		if down.Load() {
+			return errors.New("ollama is down")
+		}
+		return nil
+	}
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	if code := get(t, ts.URL+"/healthz"); code != http.StatusOK {
+		t.Errorf("healthz = %d", code)
+	}
+	if code := get(t, ts.URL+"/readyz"); code != http.StatusServiceUnavailable {
+		t.Errorf("readyz with the model down = %d, want 503", code)
+	}
+	down.Store(false)
+	if code := get(t, ts.URL+"/readyz"); code != http.StatusOK {
+		t.Errorf("readyz = %d, want 200", code)
+	}
+}
+
+func TestReadyzWhileDraining(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	var once sync.Once
+	s := newServer(t, func(ctx context.Context, _ []*schema.Message) (*schema.Message, error) {
+		once.Do(func() { close(started) })
+		select {
+		case <-release:
+			return schema.AssistantMessage("done", nil), nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}, nil)
+
+	// Serve stops listening when it drains, the probes reach the same server through another listener
+	probe := httptest.NewServer(s.Handler())
+	defer probe.Close()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+	served := make(chan error, 1)
+	go func() { served <- s.Serve(ctx, addr) }()
+
+	answered := make(chan *ChatResponse, 1)
+	go func() {
+		for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(20 * time.Millisecond) {
+			resp, err := http.Post("http://"+addr+"/chat", "application/json", chatBody("", "slow", false))
+			if err != nil {
+				continue // not listening yet
+			}
+			var out ChatResponse
+			_ = json.NewDecoder(resp.Body).Decode(&out)
+			resp.Body.Close()
+			answered <- &out
+			return
+		}
+		answered <- nil
+	}()
+
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the request didn't start")
+	}
+	if code := get(t, probe.URL+"/readyz"); code != http.StatusOK {
+		t.Fatalf("readyz before shutdown = %d, want 200", code)
+	}
+
+	stop()
+	deadline := time.Now().Add(2 * time.Second)
+	for get(t, probe.URL+"/readyz") != http.StatusServiceUnavailable {
+		if time.Now().After(deadline) {
+			t.Fatal("readyz still ready while draining")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	select {
+	case err := <-served:
+		t.Fatalf("Serve returned %v with a request running", err)
+	default:
+	}
+
+	// the running request is answered before Serve returns
+	close(release)
+	if out := <-answered; out == nil || out.Message == nil || out.Message.Content != "done" {
+		t.Errorf("drained request got %+v", out)
+	}
+	if err := <-served; err != nil {
+		t.Errorf("Serve = %v", err)
+	}
+}
+
+func TestSessionLock(t *testing.T) {
+	store, err := session.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		inFlight, maxInFlight atomic.Int32
+		mu                    sync.Mutex
+		inputs                []int
+	)
+	s := newServer(t, func(ctx context.Context, input []*schema.Message) (*schema.Message, error) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for m := maxInFlight.Load(); n > m && !maxInFlight.CompareAndSwap(m, n); m = maxInFlight.Load() {
+		}
+		mu.Lock()
+		inputs = append(inputs, len(input))
+		mu.Unlock()
+
+		time.Sleep(50 * time.Millisecond)
+		return schema.AssistantMessage(fmt.Sprintf("answer to %d messages", len(input)), nil), nil
+	}, store)
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	const requests = 3
+	var wg sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := http.Post(ts.URL+"/chat", "application/json", chatBody("shared", fmt.Sprint("question ", i), false))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusOK || resp.Header.Get("X-Session-ID") != "shared" {
+				t.Errorf("status %d, session %q", resp.StatusCode, resp.Header.Get("X-Session-ID"))
+			}
+		}()
+	}
+	wg.Wait()
+
+	if m := maxInFlight.Load(); m != 1 {
+		t.Errorf("%d runs of the same session at once, want 1", m)
+	}
+	// every request saw the turns of the ones before it
+	slices.Sort(inputs)
+	if !slices.Equal(inputs, []int{1, 3, 5}) {
+		t.Errorf("input lengths %v, want [1 3 5]", inputs)
+	}
+	sess, err := store.Load(context.Background(), "shared")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sess.Messages) != 2*requests {
+		t.Errorf("session has %d messages, want %d", len(sess.Messages), 2*requests)
+	}
+	if len(s.locks) != 0 {
+		t.Errorf("%d session locks left", len(s.locks))
+	}
+}
+
+func TestSessionLockWaitCancelled(t *testing.T) {
+	s := newServer(t, func(context.Context, []*schema.Message) (*schema.Message, error) {
+		return schema.AssistantMessage("ok", nil), nil
+	}, nil)
+
+	release, err := s.lock(context.Background(), "shared")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 持有锁的请求没有结束, 等待的请求在 ctx 结束时放弃
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := s.lock(ctx, "shared")
+		done <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	select {
+	case err = <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("got %v, want context.Canceled", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("lock kept waiting after its context was cancelled")
+	}
+
+	release()
+	if len(s.locks) != 0 {
+		t.Errorf("%d session locks left", len(s.locks))
+	}
+	// 放弃等待不影响之后的请求
+	release, err = s.lock(context.Background(), "shared")
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+}
+
+func TestSessionsRunInParallel(t *testing.T) {
+	store, err := session.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 两个会话的请求都到达模型后才回答, 会话之间互相阻塞时超时
+	var arrived sync.WaitGroup
+	arrived.Add(2)
+	both := make(chan struct{})
+	go func() { arrived.Wait(); close(both) }()
+	s := newServer(t, func(ctx context.Context, _ []*schema.Message) (*schema.Message, error) {
+		arrived.Done()
+		select {
+		case <-both:
+			return schema.AssistantMessage("ok", nil), nil
+		case <-time.After(3 * time.Second):
+			return nil, errors.New("the other session never ran")
+		}
+	}, store)
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	var wg sync.WaitGroup
+	for _, id := range []string{"alice", "bob"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := http.Post(ts.URL+"/chat", "application/json", chatBody(id, "hi", false))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				t.Errorf("session %s: status %d", id, resp.StatusCode)
+			}
+		}()
+	}
+	wg.Wait()
+}
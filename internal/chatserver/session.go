@@ -0,0 +1,101 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package chatserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/galihrivanto/eino-exp/internal/session"
+)
+
+// errBadSession 请求中的会话 ID 无效或者没有启用会话, 返回 400.
+var errBadSession = errors.New("bad session")
+
+// openSession 加载会话并锁住它直到 release, 同一个会话的请求不能同时修改历史.
+// id 为空时返回 nil, 请求不使用会话.
+func (s *Server) openSession(ctx context.Context, id string) (sess *session.Session, release func(), err error) {
+	if id == "" {
+		return nil, func() {}, nil
+	}
+	if s.conf.Sessions == nil {
+		return nil, nil, fmt.Errorf("%w: sessions are not enabled on this server", errBadSession)
+	}
+	if id == "new" {
+		id = session.NewID()
+	}
+	if err = session.ValidateID(id); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", errBadSession, err)
+	}
+
+	if release, err = s.lock(ctx, id); err != nil {
+		return nil, nil, fmt.Errorf("wait for session %s: %w", id, err)
+	}
+	sess, err = s.conf.Sessions.Load(ctx, id)
+	if errors.Is(err, session.ErrNotFound) {
+		return &session.Session{ID: id}, release, nil
+	}
+	if err != nil {
+		release()
+		return nil, nil, fmt.Errorf("load session %s: %w", id, err)
+	}
+	return sess, release, nil
+}
+
+// lock 等待会话的锁, ctx 结束时放弃等待并返回 ctx.Err(), 比如客户端在前一个请求完成前断开.
+func (s *Server) lock(ctx context.Context, id string) (release func(), err error) {
+	s.mu.Lock()
+	l := s.locks[id]
+	if l == nil {
+		l = &sessionLock{sem: make(chan struct{}, 1)}
+		s.locks[id] = l
+	}
+	l.refs++
+	s.mu.Unlock()
+
+	unref := func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if l.refs--; l.refs == 0 {
+			delete(s.locks, id)
+		}
+	}
+
+	select {
+	case l.sem <- struct{}{}:
+	case <-ctx.Done():
+		unref()
+		return nil, ctx.Err()
+	}
+	return func() {
+		<-l.sem
+		unref()
+	}, nil
+}
+
+func (s *Server) saveSession(ctx context.Context, sess *session.Session, msgs []*schema.Message) error {
+	sess.Model = s.conf.Model
+	sess.Persona = s.conf.Persona
+	sess.Messages = msgs
+	if err := s.conf.Sessions.Save(ctx, sess); err != nil {
+		return fmt.Errorf("save session %s: %w", sess.ID, err)
+	}
+	return nil
+}
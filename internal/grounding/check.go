@@ -0,0 +1,422 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package grounding
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// claim kinds
+const (
+	KindRestaurant = "restaurant"
+	KindDish       = "dish"
+	KindPrice      = "price"
+)
+
+// Claim is a restaurant, dish or price mentioned in the answer.
+type Claim struct {
+	Kind      string
+	Value     string
+	Supported bool
+	Reason    string // 不成立的原因
+}
+
+// Report is the outcome of Check.
+type Report struct {
+	Claims []Claim
+}
+
+// Unsupported returns the claims the tool results don't back.
+func (r *Report) Unsupported() []Claim {
+	var res []Claim
+	for _, c := range r.Claims {
+		if !c.Supported {
+			res = append(res, c)
+		}
+	}
+	return res
+}
+
+// Grounded reports whether every claim is backed by the tool results.
+func (r *Report) Grounded() bool {
+	return len(r.Unsupported()) == 0
+}
+
+func (r *Report) String() string {
+	bad := r.Unsupported()
+	var b strings.Builder
+	fmt.Fprintf(&b, "grounding: %d claims, %d unsupported", len(r.Claims), len(bad))
+	for _, c := range bad {
+		fmt.Fprintf(&b, "\n  - %s %q: %s", c.Kind, c.Value, c.Reason)
+	}
+	return b.String()
+}
+
+// Feedback is the message asking the model to answer again without the unsupported claims.
+func (r *Report) Feedback() *schema.Message {
+	var b strings.Builder
+	b.WriteString("Your answer mentions things the tools did not return:\n")
+	for _, c := range r.Unsupported() {
+		fmt.Fprintf(&b, "- %s %q: %s\n", c.Kind, c.Value, c.Reason)
+	}
+	b.WriteString("Answer again using only restaurants, dishes and prices from the tool results, call the tools again if you need more data.")
+	return schema.UserMessage(b.String())
+}
+
+// Check extracts the restaurants, dishes and prices mentioned in answer and checks them
+// against the tool results. A structured recommendation (a JSON object with "restaurants")
+// is checked field by field; for free text, names in bold or at the start of list items
+// and amounts with a currency are checked.
+func Check(answer string, results []ToolResult) *Report {
+	f := collectFacts(results)
+	if rec, ok := parseRecommendation(answer); ok {
+		return f.checkRecommendation(rec)
+	}
+	return f.checkText(answer)
+}
+
+// entity 工具结果中的餐厅或菜品.
+type entity struct {
+	id, name string
+	prices   []float64
+	currency []string
+}
+
+type facts struct {
+	restaurants map[string]*entity // by id
+	dishes      map[string]*entity // by id
+	names       map[string]string  // 规范化的名称 -> 种类, 包括地点
+	prices      []float64
+	raw         []string // 不是 JSON 的工具结果, 如 markdown 格式
+}
+
+func collectFacts(results []ToolResult) *facts {
+	f := &facts{
+		restaurants: map[string]*entity{},
+		dishes:      map[string]*entity{},
+		names:       map[string]string{},
+	}
+	for _, r := range results {
+		var v any
+		if err := json.Unmarshal([]byte(r.Output), &v); err != nil {
+			f.raw = append(f.raw, strings.ToLower(r.Output))
+			continue
+		}
+		f.walk(v)
+	}
+	return f
+}
+
+func (f *facts) walk(v any) {
+	switch x := v.(type) {
+	case []any:
+		for _, e := range x {
+			f.walk(e)
+		}
+	case map[string]any:
+		name, _ := x["name"].(string)
+		if name == "" {
+			for _, e := range x {
+				f.walk(e)
+			}
+			return
+		}
+
+		e := &entity{name: name}
+		e.id, _ = x["id"].(string)
+		for _, k := range []string{"price", "user_price"} {
+			if p, ok := x[k].(float64); ok {
+				e.prices = append(e.prices, p)
+				f.prices = append(f.prices, p)
+			}
+		}
+		for _, k := range []string{"currency", "user_currency"} {
+			if c, ok := x[k].(string); ok && c != "" {
+				e.currency = append(e.currency, c)
+			}
+		}
+
+		// 有价格的是菜品, 其他是餐厅
+		if _, isDish := x["price"]; isDish {
+			f.names[normalize(name)] = KindDish
+			if e.id != "" {
+				f.dishes[e.id] = e
+			}
+		} else {
+			f.names[normalize(name)] = KindRestaurant
+			if e.id != "" {
+				f.restaurants[e.id] = e
+			}
+		}
+		if place, ok := x["place"].(string); ok && place != "" {
+			f.names[normalize(place)] = "place"
+		}
+	}
+}
+
+// knownName reports whether name, or a close variant, was returned by a tool.
+func (f *facts) knownName(name string) bool {
+	n := normalize(name)
+	if n == "" {
+		return false
+	}
+	if _, ok := f.names[n]; ok {
+		return true
+	}
+	for known := range f.names {
+		if len(known) >= 4 && len(n) >= 4 && (strings.Contains(n, known) || strings.Contains(known, n)) {
+			return true
+		}
+	}
+	for _, raw := range f.raw {
+		if strings.Contains(raw, strings.ToLower(strings.TrimSpace(name))) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *facts) knownPrice(p float64) bool {
+	for _, known := range f.prices {
+		if samePrice(p, known) {
+			return true
+		}
+	}
+	for _, raw := range f.raw {
+		for _, m := range number.FindAllString(raw, -1) {
+			if known, err := strconv.ParseFloat(strings.ReplaceAll(m, ",", ""), 64); err == nil && samePrice(p, known) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// samePrice allows the rounding models do when they quote converted prices.
+func samePrice(a, b float64) bool {
+	return math.Abs(a-b) <= math.Max(0.01, math.Abs(b)*0.005)
+}
+
+type recommendation struct {
+	Restaurants []struct {
+		ID     string `json:"id"`
+		Name   string `json:"name"`
+		Dishes []struct {
+			ID       string   `json:"id"`
+			Name     string   `json:"name"`
+			Price    *float64 `json:"price"`
+			Currency string   `json:"currency"`
+		} `json:"dishes"`
+	} `json:"restaurants"`
+}
+
+func parseRecommendation(answer string) (*recommendation, bool) {
+	start, end := strings.IndexByte(answer, '{'), strings.LastIndexByte(answer, '}')
+	if start < 0 || end < start {
+		return nil, false
+	}
+
+	var probe map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(answer[start:end+1]), &probe); err != nil {
+		return nil, false
+	}
+	if _, ok := probe["restaurants"]; !ok {
+		return nil, false
+	}
+
+	rec := &recommendation{}
+	if err := json.Unmarshal([]byte(answer[start:end+1]), rec); err != nil {
+		return nil, false
+	}
+	return rec, true
+}
+
+func (f *facts) checkRecommendation(rec *recommendation) *Report {
+	r := &Report{}
+	for _, rest := range rec.Restaurants {
+		r.Claims = append(r.Claims, f.checkEntity(KindRestaurant, f.restaurants, rest.ID, rest.Name))
+
+		for _, dish := range rest.Dishes {
+			r.Claims = append(r.Claims, f.checkEntity(KindDish, f.dishes, dish.ID, dish.Name))
+			if dish.Price == nil {
+				continue
+			}
+
+			c := Claim{Kind: KindPrice, Value: fmt.Sprintf("%v %s (%s)", *dish.Price, dish.Currency, dish.Name), Supported: true}
+			if e, ok := f.dishes[dish.ID]; ok {
+				if !matchesPrice(e, *dish.Price, dish.Currency) {
+					c.Supported = false
+					c.Reason = fmt.Sprintf("query_dishes returned %s", describePrices(e))
+				}
+			} else if !f.knownPrice(*dish.Price) {
+				c.Supported = false
+				c.Reason = "no tool returned this price"
+			}
+			r.Claims = append(r.Claims, c)
+		}
+	}
+	return r
+}
+
+// checkEntity checks the id and name of a restaurant or dish of a structured answer.
+func (f *facts) checkEntity(kind string, byID map[string]*entity, id, name string) Claim {
+	c := Claim{Kind: kind, Value: name, Supported: true}
+	if id != "" {
+		c.Value = fmt.Sprintf("%s [%s]", name, id)
+	}
+
+	e, ok := byID[id]
+	switch {
+	case ok && normalize(e.name) != normalize(name):
+		c.Supported = false
+		c.Reason = fmt.Sprintf("id %s belongs to %q", id, e.name)
+	case !ok && id != "":
+		c.Supported = false
+		c.Reason = "no tool returned this id"
+	case !ok && !f.knownName(name):
+		c.Supported = false
+		c.Reason = "no tool returned this name"
+	}
+	return c
+}
+
+func matchesPrice(e *entity, price float64, currency string) bool {
+	for i, p := range e.prices {
+		if !samePrice(price, p) {
+			continue
+		}
+		if currency == "" || i >= len(e.currency) || strings.EqualFold(e.currency[i], currency) {
+			return true
+		}
+	}
+	return false
+}
+
+func describePrices(e *entity) string {
+	parts := make([]string, 0, len(e.prices))
+	for i, p := range e.prices {
+		s := strconv.FormatFloat(math.Round(p*100)/100, 'f', -1, 64)
+		if i < len(e.currency) {
+			s += " " + e.currency[i]
+		}
+		parts = append(parts, s)
+	}
+	return strings.Join(parts, " / ")
+}
+
+var (
+	bold     = regexp.MustCompile(`\*\*([^*\n]{2,80})\*\*`)
+	listItem = regexp.MustCompile(`(?m)^\s*(?:[-*•]|\d+[.)])\s+([^:：,，\n(（\-–—]{2,80})`)
+	number   = regexp.MustCompile(`\d+(?:,\d{3})*(?:\.\d+)?`)
+	amount   = regexp.MustCompile(`(?i)(?:(?:[A-Z]{2}\$|[¥￥$€£]|\b(?:CNY|RMB|USD|EUR|GBP|JPY|SGD|HKD|IDR|Rp)\b)\s?(\d+(?:,\d{3})*(?:\.\d+)?))|(?:(\d+(?:,\d{3})*(?:\.\d+)?)\s?(?:元|块|\b(?:yuan|CNY|RMB|USD|EUR|GBP|JPY|SGD|HKD|IDR)\b))`)
+)
+
+// labels 加粗或列表中常见的非实体词, 这些候选不做检查.
+var labels = []string{"recommend", "dish", "restaurant", "price", "score", "rating", "note", "tip", "summary",
+	"option", "menu", "address", "location", "why", "reason", "description", "total", "budget"}
+
+func (f *facts) checkText(answer string) *Report {
+	r := &Report{}
+	seen := map[string]bool{}
+
+	var candidates []string
+	for _, m := range bold.FindAllStringSubmatch(answer, -1) {
+		candidates = append(candidates, m[1])
+	}
+	// list items are matched without the bold markers, so "- **Mapo Tofu** ¥18" gives "Mapo Tofu"
+	for _, m := range listItem.FindAllStringSubmatch(strings.ReplaceAll(answer, "**", ""), -1) {
+		candidates = append(candidates, m[1])
+	}
+
+	for _, cand := range candidates {
+		name := strings.Trim(amount.ReplaceAllString(cand, ""), " \t*_`'\",.:;!?")
+		key := normalize(name)
+		if !looksLikeName(name) || seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		c := Claim{Kind: f.kindOf(name), Value: name, Supported: f.knownName(name)}
+		if !c.Supported {
+			c.Reason = "no tool returned this name"
+		}
+		r.Claims = append(r.Claims, c)
+	}
+
+	for _, m := range amount.FindAllStringSubmatch(answer, -1) {
+		s := m[1]
+		if s == "" {
+			s = m[2]
+		}
+		p, err := strconv.ParseFloat(strings.ReplaceAll(s, ",", ""), 64)
+		if err != nil || seen["price:"+s] {
+			continue
+		}
+		seen["price:"+s] = true
+
+		c := Claim{Kind: KindPrice, Value: strings.TrimSpace(m[0]), Supported: f.knownPrice(p)}
+		if !c.Supported {
+			c.Reason = "no tool returned this price"
+		}
+		r.Claims = append(r.Claims, c)
+	}
+	return r
+}
+
+func (f *facts) kindOf(name string) string {
+	if kind, ok := f.names[normalize(name)]; ok && kind != "place" {
+		return kind
+	}
+	return KindDish // 编造的名称多数是菜名
+}
+
+// looksLikeName skips labels like "Recommended dishes:" and sentences in bold.
+func looksLikeName(s string) bool {
+	if s == "" || strings.Count(s, " ") > 6 {
+		return false
+	}
+	first := []rune(s)[0]
+	if !unicode.IsUpper(first) && first < unicode.MaxASCII {
+		return false
+	}
+
+	lower := strings.ToLower(s)
+	for _, l := range labels {
+		if strings.Contains(lower, l) {
+			return false
+		}
+	}
+	return true
+}
+
+func normalize(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,120 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package grounding
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	results := []ToolResult{
+		{Tool: "query_restaurants", Output: `[{"id":"1001","name":"Sichuan House","place":"Chengdu"}]`},
+		{Tool: "query_dishes", Output: `[{"id":"2001","name":"Mapo Tofu","price":18,"currency":"CNY","user_price":2.5,"user_currency":"USD"}]`},
+		{Tool: "search", Output: "| Noodle Bar | ¥12 |"},
+	}
+
+	tests := []struct {
+		name        string
+		answer      string
+		unsupported []string // 不成立的声明的值
+	}{
+		{
+			name:   "text grounded",
+			answer: "I'd go to **Sichuan House** and order the **Mapo Tofu** for ¥18.",
+		},
+		{
+			name:   "text converted price",
+			answer: "**Mapo Tofu** costs about $2.50.",
+		},
+		{
+			name:   "text from a markdown result",
+			answer: "**Noodle Bar** has noodles for 12 yuan.",
+		},
+		{
+			name:        "text made-up restaurant",
+			answer:      "**Golden Dragon** is a great place nearby.",
+			unsupported: []string{"Golden Dragon"},
+		},
+		{
+			name:        "text made-up price",
+			answer:      "**Mapo Tofu** for ¥25.",
+			unsupported: []string{"¥25"},
+		},
+		{
+			name:        "text made-up list item",
+			answer:      "Try these:\n- Kung Pao Chicken: ¥32\n- Mapo Tofu: ¥18",
+			unsupported: []string{"Kung Pao Chicken", "¥32"},
+		},
+		{
+			name:   "structured grounded",
+			answer: `{"restaurants":[{"id":"1001","name":"Sichuan House","dishes":[{"id":"2001","name":"Mapo Tofu","price":18,"currency":"CNY"}]}]}`,
+		},
+		{
+			name:   "structured converted price",
+			answer: `{"restaurants":[{"id":"1001","name":"Sichuan House","dishes":[{"id":"2001","name":"Mapo Tofu","price":2.5,"currency":"USD"}]}]}`,
+		},
+		{
+			name:   "structured in prose",
+			answer: "Here you go:\n```json\n{\"restaurants\":[{\"id\":\"1001\",\"name\":\"Sichuan House\",\"dishes\":[]}]}\n```",
+		},
+		{
+			name:        "structured made-up restaurant",
+			answer:      `{"restaurants":[{"id":"1009","name":"Golden Dragon","dishes":[]}]}`,
+			unsupported: []string{"Golden Dragon [1009]"},
+		},
+		{
+			name:        "structured name of another id",
+			answer:      `{"restaurants":[{"id":"1001","name":"Golden Dragon","dishes":[]}]}`,
+			unsupported: []string{"Golden Dragon [1001]"},
+		},
+		{
+			name:        "structured made-up dish",
+			answer:      `{"restaurants":[{"name":"Sichuan House","dishes":[{"name":"Kung Pao Chicken"}]}]}`,
+			unsupported: []string{"Kung Pao Chicken"},
+		},
+		{
+			name:        "structured made-up price",
+			answer:      `{"restaurants":[{"id":"1001","name":"Sichuan House","dishes":[{"id":"2001","name":"Mapo Tofu","price":25,"currency":"CNY"}]}]}`,
+			unsupported: []string{"25 CNY (Mapo Tofu)"},
+		},
+		{
+			name:        "structured price in another currency",
+			answer:      `{"restaurants":[{"id":"1001","name":"Sichuan House","dishes":[{"id":"2001","name":"Mapo Tofu","price":18,"currency":"USD"}]}]}`,
+			unsupported: []string{"18 USD (Mapo Tofu)"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := Check(tt.answer, results)
+			var got []string
+			for _, c := range report.Unsupported() {
+				got = append(got, c.Value)
+			}
+			if !slices.Equal(got, tt.unsupported) {
+				t.Errorf("unsupported %q, want %q\n%s", got, tt.unsupported, report)
+			}
+			if len(report.Claims) == 0 {
+				t.Error("no claims extracted")
+			}
+			if report.Grounded() != (len(tt.unsupported) == 0) {
+				t.Errorf("Grounded() = %v", report.Grounded())
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package grounding checks that an answer only cites restaurants, dishes and
+// prices the tools actually returned.
+package grounding
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+)
+
+// ToolResult is the output of one tool call.
+type ToolResult struct {
+	Tool   string
+	Output string
+}
+
+// Collector records the tool results of agent runs through callbacks.
+type Collector struct {
+	mu      sync.Mutex
+	results []ToolResult
+	wg      sync.WaitGroup // 还在读的流式结果
+}
+
+// NewCollector creates an empty collector.
+func NewCollector() *Collector {
+	return &Collector{}
+}
+
+// Handler returns the callback handler to pass with compose.WithCallbacks.
+func (c *Collector) Handler() callbacks.Handler {
+	return callbacks.NewHandlerBuilder().
+		OnEndFn(func(ctx context.Context, info *callbacks.RunInfo, output callbacks.CallbackOutput) context.Context {
+			if info == nil || info.Component != components.ComponentOfTool {
+				return ctx
+			}
+			if out := tool.ConvCallbackOutput(output); out != nil {
+				c.add(info.Name, out.Response)
+			}
+			return ctx
+		}).
+		OnEndWithStreamOutputFn(func(ctx context.Context, info *callbacks.RunInfo, output *schema.StreamReader[callbacks.CallbackOutput]) context.Context {
+			if info == nil || info.Component != components.ComponentOfTool {
+				output.Close()
+				return ctx
+			}
+
+			// streamable tools, the handler gets its own copy of the stream
+			c.wg.Add(1)
+			go func() {
+				defer c.wg.Done()
+				defer output.Close()
+				var b strings.Builder
+				for {
+					chunk, err := output.Recv()
+					if errors.Is(err, io.EOF) {
+						break
+					}
+					if err != nil {
+						return
+					}
+					if out := tool.ConvCallbackOutput(chunk); out != nil {
+						b.WriteString(out.Response)
+					}
+				}
+				c.add(info.Name, b.String())
+			}()
+			return ctx
+		}).
+		Build()
+}
+
+func (c *Collector) add(name, output string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.results = append(c.results, ToolResult{Tool: name, Output: output})
+}
+
+// Results returns the tool results recorded so far, after waiting for the streamed results
+// still being read.
+func (c *Collector) Results() []ToolResult {
+	c.wg.Wait()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]ToolResult(nil), c.results...)
+}
+
+// Reset forgets the recorded results, e.g. when a new conversation starts.
+func (c *Collector) Reset() {
+	c.wg.Wait()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.results = nil
+}
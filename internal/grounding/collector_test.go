@@ -0,0 +1,48 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package grounding
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+)
+
+func TestCollectorWaitsForStreams(t *testing.T) {
+	c := NewCollector()
+	sr, sw := schema.Pipe[callbacks.CallbackOutput](1)
+	c.Handler().OnEndWithStreamOutput(context.Background(),
+		&callbacks.RunInfo{Name: "query_dishes", Component: components.ComponentOfTool}, sr)
+
+	// 结果在 Results 调用之后才读完
+	go func() {
+		defer sw.Close()
+		time.Sleep(50 * time.Millisecond)
+		sw.Send(&tool.CallbackOutput{Response: `[{"name":"Mapo Tofu",`}, nil)
+		sw.Send(&tool.CallbackOutput{Response: `"price":18}]`}, nil)
+	}()
+
+	got := c.Results()
+	if len(got) != 1 || got[0].Output != `[{"name":"Mapo Tofu","price":18}]` {
+		t.Errorf("Results() = %+v, want the whole streamed result", got)
+	}
+}
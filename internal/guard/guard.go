@@ -0,0 +1,220 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package guard stops an agent run that loops on tool calls or exceeds its budget,
+// and makes the model answer with what it has instead of failing the run.
+package guard
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMaxRepeats = 2
+	defaultMaxPeriod  = 3
+	// defaultMaxRounds 不限制工具调用次数时每轮允许的模型调用次数, 之后强制回答.
+	defaultMaxRounds = 10
+)
+
+// Config is the budget of one run.
+type Config struct {
+	// MaxToolCalls is the number of tool calls allowed per run, 0 means unlimited. The model
+	// is still made to answer after 10 rounds then, so the run fits in MaxStep.
+	MaxToolCalls int
+	// MaxDuration is the wall-clock budget per run, 0 means unlimited. Model and tool calls
+	// still running when it expires are cancelled and the model is made to answer.
+	MaxDuration time.Duration
+	// MaxRepeats is how many times a tool may be called with the same arguments, default 2.
+	MaxRepeats int
+	// MaxPeriod is the longest cycle detected, e.g. 2 catches A B A B, default 3.
+	MaxPeriod int
+}
+
+// Guard wraps the model and the tools of an agent, see Start.
+type Guard struct {
+	conf Config
+}
+
+// New creates a guard.
+func New(conf *Config) *Guard {
+	c := *conf
+	if c.MaxRepeats <= 0 {
+		c.MaxRepeats = defaultMaxRepeats
+	}
+	if c.MaxPeriod <= 0 {
+		c.MaxPeriod = defaultMaxPeriod
+	}
+	return &Guard{conf: c}
+}
+
+// MaxStep returns a react.AgentConfig.MaxStep large enough for the forced answer to be
+// reached before the graph's own step limit fails the run.
+func (g *Guard) MaxStep() int {
+	rounds := g.conf.MaxToolCalls
+	if rounds <= 0 {
+		rounds = defaultMaxRounds
+	}
+	// every round is a model step and a tools step, the last round is the forced answer
+	return 2*(rounds+1) + 2
+}
+
+type runKey struct{}
+
+// Start begins a run; pass the returned context to the agent. Models and tools wrapped by
+// the guard only enforce the budget within a started run.
+func (g *Guard) Start(ctx context.Context) (context.Context, *Run) {
+	r := &Run{conf: g.conf, start: time.Now()}
+	if g.conf.MaxDuration > 0 {
+		r.deadline = r.start.Add(g.conf.MaxDuration)
+	}
+	return context.WithValue(ctx, runKey{}, r), r
+}
+
+// RunFrom returns the run started on ctx, nil if there is none.
+func RunFrom(ctx context.Context) *Run {
+	r, _ := ctx.Value(runKey{}).(*Run)
+	return r
+}
+
+// Run tracks the tool calls of one agent run.
+type Run struct {
+	conf     Config
+	start    time.Time
+	deadline time.Time
+
+	mu      sync.Mutex
+	calls   []string       // 已执行调用的签名, 按顺序
+	counts  map[string]int // 签名 -> 次数
+	refused int
+	rounds  int    // 模型调用次数
+	reason  string // 停止的原因, 为空表示还没有停止
+}
+
+// Stopped returns why the run was stopped, empty while it may still call tools.
+func (r *Run) Stopped() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checkTime()
+	return r.reason
+}
+
+// ToolCalls returns the number of tool calls run and refused.
+func (r *Run) ToolCalls() (run, refused int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.calls), r.refused
+}
+
+// Elapsed returns the time since the run started.
+func (r *Run) Elapsed() time.Duration {
+	return time.Since(r.start)
+}
+
+func (r *Run) checkTime() {
+	if r.reason == "" && !r.deadline.IsZero() && time.Now().After(r.deadline) {
+		r.reason = fmt.Sprintf("the time budget of %s is used up", r.conf.MaxDuration)
+	}
+}
+
+// round 记录一次模型调用; 不限制工具调用次数时, 用完 defaultMaxRounds 后停止.
+func (r *Run) round() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.rounds++
+	if r.reason == "" && r.conf.MaxToolCalls <= 0 && r.rounds > defaultMaxRounds {
+		r.reason = fmt.Sprintf("the budget of %d model rounds is used up", defaultMaxRounds)
+	}
+}
+
+// admit records the call if it may run, otherwise stops the run and returns the reason.
+func (r *Run) admit(name, args string) (reason string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.checkTime()
+	if r.reason != "" {
+		r.refused++
+		return r.reason
+	}
+
+	sig := signature(name, args)
+	switch {
+	case r.conf.MaxToolCalls > 0 && len(r.calls) >= r.conf.MaxToolCalls:
+		r.reason = fmt.Sprintf("the budget of %d tool calls is used up", r.conf.MaxToolCalls)
+	case r.counts[sig] >= r.conf.MaxRepeats:
+		r.reason = fmt.Sprintf("%s was already called %d times with the same arguments", name, r.counts[sig])
+	default:
+		if cycle := r.cycle(sig); cycle != "" {
+			r.reason = "the tool calls are going in circles: " + cycle
+		}
+	}
+	if r.reason != "" {
+		r.refused++
+		return r.reason
+	}
+
+	if r.counts == nil {
+		r.counts = map[string]int{}
+	}
+	r.counts[sig]++
+	r.calls = append(r.calls, sig)
+	return ""
+}
+
+// cycle reports whether sig completes a pattern of period 2..MaxPeriod repeated twice,
+// such as A B A B. Period 1 is left to MaxRepeats.
+func (r *Run) cycle(sig string) string {
+	seq := append(r.calls[:len(r.calls):len(r.calls)], sig)
+	for p := 2; p <= r.conf.MaxPeriod; p++ {
+		if len(seq) < 2*p {
+			break
+		}
+		last, prev := seq[len(seq)-p:], seq[len(seq)-2*p:len(seq)-p]
+		same := true
+		for i := range last {
+			if last[i] != prev[i] {
+				same = false
+				break
+			}
+		}
+		if same {
+			calls := make([]string, 0, 2*p)
+			for _, s := range seq[len(seq)-2*p:] {
+				name, args, _ := strings.Cut(s, " ")
+				calls = append(calls, name+"("+args+")")
+			}
+			return strings.Join(calls, " → ")
+		}
+	}
+	return ""
+}
+
+// signature 工具名加上规范化的参数, 参数中字段的顺序和空白不影响结果.
+func signature(name, args string) string {
+	var v any
+	if err := json.Unmarshal([]byte(args), &v); err == nil {
+		if b, err := json.Marshal(v); err == nil {
+			args = string(b)
+		}
+	}
+	return name + " " + args
+}
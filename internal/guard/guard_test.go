@@ -0,0 +1,233 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package guard
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+)
+
+// slowModel 被强制回答时立即回答, 否则一直等到 ctx 结束.
+type slowModel struct{}
+
+func isForced(input []*schema.Message) bool {
+	last := input[len(input)-1]
+	return last.Role == schema.User && strings.HasPrefix(last.Content, "Stop calling tools")
+}
+
+func (slowModel) Generate(ctx context.Context, input []*schema.Message, _ ...model.Option) (*schema.Message, error) {
+	if isForced(input) {
+		return schema.AssistantMessage("forced answer", nil), nil
+	}
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (m slowModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	msg, err := m.Generate(ctx, input, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return schema.StreamReaderFromArray([]*schema.Message{msg}), nil
+}
+
+func (slowModel) BindTools([]*schema.ToolInfo) error { return nil }
+
+// slowStreamModel 立即返回流, 但流中的第一个分块一直等到 ctx 结束.
+type slowStreamModel struct {
+	slowModel
+}
+
+func (m slowStreamModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	if isForced(input) {
+		return m.slowModel.Stream(ctx, input, opts...)
+	}
+	sr, sw := schema.Pipe[*schema.Message](1)
+	go func() {
+		defer sw.Close()
+		<-ctx.Done()
+		sw.Send(nil, ctx.Err())
+	}()
+	return sr, nil
+}
+
+func TestMaxStep(t *testing.T) {
+	if got := New(&Config{MaxToolCalls: 3}).MaxStep(); got != 10 {
+		t.Errorf("MaxStep with 3 tool calls = %d, want 10", got)
+	}
+	if got := New(&Config{}).MaxStep(); got != 2*(defaultMaxRounds+1)+2 {
+		t.Errorf("MaxStep without a tool call limit = %d, want room for %d rounds", got, defaultMaxRounds)
+	}
+}
+
+func TestUnlimitedToolCallsForcedAfterRounds(t *testing.T) {
+	g := New(&Config{})
+	ctx, run := g.Start(context.Background())
+	for range defaultMaxRounds {
+		run.round()
+	}
+	if reason := run.Stopped(); reason != "" {
+		t.Fatalf("stopped after %d rounds: %s", defaultMaxRounds, reason)
+	}
+
+	msg, err := g.WrapModel(slowModel{}).Generate(ctx, []*schema.Message{schema.UserMessage("hi")})
+	if err != nil || msg.Content != "forced answer" {
+		t.Errorf("got %v, %v, want the forced answer", msg, err)
+	}
+}
+
+func TestMaxDurationBoundsModel(t *testing.T) {
+	g := New(&Config{MaxDuration: 100 * time.Millisecond})
+	m := g.WrapModel(slowModel{})
+	input := []*schema.Message{schema.UserMessage("hi")}
+
+	t.Run("generate", func(t *testing.T) {
+		ctx, _ := g.Start(context.Background())
+		msg, err := m.Generate(ctx, input)
+		if err != nil || msg.Content != "forced answer" {
+			t.Errorf("got %v, %v, want the forced answer", msg, err)
+		}
+	})
+
+	for name, cm := range map[string]model.ChatModel{"stream": slowModel{}, "stream recv": slowStreamModel{}} {
+		t.Run(name, func(t *testing.T) {
+			ctx, _ := g.Start(context.Background())
+			sr, err := g.WrapModel(cm).Stream(ctx, input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer sr.Close()
+			msg, err := sr.Recv()
+			if err != nil || msg.Content != "forced answer" {
+				t.Errorf("got %v, %v, want the forced answer", msg, err)
+			}
+		})
+	}
+}
+
+// stubbornModel 被强制回答时仍然调用工具, 自己向回调报告输出.
+type stubbornModel struct {
+	slowModel
+}
+
+func (stubbornModel) IsCallbacksEnabled() bool { return true }
+
+func (stubbornModel) answer() *schema.Message {
+	return schema.AssistantMessage("forced answer", []schema.ToolCall{{
+		ID:       "call_1",
+		Function: schema.FunctionCall{Name: "query_dishes", Arguments: `{}`},
+	}})
+}
+
+func (m stubbornModel) Generate(ctx context.Context, input []*schema.Message, _ ...model.Option) (*schema.Message, error) {
+	ctx = callbacks.OnStart(ctx, &model.CallbackInput{Messages: input})
+	msg := m.answer()
+	callbacks.OnEnd(ctx, &model.CallbackOutput{Message: msg})
+	return msg, nil
+}
+
+func (m stubbornModel) Stream(ctx context.Context, input []*schema.Message, _ ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	ctx = callbacks.OnStart(ctx, &model.CallbackInput{Messages: input})
+	_, out := callbacks.OnEndWithStreamOutput(ctx, schema.StreamReaderFromArray([]*model.CallbackOutput{{Message: m.answer()}}))
+	return schema.StreamReaderWithConvert(out, func(o *model.CallbackOutput) (*schema.Message, error) {
+		return o.Message, nil
+	}), nil
+}
+
+func TestCallbacksSeeGuardedOutput(t *testing.T) {
+	g := New(&Config{})
+	chain := compose.NewChain[[]*schema.Message, *schema.Message]()
+	chain.AppendChatModel(g.WrapModel(stubbornModel{}))
+	r, err := chain.Compile(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := []*schema.Message{schema.UserMessage("hi")}
+
+	for _, stream := range []bool{false, true} {
+		t.Run(map[bool]string{false: "generate", true: "stream"}[stream], func(t *testing.T) {
+			ctx, run := g.Start(context.Background())
+			for range defaultMaxRounds {
+				run.round()
+			}
+
+			// 回调看到的是去掉工具调用后的强制回答
+			got := make(chan *schema.Message, 1)
+			handler := callbacks.NewHandlerBuilder().
+				OnEndFn(func(ctx context.Context, info *callbacks.RunInfo, output callbacks.CallbackOutput) context.Context {
+					if info.Component != components.ComponentOfChatModel {
+						return ctx
+					}
+					got <- model.ConvCallbackOutput(output).Message
+					return ctx
+				}).
+				OnEndWithStreamOutputFn(func(ctx context.Context, info *callbacks.RunInfo, output *schema.StreamReader[callbacks.CallbackOutput]) context.Context {
+					defer output.Close()
+					if info.Component != components.ComponentOfChatModel {
+						return ctx
+					}
+					var msgs []*schema.Message
+					for {
+						o, err := output.Recv()
+						if err != nil {
+							break
+						}
+						msgs = append(msgs, model.ConvCallbackOutput(o).Message)
+					}
+					msg, err := schema.ConcatMessages(msgs)
+					if err != nil {
+						t.Error(err)
+					}
+					got <- msg
+					return ctx
+				}).
+				Build()
+
+			if stream {
+				sr, err := r.Stream(ctx, input, compose.WithCallbacks(handler))
+				if err != nil {
+					t.Fatal(err)
+				}
+				for {
+					if _, err := sr.Recv(); err != nil {
+						break
+					}
+				}
+				sr.Close()
+			} else if _, err := r.Invoke(ctx, input, compose.WithCallbacks(handler)); err != nil {
+				t.Fatal(err)
+			}
+
+			select {
+			case msg := <-got:
+				if msg == nil || msg.Content != "forced answer" || len(msg.ToolCalls) != 0 {
+					t.Errorf("callbacks got %v, want the forced answer without tool calls", msg)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("the callbacks didn't see the model output")
+			}
+		})
+	}
+}
@@ -0,0 +1,330 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package guard
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/galihrivanto/eino-exp/internal/logs"
+	"github.com/galihrivanto/eino-exp/internal/toolmw"
+)
+
+// FallbackAnswer is used when the forced answer comes back empty.
+const FallbackAnswer = "Sorry, I couldn't finish looking this up. Please try again with a more specific question."
+
+// WrapTools wraps every invokable tool in tools, other tools are returned as they are.
+// A refused call isn't run, the reason is returned to the model as the tool result.
+func (g *Guard) WrapTools(ctx context.Context, tools []tool.BaseTool) ([]tool.BaseTool, error) {
+	res := make([]tool.BaseTool, 0, len(tools))
+	for _, t := range tools {
+		it, ok := t.(tool.InvokableTool)
+		if !ok {
+			res = append(res, t)
+			continue
+		}
+
+		info, err := it.Info(ctx)
+		if err != nil {
+			return nil, err
+		}
+		gt := &guardedTool{InvokableTool: it, name: info.Name}
+		if st, ok := it.(tool.StreamableTool); ok {
+			res = append(res, toolmw.Streamable(gt, func(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (*schema.StreamReader[string], error) {
+				return gt.streamableRun(ctx, st, argumentsInJSON, opts...)
+			}))
+			continue
+		}
+		res = append(res, gt)
+	}
+	return res, nil
+}
+
+type guardedTool struct {
+	tool.InvokableTool
+	name string
+}
+
+func (t *guardedTool) InvokableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (string, error) {
+	r := RunFrom(ctx)
+	if r == nil {
+		return t.InvokableTool.InvokableRun(ctx, argumentsInJSON, opts...)
+	}
+
+	if refusal := t.admit(r, argumentsInJSON); refusal != "" {
+		return refusal, nil
+	}
+
+	if !r.deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, r.deadline)
+		defer cancel()
+	}
+	return t.InvokableTool.InvokableRun(ctx, argumentsInJSON, opts...)
+}
+
+func (t *guardedTool) streamableRun(ctx context.Context, st tool.StreamableTool, argumentsInJSON string, opts ...tool.Option) (*schema.StreamReader[string], error) {
+	r := RunFrom(ctx)
+	if r == nil {
+		return st.StreamableRun(ctx, argumentsInJSON, opts...)
+	}
+
+	if refusal := t.admit(r, argumentsInJSON); refusal != "" {
+		return schema.StreamReaderFromArray([]string{refusal}), nil
+	}
+
+	if r.deadline.IsZero() {
+		return st.StreamableRun(ctx, argumentsInJSON, opts...)
+	}
+	ctx, cancel := context.WithDeadline(ctx, r.deadline)
+	sr, err := st.StreamableRun(ctx, argumentsInJSON, opts...)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	return toolmw.Relay(sr, nil, func(err error) (string, error) {
+		cancel()
+		return "", err
+	}), nil
+}
+
+// admit 返回拒绝调用时给模型的结果, 允许时为空.
+func (t *guardedTool) admit(r *Run, argumentsInJSON string) string {
+	reason := r.admit(t.name, argumentsInJSON)
+	if reason == "" {
+		return ""
+	}
+
+	logs.Errorf("refused %s(%s): %s", t.name, argumentsInJSON, reason)
+	b, _ := json.Marshal(map[string]string{
+		"error":   "stopped",
+		"tool":    t.name,
+		"message": reason,
+		"hint":    "don't call any more tools, answer the user with the information you already have",
+	})
+	return string(b)
+}
+
+// WrapModel wraps m so that, once the run is stopped, the model is told to answer now and any
+// tool calls it still makes are dropped. The agent then ends with that answer instead of
+// failing on its step limit.
+func (g *Guard) WrapModel(m model.ChatModel) model.ChatModel {
+	return &guardedModel{ChatModel: m}
+}
+
+type guardedModel struct {
+	model.ChatModel
+}
+
+// IsCallbacksEnabled returns false, so the graph runs the callbacks on the guarded messages: the
+// forced answer without its dropped tool calls. The wrapped model is called without callbacks.
+func (m *guardedModel) IsCallbacksEnabled() bool { return false }
+
+// withoutCallbacks 去掉 ctx 中的 callback handler, 用于调用被包装的模型.
+func withoutCallbacks(ctx context.Context) context.Context {
+	return callbacks.InitCallbacks(ctx, nil)
+}
+
+func (m *guardedModel) GetType() string {
+	if typ, ok := components.GetType(m.ChatModel); ok {
+		return typ
+	}
+	return "Guard"
+}
+
+// forced returns the input with the instruction to answer, or nil while the run may go on.
+func forced(ctx context.Context, input []*schema.Message) []*schema.Message {
+	r := RunFrom(ctx)
+	if r == nil {
+		return nil
+	}
+	reason := r.Stopped()
+	if reason == "" {
+		return nil
+	}
+
+	logs.Infof("forcing the final answer: %s", reason)
+	return append(input[:len(input):len(input)], schema.UserMessage(fmt.Sprintf(
+		"Stop calling tools, %s. Answer my question now with the information you already have, and say briefly what you could not look up.",
+		reason)))
+}
+
+// withBudget 用本轮的时间预算限制 ctx.
+func withBudget(ctx context.Context) (context.Context, context.CancelFunc) {
+	r := RunFrom(ctx)
+	if r == nil || r.deadline.IsZero() {
+		return ctx, func() {}
+	}
+	return context.WithDeadline(ctx, r.deadline)
+}
+
+// overBudget 判断 bounded 是否因为时间预算结束, 而 ctx 本身还有效.
+func overBudget(ctx, bounded context.Context) bool {
+	return ctx.Err() == nil && errors.Is(bounded.Err(), context.DeadlineExceeded)
+}
+
+func (m *guardedModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	if r := RunFrom(ctx); r != nil {
+		r.round()
+	}
+	if in := forced(ctx, input); in != nil {
+		return m.generateForced(ctx, in, opts...)
+	}
+
+	bounded, cancel := withBudget(ctx)
+	defer cancel()
+	msg, err := m.ChatModel.Generate(withoutCallbacks(bounded), input, opts...)
+	if err != nil && overBudget(ctx, bounded) {
+		// the time budget ran out during the call, the forced answer isn't bounded by it
+		if in := forced(ctx, input); in != nil {
+			return m.generateForced(ctx, in, opts...)
+		}
+	}
+	return msg, err
+}
+
+func (m *guardedModel) generateForced(ctx context.Context, in []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	msg, err := m.ChatModel.Generate(withoutCallbacks(ctx), in, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	out := *msg
+	out.ToolCalls = nil
+	if strings.TrimSpace(out.Content) == "" {
+		out.Content = FallbackAnswer
+	}
+	return &out, nil
+}
+
+func (m *guardedModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	if r := RunFrom(ctx); r != nil {
+		r.round()
+	}
+	if in := forced(ctx, input); in != nil {
+		return m.streamForced(ctx, in, opts...)
+	}
+
+	bounded, cancel := withBudget(ctx)
+	sr, err := m.ChatModel.Stream(withoutCallbacks(bounded), input, opts...)
+	if err != nil {
+		cancel()
+		if overBudget(ctx, bounded) {
+			if in := forced(ctx, input); in != nil {
+				return m.streamForced(ctx, in, opts...)
+			}
+		}
+		return nil, err
+	}
+	if bounded == ctx {
+		return sr, nil
+	}
+
+	out, sw := schema.Pipe[*schema.Message](1)
+	go func() {
+		defer cancel()
+		defer sw.Close()
+
+		sent, err := relay(sr, sw)
+		if err == nil || sent || !overBudget(ctx, bounded) {
+			if err != nil {
+				sw.Send(nil, err)
+			}
+			return
+		}
+		// nothing was sent before the time budget ran out, answer with what the run has instead
+		in := forced(ctx, input)
+		if in == nil {
+			sw.Send(nil, err)
+			return
+		}
+		fsr, err := m.streamForced(ctx, in, opts...)
+		if err != nil {
+			sw.Send(nil, err)
+			return
+		}
+		_, _ = relay(fsr, sw)
+	}()
+	return out, nil
+}
+
+// relay 把 sr 转发到 sw 直到结束, 返回是否转发过分块和 sr 的错误; 读取方关闭时返回 nil.
+func relay(sr *schema.StreamReader[*schema.Message], sw *schema.StreamWriter[*schema.Message]) (sent bool, err error) {
+	defer sr.Close()
+	for {
+		chunk, err := sr.Recv()
+		if errors.Is(err, io.EOF) {
+			return sent, nil
+		}
+		if err != nil {
+			return sent, err
+		}
+		if sw.Send(chunk, nil) {
+			return sent, nil
+		}
+		sent = true
+	}
+}
+
+func (m *guardedModel) streamForced(ctx context.Context, in []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	sr, err := m.ChatModel.Stream(withoutCallbacks(ctx), in, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	out, sw := schema.Pipe[*schema.Message](1)
+	go func() {
+		defer sr.Close()
+		defer sw.Close()
+
+		empty := true
+		for {
+			chunk, err := sr.Recv()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				sw.Send(nil, err)
+				return
+			}
+
+			if strings.TrimSpace(chunk.Content) != "" {
+				empty = false
+			}
+			c := *chunk
+			c.ToolCalls = nil
+			if sw.Send(&c, nil) {
+				return
+			}
+		}
+
+		if empty {
+			sw.Send(schema.AssistantMessage(FallbackAnswer, nil), nil)
+		}
+	}()
+	return out, nil
+}
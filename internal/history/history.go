@@ -0,0 +1,315 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package history keeps the conversation handed to the model within a token
+// budget by summarizing the older turns.
+package history
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/flow/agent/react"
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/galihrivanto/eino-exp/internal/logs"
+)
+
+const (
+	defaultKeepRatio = 0.5
+
+	// SummaryPrefix starts the system note that replaces the summarized turns.
+	SummaryPrefix = "Summary of the earlier conversation:\n"
+
+	defaultSummaryPrompt = `You compress conversations between a user and a restaurant recommendation assistant.
+Summarize the conversation below in a few short sentences. Keep every fact that may matter later:
+locations, restaurant and dish names and IDs, prices, the user's preferences and what was already recommended.
+Reply with the summary only.`
+)
+
+// Config 配置历史管理器.
+type Config struct {
+	// Model 用于生成摘要, 不要传入已经绑定了工具的模型.
+	Model model.ChatModel
+	// MaxTokens is the budget for the whole history, system messages included.
+	MaxTokens int
+	// KeepRatio is the share of MaxTokens kept verbatim for the most recent turns, default 0.5.
+	// Fewer turns are kept when the system messages and the summary leave less room.
+	KeepRatio float64
+	// Estimate 估算单条消息的 token 数, 默认为 EstimateTokens.
+	Estimate func(msg *schema.Message) int
+	// Prompt is the system prompt used for summarizing.
+	Prompt string
+}
+
+// Manager trims the history handed to the model, see Modifier.
+type Manager struct {
+	conf *Config
+
+	mu   sync.Mutex
+	last summary // 上一次的摘要, 用于增量摘要
+}
+
+// summary 摘要覆盖的消息数量和这些消息的哈希.
+type summary struct {
+	count int
+	hash  string
+	text  string
+}
+
+// New creates a history manager.
+func New(conf *Config) (*Manager, error) {
+	if conf == nil || conf.Model == nil {
+		return nil, errors.New("history: summary model is required")
+	}
+	if conf.MaxTokens <= 0 {
+		return nil, errors.New("history: MaxTokens must be positive")
+	}
+
+	c := *conf
+	if c.KeepRatio <= 0 || c.KeepRatio >= 1 {
+		c.KeepRatio = defaultKeepRatio
+	}
+	if c.Estimate == nil {
+		c.Estimate = EstimateTokens
+	}
+	if c.Prompt == "" {
+		c.Prompt = defaultSummaryPrompt
+	}
+	return &Manager{conf: &c}, nil
+}
+
+// Modifier returns a react.MessageModifier that hands the history to next and then trims
+// the result, so the system prompt added by next counts against MaxTokens.
+// next may be nil, otherwise it's usually react.NewPersonaModifier.
+func (m *Manager) Modifier(next react.MessageModifier) react.MessageModifier {
+	return func(ctx context.Context, input []*schema.Message) []*schema.Message {
+		if next != nil {
+			input = next(ctx, input)
+		}
+		msgs, err := m.Trim(ctx, input)
+		if err != nil {
+			// 摘要失败时丢弃较早的对话, 总比超出上下文好
+			logs.Errorf("summarize history failed, dropping older turns: %v", err)
+			msgs = m.drop(input)
+		}
+		return msgs
+	}
+}
+
+// Tokens returns the estimated number of tokens of msgs.
+func (m *Manager) Tokens(msgs []*schema.Message) int {
+	n := 0
+	for _, msg := range msgs {
+		n += m.conf.Estimate(msg)
+	}
+	return n
+}
+
+// Trim returns msgs unchanged when they fit in the budget. Otherwise the older turns
+// are replaced with a system note summarizing them; the leading system messages and the
+// most recent turns are kept as they are, and a tool call is never separated from its results.
+func (m *Manager) Trim(ctx context.Context, msgs []*schema.Message) ([]*schema.Message, error) {
+	if m.Tokens(msgs) <= m.conf.MaxTokens {
+		return msgs, nil
+	}
+
+	// 摘要的长度事先不知道: 先不给它留预算, 放不下时按摘要的长度少保留一些最近的轮次,
+	// 再增量摘要一次, 直到放得下或者只剩最后一轮
+	var out []*schema.Message
+	reserve, kept := 0, -1
+	for {
+		head, old, recent := m.split(msgs, reserve)
+		if len(old) == 0 {
+			return msgs, nil
+		}
+		if len(recent) == kept {
+			return out, nil
+		}
+
+		text, err := m.summarize(ctx, old)
+		if err != nil {
+			return nil, err
+		}
+
+		note := schema.SystemMessage(SummaryPrefix + text)
+		out = make([]*schema.Message, 0, len(head)+1+len(recent))
+		out = append(out, head...)
+		out = append(out, note)
+		out = append(out, recent...)
+		if m.Tokens(out) <= m.conf.MaxTokens {
+			return out, nil
+		}
+		reserve, kept = max(reserve, m.conf.Estimate(note)), len(recent)
+	}
+}
+
+// split 分成开头的系统消息, 需要摘要的较早消息和原样保留的最近消息.
+// 最近的消息不超过 MaxTokens*KeepRatio, 也不超过扣除开头的系统消息和 reserve 之后剩下的预算.
+func (m *Manager) split(msgs []*schema.Message, reserve int) (head, old, recent []*schema.Message) {
+	i := 0
+	for i < len(msgs) && msgs[i].Role == schema.System {
+		i++
+	}
+	head = msgs[:i]
+
+	units := groupUnits(msgs[i:])
+	keep := min(int(float64(m.conf.MaxTokens)*m.conf.KeepRatio), m.conf.MaxTokens-m.Tokens(head)-reserve)
+
+	// 从后往前保留完整的轮次, 至少保留最后一个
+	start, used := len(units), 0
+	for start > 0 {
+		n := m.Tokens(units[start-1])
+		if start < len(units) && used+n > keep {
+			break
+		}
+		used += n
+		start--
+	}
+
+	for _, u := range units[:start] {
+		old = append(old, u...)
+	}
+	for _, u := range units[start:] {
+		recent = append(recent, u...)
+	}
+	return head, old, recent
+}
+
+// drop keeps only what split would keep verbatim.
+func (m *Manager) drop(msgs []*schema.Message) []*schema.Message {
+	head, _, recent := m.split(msgs, 0)
+	return append(append([]*schema.Message{}, head...), recent...)
+}
+
+// groupUnits 把带工具调用的 assistant 消息和对应的 tool 消息分在同一组, 其他消息单独一组.
+func groupUnits(msgs []*schema.Message) [][]*schema.Message {
+	var units [][]*schema.Message
+	for i := 0; i < len(msgs); {
+		msg := msgs[i]
+		j := i + 1
+		if msg.Role == schema.Assistant && len(msg.ToolCalls) > 0 {
+			for j < len(msgs) && msgs[j].Role == schema.Tool {
+				j++
+			}
+		}
+		units = append(units, msgs[i:j])
+		i = j
+	}
+	return units
+}
+
+// summarize 摘要 old; 如果 old 以上一次摘要过的消息开头, 只摘要上一次的摘要和新增的消息.
+func (m *Manager) summarize(ctx context.Context, old []*schema.Message) (string, error) {
+	m.mu.Lock()
+	last := m.last
+	m.mu.Unlock()
+
+	var prev string
+	rest := old
+	if last.count > 0 && last.count <= len(old) && hashMessages(old[:last.count]) == last.hash {
+		if last.count == len(old) {
+			return last.text, nil
+		}
+		prev, rest = last.text, old[last.count:]
+	}
+
+	var b strings.Builder
+	if prev != "" {
+		b.WriteString("Earlier summary: ")
+		b.WriteString(prev)
+		b.WriteString("\n\n")
+	}
+	writeTranscript(&b, rest)
+
+	resp, err := m.conf.Model.Generate(ctx, []*schema.Message{
+		schema.SystemMessage(m.conf.Prompt),
+		schema.UserMessage(b.String()),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	text := strings.TrimSpace(resp.Content)
+	if text == "" {
+		return "", errors.New("empty summary")
+	}
+
+	m.mu.Lock()
+	m.last = summary{count: len(old), hash: hashMessages(old), text: text}
+	m.mu.Unlock()
+
+	logs.Infof("summarized %d messages into %d tokens", len(old), EstimateTokens(schema.SystemMessage(text)))
+	return text, nil
+}
+
+func writeTranscript(b *strings.Builder, msgs []*schema.Message) {
+	for _, msg := range msgs {
+		switch {
+		case msg.Role == schema.System && strings.HasPrefix(msg.Content, SummaryPrefix):
+			fmt.Fprintf(b, "Earlier summary: %s\n", strings.TrimPrefix(msg.Content, SummaryPrefix))
+		case msg.Role == schema.Tool:
+			fmt.Fprintf(b, "tool result: %s\n", msg.Content)
+		default:
+			if msg.Content != "" {
+				fmt.Fprintf(b, "%s: %s\n", msg.Role, msg.Content)
+			}
+			for _, tc := range msg.ToolCalls {
+				fmt.Fprintf(b, "%s called %s(%s)\n", msg.Role, tc.Function.Name, tc.Function.Arguments)
+			}
+		}
+	}
+}
+
+func hashMessages(msgs []*schema.Message) string {
+	h := sha256.New()
+	for _, msg := range msgs {
+		fmt.Fprintf(h, "%s\x00%s\x00", msg.Role, msg.Content)
+		for _, tc := range msg.ToolCalls {
+			fmt.Fprintf(h, "%s\x00%s\x00%s\x00", tc.ID, tc.Function.Name, tc.Function.Arguments)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// EstimateTokens 粗略估算 token 数: ASCII 约 4 个字符一个 token, 其他字符(如中文)约一个字符一个 token,
+// 每条消息另加少量固定开销.
+func EstimateTokens(msg *schema.Message) int {
+	n := 4 + estimateText(msg.Content)
+	for _, tc := range msg.ToolCalls {
+		n += 4 + estimateText(tc.Function.Name) + estimateText(tc.Function.Arguments)
+	}
+	return n
+}
+
+func estimateText(s string) int {
+	ascii, other := 0, 0
+	for _, r := range s {
+		if r < utf8.RuneSelf {
+			ascii++
+		} else {
+			other++
+		}
+	}
+	return (ascii+3)/4 + other
+}
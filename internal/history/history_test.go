@@ -0,0 +1,138 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package history
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/flow/agent/react"
+	"github.com/cloudwego/eino/schema"
+)
+
+// summaryModel 总是返回固定的摘要.
+type summaryModel struct{}
+
+func (summaryModel) Generate(context.Context, []*schema.Message, ...model.Option) (*schema.Message, error) {
+	return schema.AssistantMessage("short", nil), nil
+}
+
+func (summaryModel) Stream(context.Context, []*schema.Message, ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	return schema.StreamReaderFromArray([]*schema.Message{schema.AssistantMessage("short", nil)}), nil
+}
+
+func (summaryModel) BindTools([]*schema.ToolInfo) error { return nil }
+
+func TestModifierCountsPersona(t *testing.T) {
+	m, err := New(&Config{Model: summaryModel{}, MaxTokens: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var input []*schema.Message
+	for range 3 {
+		input = append(input,
+			schema.UserMessage(strings.Repeat("a", 40)),
+			schema.AssistantMessage(strings.Repeat("b", 40), nil))
+	}
+	if n := m.Tokens(input); n > 100 {
+		t.Fatalf("the history alone is %d tokens, it should fit", n)
+	}
+
+	// 加上 persona 后超出预算
+	persona := strings.Repeat("p", 100)
+	out := m.Modifier(react.NewPersonaModifier(persona))(context.Background(), input)
+	if out[0].Role != schema.System || out[0].Content != persona {
+		t.Errorf("the persona should come first, got %+v", out[0])
+	}
+	if n := m.Tokens(out); n > 100 {
+		t.Errorf("got %d tokens with the persona, want at most 100", n)
+	}
+	if !strings.HasPrefix(out[1].Content, SummaryPrefix) {
+		t.Errorf("the older turns should be summarized, got %+v", out[1])
+	}
+}
+
+// wordsModel 返回 n 个单词的摘要.
+type wordsModel struct {
+	n int
+}
+
+func (m *wordsModel) Generate(context.Context, []*schema.Message, ...model.Option) (*schema.Message, error) {
+	return schema.AssistantMessage(strings.TrimSpace(strings.Repeat("word ", m.n)), nil), nil
+}
+
+func (m *wordsModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	msg, err := m.Generate(ctx, input, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return schema.StreamReaderFromArray([]*schema.Message{msg}), nil
+}
+
+func (m *wordsModel) BindTools([]*schema.ToolInfo) error { return nil }
+
+func TestTrimFitsWithLargePersona(t *testing.T) {
+	const maxTokens = 200
+	var turns []*schema.Message
+	for range 10 {
+		turns = append(turns,
+			schema.UserMessage(strings.Repeat("q", 40)),
+			schema.AssistantMessage(strings.Repeat("a", 40), nil))
+	}
+
+	for _, tt := range []struct {
+		name         string
+		persona      int // persona 的字符数, 约 4 个字符一个 token
+		summaryWords int
+	}{
+		{"small persona", 40, 5},
+		{"persona over the keep ratio", 480, 5},
+		{"persona and a long summary", 400, 30},
+		{"long summary", 40, 100},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := &wordsModel{n: tt.summaryWords}
+			m, err := New(&Config{Model: sm, MaxTokens: maxTokens})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			input := append([]*schema.Message{schema.SystemMessage(strings.Repeat("p", tt.persona))}, turns...)
+			out, err := m.Trim(context.Background(), input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n := m.Tokens(out); n > maxTokens {
+				t.Errorf("trimmed to %d tokens, want at most %d", n, maxTokens)
+			}
+			if len(out) < 3 || out[0] != input[0] || !strings.HasPrefix(out[1].Content, SummaryPrefix) {
+				t.Fatalf("want the persona, the summary and the recent turns, got %d messages", len(out))
+			}
+			if last := out[len(out)-1]; last != input[len(input)-1] {
+				t.Errorf("the last turn wasn't kept, got %+v", last)
+			}
+
+			// 摘要失败时丢弃较早的对话, 同样不超出预算
+			if n := m.Tokens(m.drop(input)); n > maxTokens {
+				t.Errorf("dropped to %d tokens, want at most %d", n, maxTokens)
+			}
+		})
+	}
+}
@@ -18,6 +18,7 @@ package logs
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"time"
 )
@@ -30,29 +31,37 @@ const (
 	colorReset = "\033[0m"
 )
 
+// out 日志输出的位置, 默认是 stdout.
+var out io.Writer = os.Stdout
+
+// SetOutput sets where the logs are written, e.g. os.Stderr when stdout carries a protocol.
+func SetOutput(w io.Writer) {
+	out = w
+}
+
 func Infof(format string, args ...interface{}) {
 	timestamp := time.Now().Format("2006-01-02 15:04:05")
 	prefix := fmt.Sprintf("%s[INFO] %s ", colorGreen, timestamp)
 	message := fmt.Sprintf(format, args...)
-	fmt.Printf("%s%s%s\n", prefix, message, colorReset)
+	fmt.Fprintf(out, "%s%s%s\n", prefix, message, colorReset)
 }
 
 func Errorf(format string, args ...interface{}) {
 	timestamp := time.Now().Format("2006-01-02 15:04:05")
 	prefix := fmt.Sprintf("%s[ERROR] %s ", colorRed, timestamp)
 	message := fmt.Sprintf(format, args...)
-	fmt.Printf("%s%s%s\n", prefix, message, colorReset)
+	fmt.Fprintf(out, "%s%s%s\n", prefix, message, colorReset)
 }
 
 func Tokenf(format string, args ...interface{}) {
 	message := fmt.Sprintf(format, args...)
-	fmt.Printf("%s%s%s", colorBrown, message, colorReset)
+	fmt.Fprintf(out, "%s%s%s", colorBrown, message, colorReset)
 }
 
 func Fatalf(format string, args ...interface{}) {
 	timestamp := time.Now().Format("2006-01-02 15:04:05")
 	prefix := fmt.Sprintf("%s[FATAL] %s ", colorRed, timestamp)
 	message := fmt.Sprintf(format, args...)
-	fmt.Printf("%s%s%s\n", prefix, message, colorReset)
+	fmt.Fprintf(out, "%s%s%s\n", prefix, message, colorReset)
 	os.Exit(1)
 }
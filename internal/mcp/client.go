@@ -0,0 +1,339 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/galihrivanto/eino-exp/internal/logs"
+)
+
+// ErrDisconnected is returned for the requests in flight when the connection to the server
+// is lost. The next request connects again.
+var ErrDisconnected = errors.New("mcp: disconnected from the server")
+
+const (
+	defaultDialAttempts = 3
+	dialBackoff         = 200 * time.Millisecond
+)
+
+// Client talks to one MCP server. It connects on the first request and connects again on
+// the request after the connection was lost; requests in flight at that moment fail with
+// ErrDisconnected and are not retried, as tool calls may not be idempotent.
+type Client struct {
+	name string
+	spec ServerSpec
+
+	mu   sync.Mutex // 保护 conn, 同时让重连依次进行
+	conn *clientConn
+}
+
+// NewClient creates a client for the server name, which is also the namespace of its
+// tools, see Tools.
+func NewClient(name string, spec *ServerSpec) (*Client, error) {
+	if err := spec.validate(name); err != nil {
+		return nil, err
+	}
+	return &Client{name: name, spec: *spec}, nil
+}
+
+// Name returns the name of the server.
+func (c *Client) Name() string {
+	return c.name
+}
+
+// ListTools returns all tools of the server, following the pagination.
+func (c *Client) ListTools(ctx context.Context) ([]Tool, error) {
+	var (
+		tools  []Tool
+		cursor string
+	)
+	for {
+		params := map[string]any{}
+		if cursor != "" {
+			params["cursor"] = cursor
+		}
+
+		var res ListToolsResult
+		if err := c.request(ctx, "tools/list", params, &res); err != nil {
+			return nil, err
+		}
+		tools = append(tools, res.Tools...)
+		if res.NextCursor == "" {
+			return tools, nil
+		}
+		cursor = res.NextCursor
+	}
+}
+
+// CallTool calls the tool name of the server. A tool that failed is a result with IsError
+// set, the error is for the protocol and the connection.
+func (c *Client) CallTool(ctx context.Context, name string, args json.RawMessage) (*CallToolResult, error) {
+	var res CallToolResult
+	if err := c.request(ctx, "tools/call", CallToolParams{Name: name, Arguments: args}, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// Close disconnects, a command is stopped.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.close()
+	c.conn = nil
+	return err
+}
+
+func (c *Client) request(ctx context.Context, method string, params, result any) error {
+	conn, err := c.connection(ctx)
+	if err != nil {
+		return err
+	}
+	return conn.request(ctx, method, params, result)
+}
+
+// connection 返回当前的连接, 没有连接或者连接已经断开时重新连接并完成初始化.
+func (c *Client) connection(ctx context.Context) (*clientConn, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conn != nil {
+		if c.conn.alive() {
+			return c.conn, nil
+		}
+		logs.Errorf("mcp server %s: %v, reconnecting", c.name, c.conn.err())
+		_ = c.conn.close()
+		c.conn = nil
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < defaultDialAttempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(dialBackoff << (attempt - 1)):
+			}
+		}
+
+		conn, err := c.connect(ctx)
+		if err == nil {
+			c.conn = conn
+			return conn, nil
+		}
+		lastErr = err
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, fmt.Errorf("mcp: connect to server %s: %w", c.name, lastErr)
+}
+
+func (c *Client) connect(ctx context.Context) (*clientConn, error) {
+	rwc, err := c.spec.dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+	conn := newClientConn(rwc)
+
+	var res InitializeResult
+	err = conn.request(ctx, "initialize", InitializeParams{
+		ProtocolVersion: ProtocolVersion,
+		Capabilities:    map[string]any{},
+		ClientInfo:      Implementation{Name: "eino-exp", Version: "0.1.0"},
+	}, &res)
+	if err == nil && !supportedVersions[res.ProtocolVersion] {
+		err = fmt.Errorf("unsupported protocol version %q", res.ProtocolVersion)
+	}
+	if err == nil {
+		err = conn.notify("notifications/initialized", nil)
+	}
+	if err != nil {
+		_ = conn.close()
+		return nil, err
+	}
+
+	logs.Infof("connected to mcp server %s (%s %s)", c.name, res.ServerInfo.Name, res.ServerInfo.Version)
+	return conn, nil
+}
+
+// clientConn 一个连接, 读 goroutine 把响应分发给等待的请求.
+type clientConn struct {
+	rwc io.ReadWriteCloser
+	wmu sync.Mutex
+
+	mu      sync.Mutex
+	nextID  int64
+	pending map[string]chan *message
+	readErr error         // 连接断开的原因
+	done    chan struct{} // 连接断开时关闭
+}
+
+func newClientConn(rwc io.ReadWriteCloser) *clientConn {
+	conn := &clientConn{rwc: rwc, pending: map[string]chan *message{}, done: make(chan struct{})}
+	go conn.readLoop()
+	return conn
+}
+
+func (c *clientConn) alive() bool {
+	select {
+	case <-c.done:
+		return false
+	default:
+		return true
+	}
+}
+
+func (c *clientConn) err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.readErr
+}
+
+func (c *clientConn) close() error {
+	err := c.rwc.Close()
+	<-c.done
+	return err
+}
+
+func (c *clientConn) readLoop() {
+	br := bufio.NewReader(c.rwc)
+	var err error
+	for {
+		var line []byte
+		if line, err = br.ReadBytes('\n'); err != nil {
+			break
+		}
+
+		var msg message
+		if json.Unmarshal(line, &msg) != nil {
+			continue
+		}
+		switch {
+		case msg.Method == "":
+			c.deliver(&msg)
+		case !msg.isNotification():
+			// requests of the server: answer ping, refuse the rest
+			if msg.Method == "ping" {
+				_ = c.write(&message{JSONRPC: "2.0", ID: msg.ID, Result: json.RawMessage("{}")})
+			} else {
+				_ = c.write(&message{JSONRPC: "2.0", ID: msg.ID, Error: &Error{Code: CodeMethodNotFound, Message: "method not found: " + msg.Method}})
+			}
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.readErr = fmt.Errorf("%w: %v", ErrDisconnected, err)
+	for id, ch := range c.pending {
+		close(ch)
+		delete(c.pending, id)
+	}
+	close(c.done)
+}
+
+func (c *clientConn) deliver(msg *message) {
+	c.mu.Lock()
+	ch, ok := c.pending[string(msg.ID)]
+	delete(c.pending, string(msg.ID))
+	c.mu.Unlock()
+	if ok {
+		ch <- msg
+	}
+}
+
+func (c *clientConn) request(ctx context.Context, method string, params, result any) error {
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	if c.readErr != nil {
+		c.mu.Unlock()
+		return c.readErr
+	}
+	c.nextID++
+	id := json.RawMessage(strconv.FormatInt(c.nextID, 10))
+	ch := make(chan *message, 1)
+	c.pending[string(id)] = ch
+	c.mu.Unlock()
+
+	if err = c.write(&message{JSONRPC: "2.0", ID: id, Method: method, Params: raw}); err != nil {
+		c.forget(id)
+		return fmt.Errorf("%w: %v", ErrDisconnected, err)
+	}
+
+	select {
+	case msg, ok := <-ch:
+		if !ok {
+			return c.err()
+		}
+		if msg.Error != nil {
+			return msg.Error
+		}
+		if result == nil {
+			return nil
+		}
+		return json.Unmarshal(msg.Result, result)
+	case <-ctx.Done():
+		c.forget(id)
+		_ = c.notify("notifications/cancelled", cancelledParams{RequestID: id, Reason: ctx.Err().Error()})
+		return ctx.Err()
+	}
+}
+
+func (c *clientConn) forget(id json.RawMessage) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.pending, string(id))
+}
+
+func (c *clientConn) notify(method string, params any) error {
+	msg := &message{JSONRPC: "2.0", Method: method}
+	if params != nil {
+		raw, err := json.Marshal(params)
+		if err != nil {
+			return err
+		}
+		msg.Params = raw
+	}
+	return c.write(msg)
+}
+
+func (c *clientConn) write(msg *message) error {
+	b, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	_, err = c.rwc.Write(append(b, '\n'))
+	return err
+}
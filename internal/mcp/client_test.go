@@ -0,0 +1,295 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// 设置了这个环境变量时测试二进制作为 stub MCP server 运行.
+const stubEnv = "MCP_TEST_STUB"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(stubEnv) == "1" {
+		runStub()
+		return
+	}
+	os.Exit(m.Run())
+}
+
+var (
+	longA = strings.Repeat("a", 70) + "_first"
+	longB = strings.Repeat("a", 70) + "_second"
+)
+
+// stubTools 是 stub server 的工具, 输入 schema 用了 OpenAPI 3.0 没有的 JSON Schema 写法.
+var stubTools = []map[string]any{
+	{"name": "forecast", "description": "weather forecast", "inputSchema": map[string]any{
+		"$schema": "http://json-schema.org/draft-07/schema#",
+		"type":    "object",
+		"properties": map[string]any{
+			"city":  map[string]any{"type": "string", "description": "city name"},
+			"days":  map[string]any{"type": []any{"integer", "null"}},
+			"units": map[string]any{"const": "metric"},
+		},
+		"required": []any{"city"},
+	}},
+	{"name": "pid", "inputSchema": map[string]any{"type": "object"}},
+	{"name": "crash", "inputSchema": map[string]any{"type": "object"}},
+	{"name": "hang", "inputSchema": map[string]any{"type": "object"}},
+	{"name": "get.weather", "inputSchema": map[string]any{"type": "object"}},
+	{"name": "get weather", "inputSchema": map[string]any{"type": "object"}},
+	{"name": longA, "inputSchema": map[string]any{"type": "object"}},
+	{"name": longB, "inputSchema": map[string]any{"type": "object"}},
+	{"name": "not_an_object", "inputSchema": map[string]any{"type": "string"}},
+}
+
+// runStub 在 stdin/stdout 上回答 JSON-RPC: pid 返回进程号, crash 在 100ms 后退出进程,
+// hang 不回答, 其他工具返回被调用的工具名.
+func runStub() {
+	enc := json.NewEncoder(os.Stdout)
+	sc := bufio.NewScanner(os.Stdin)
+	for sc.Scan() {
+		var msg message
+		if json.Unmarshal(sc.Bytes(), &msg) != nil || msg.isNotification() {
+			continue
+		}
+
+		var result any
+		switch msg.Method {
+		case "initialize":
+			result = InitializeResult{ProtocolVersion: ProtocolVersion, ServerInfo: Implementation{Name: "stub", Version: "1"}}
+		case "tools/list":
+			result = map[string]any{"tools": stubTools}
+		case "tools/call":
+			var p CallToolParams
+			_ = json.Unmarshal(msg.Params, &p)
+			switch p.Name {
+			case "pid":
+				result = textResult(strconv.Itoa(os.Getpid()))
+			case "crash":
+				time.Sleep(100 * time.Millisecond)
+				os.Exit(3)
+			case "hang":
+				continue
+			default:
+				result = textResult("called " + p.Name)
+			}
+		default:
+			_ = enc.Encode(message{JSONRPC: "2.0", ID: msg.ID, Error: &Error{Code: CodeMethodNotFound, Message: msg.Method}})
+			continue
+		}
+
+		b, _ := json.Marshal(result)
+		_ = enc.Encode(message{JSONRPC: "2.0", ID: msg.ID, Result: b})
+	}
+}
+
+func textResult(s string) *CallToolResult {
+	return &CallToolResult{Content: []Content{{Type: "text", Text: s}}}
+}
+
+func newStubClient(t *testing.T) *Client {
+	t.Helper()
+	c, err := NewClient("stub", &ServerSpec{Command: os.Args[0], Env: map[string]string{stubEnv: "1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+	return c
+}
+
+func stubTool(t *testing.T, tools []tool.BaseTool, remote string) *remoteTool {
+	t.Helper()
+	for _, bt := range tools {
+		if rt := bt.(*remoteTool); rt.remote == remote {
+			return rt
+		}
+	}
+	t.Fatalf("no tool for %s", remote)
+	return nil
+}
+
+func TestToolsSchemaConversion(t *testing.T) {
+	tools, err := Tools(context.Background(), newStubClient(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tools) != len(stubTools)-1 {
+		t.Errorf("got %d tools, want %d without not_an_object", len(tools), len(stubTools)-1)
+	}
+
+	info := stubTool(t, tools, "forecast").info
+	if info.Name != "stub__forecast" || info.Desc != "weather forecast" {
+		t.Errorf("info = %+v", info)
+	}
+	sc, err := info.ParamsOneOf.ToOpenAPIV3()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sc.Type != openapi3.TypeObject || len(sc.Required) != 1 || sc.Required[0] != "city" {
+		t.Errorf("schema type %s, required %v", sc.Type, sc.Required)
+	}
+	if city := sc.Properties["city"]; city == nil || city.Value.Type != openapi3.TypeString || city.Value.Description != "city name" {
+		t.Errorf("city = %+v", city)
+	}
+	if days := sc.Properties["days"]; days == nil || days.Value.Type != openapi3.TypeInteger || !days.Value.Nullable {
+		t.Errorf("days should be a nullable integer, got %+v", days)
+	}
+	if units := sc.Properties["units"]; units == nil || len(units.Value.Enum) != 1 || units.Value.Enum[0] != "metric" {
+		t.Errorf("units should be an enum of metric, got %+v", units)
+	}
+
+	if stubTool(t, tools, "pid").info.ParamsOneOf != nil {
+		t.Error("a schema without properties should give no parameters")
+	}
+}
+
+func TestToolNameClash(t *testing.T) {
+	tools, err := Tools(context.Background(), newStubClient(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := map[string]string{}
+	for _, bt := range tools {
+		rt := bt.(*remoteTool)
+		if len(rt.info.Name) > maxToolName || invalidNameChars.MatchString(rt.info.Name) {
+			t.Errorf("invalid name %q", rt.info.Name)
+		}
+		if other, ok := names[rt.info.Name]; ok {
+			t.Errorf("%s and %s are both named %s", other, rt.remote, rt.info.Name)
+		}
+		names[rt.info.Name] = rt.remote
+	}
+
+	if got := stubTool(t, tools, "get.weather").info.Name; got != "stub__get_weather" {
+		t.Errorf("the first of the clashing tools is named %s, want stub__get_weather", got)
+	}
+
+	// every name calls its own tool
+	for _, remote := range []string{"get weather", longA, longB} {
+		rt := stubTool(t, tools, remote)
+		out, err := rt.InvokableRun(context.Background(), "{}")
+		if err != nil || out != "called "+remote {
+			t.Errorf("%s: got %q, %v, want the call of %s", rt.info.Name, out, err, remote)
+		}
+	}
+}
+
+func TestToolName(t *testing.T) {
+	if got := ToolName("weather", "get-forecast"); got != "weather__get-forecast" {
+		t.Errorf("got %s", got)
+	}
+	if got := ToolName("weather", "get forecast/v2"); got != "weather__get_forecast_v2" {
+		t.Errorf("got %s", got)
+	}
+
+	a, b := ToolName("weather", longA), ToolName("weather", longB)
+	if len(a) != maxToolName || len(b) != maxToolName || a == b {
+		t.Errorf("long names %s and %s should be distinct and %d characters", a, b, maxToolName)
+	}
+	if a != ToolName("weather", longA) {
+		t.Error("ToolName is not stable")
+	}
+}
+
+func TestReconnectAfterServerExits(t *testing.T) {
+	c := newStubClient(t)
+	ctx := context.Background()
+
+	pid := func() string {
+		t.Helper()
+		res, err := c.CallTool(ctx, "pid", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res.Text()
+	}
+	first := pid()
+
+	// hang is in flight while crash makes the server exit, both fail
+	hung := make(chan error, 1)
+	go func() {
+		_, err := c.CallTool(ctx, "hang", nil)
+		hung <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	if _, err := c.CallTool(ctx, "crash", nil); !errors.Is(err, ErrDisconnected) {
+		t.Errorf("crash: got %v, want ErrDisconnected", err)
+	}
+	select {
+	case err := <-hung:
+		if !errors.Is(err, ErrDisconnected) {
+			t.Errorf("hang: got %v, want ErrDisconnected", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the call in flight didn't fail when the server exited")
+	}
+
+	// the next call starts the server again
+	if second := pid(); second == first || second == "" {
+		t.Errorf("pid before %s and after %s the crash, want another process", first, second)
+	}
+}
+
+func TestRemoteToolReportsDisconnect(t *testing.T) {
+	tools, err := Tools(context.Background(), newStubClient(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := stubTool(t, tools, "crash").InvokableRun(context.Background(), "{}")
+	if err != nil {
+		t.Fatalf("a lost server should be reported to the model, got %v", err)
+	}
+	var report map[string]string
+	if json.Unmarshal([]byte(out), &report) != nil || report["error"] != "unavailable" || report["tool"] != "stub__crash" {
+		t.Errorf("got %s, want an unavailable report", out)
+	}
+
+	out, err = stubTool(t, tools, "forecast").InvokableRun(context.Background(), `{"city":"Paris"}`)
+	if err != nil || out != "called forecast" {
+		t.Errorf("after reconnecting: got %q, %v", out, err)
+	}
+}
+
+func TestCallCancelled(t *testing.T) {
+	c := newStubClient(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	if _, err := c.CallTool(ctx, "hang", nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want the deadline", err)
+	}
+	// the connection is still usable
+	res, err := c.CallTool(context.Background(), "forecast", json.RawMessage(`{"city":"Paris"}`))
+	if err != nil || res.Text() != "called forecast" {
+		t.Errorf("got %v, %v", res, err)
+	}
+}
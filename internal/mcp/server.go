@@ -0,0 +1,311 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/cloudwego/eino/components/tool"
+
+	"github.com/galihrivanto/eino-exp/internal/logs"
+)
+
+// ServerConfig configures a Server.
+type ServerConfig struct {
+	Name    string // default "eino-exp"
+	Version string // default "0.1.0"
+	// Instructions tell the client how to use the tools. Optional.
+	Instructions string
+	// Tools are published by their schema.ToolInfo, tools which aren't invokable are skipped.
+	Tools []tool.BaseTool
+	// IsError reports whether a result the tool returned without an error is a failure, e.g.
+	// an error a middleware reported as the result, see toolmw.IsReportedError. Such results
+	// are sent with isError. Optional, by default only the errors of the tools are.
+	IsError func(result string) bool
+}
+
+// Server publishes eino tools to MCP clients.
+type Server struct {
+	info         Implementation
+	instructions string
+	tools        map[string]tool.InvokableTool
+	list         []Tool
+	isError      func(result string) bool
+}
+
+// NewServer creates a server, the info of every tool is read once.
+func NewServer(ctx context.Context, conf *ServerConfig) (*Server, error) {
+	s := &Server{
+		info:         Implementation{Name: conf.Name, Version: conf.Version},
+		instructions: conf.Instructions,
+		tools:        map[string]tool.InvokableTool{},
+		isError:      conf.IsError,
+	}
+	if s.info.Name == "" {
+		s.info.Name = "eino-exp"
+	}
+	if s.info.Version == "" {
+		s.info.Version = "0.1.0"
+	}
+
+	for _, t := range conf.Tools {
+		it, ok := t.(tool.InvokableTool)
+		if !ok {
+			continue
+		}
+		info, err := it.Info(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := s.tools[info.Name]; ok {
+			return nil, fmt.Errorf("mcp: duplicate tool %q", info.Name)
+		}
+
+		inputSchema := json.RawMessage(`{"type":"object","properties":{}}`)
+		if info.ParamsOneOf != nil {
+			sc, err := info.ParamsOneOf.ToOpenAPIV3()
+			if err != nil {
+				return nil, fmt.Errorf("mcp: schema of tool %s: %w", info.Name, err)
+			}
+			if inputSchema, err = json.Marshal(sc); err != nil {
+				return nil, fmt.Errorf("mcp: schema of tool %s: %w", info.Name, err)
+			}
+		}
+
+		s.tools[info.Name] = it
+		s.list = append(s.list, Tool{Name: info.Name, Description: info.Desc, InputSchema: inputSchema})
+	}
+	return s, nil
+}
+
+// Serve answers the messages read from r on w until r ends or ctx is done. Tool calls run
+// concurrently and are cancelled by notifications/cancelled; when r ends, Serve waits for
+// the running calls to answer before it returns.
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	c := &serverConn{s: s, w: w, calls: map[string]context.CancelFunc{}}
+	defer c.wg.Wait()
+
+	lines := make(chan []byte)
+	readErr := make(chan error, 1)
+	go func() {
+		br := bufio.NewReader(r)
+		for {
+			line, err := br.ReadBytes('\n')
+			if len(bytes.TrimSpace(line)) > 0 {
+				select {
+				case lines <- line:
+				case <-ctx.Done():
+					return
+				}
+			}
+			if err != nil {
+				readErr <- err
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-readErr:
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		case line := <-lines:
+			c.handle(ctx, line)
+		}
+	}
+}
+
+// serverConn 一个客户端连接的状态.
+type serverConn struct {
+	s *Server
+
+	wmu sync.Mutex
+	w   io.Writer
+
+	mu    sync.Mutex
+	calls map[string]context.CancelFunc // 正在执行的 tools/call, 按请求 ID
+	wg    sync.WaitGroup
+}
+
+func (c *serverConn) handle(ctx context.Context, line []byte) {
+	var msg message
+	if err := json.Unmarshal(line, &msg); err != nil {
+		c.reply(nil, nil, &Error{Code: CodeParseError, Message: "parse error: " + err.Error()})
+		return
+	}
+	if msg.JSONRPC != "2.0" {
+		c.reply(msg.ID, nil, &Error{Code: CodeInvalidRequest, Message: `jsonrpc must be "2.0"`})
+		return
+	}
+	if msg.Method == "" {
+		// a response, the server doesn't send requests
+		return
+	}
+
+	if msg.isNotification() {
+		if msg.Method == "notifications/cancelled" {
+			var p cancelledParams
+			if json.Unmarshal(msg.Params, &p) == nil {
+				c.cancel(string(p.RequestID))
+			}
+		}
+		// notifications/initialized and unknown notifications need no answer
+		return
+	}
+
+	switch msg.Method {
+	case "initialize":
+		c.reply(msg.ID, c.s.initialize(msg.Params), nil)
+	case "ping":
+		c.reply(msg.ID, struct{}{}, nil)
+	case "tools/list":
+		c.reply(msg.ID, ListToolsResult{Tools: c.s.list}, nil)
+	case "tools/call":
+		c.call(ctx, msg.ID, msg.Params)
+	default:
+		c.reply(msg.ID, nil, &Error{Code: CodeMethodNotFound, Message: "method not found: " + msg.Method})
+	}
+}
+
+func (s *Server) initialize(params json.RawMessage) *InitializeResult {
+	version := ProtocolVersion
+	var p InitializeParams
+	if json.Unmarshal(params, &p) == nil && supportedVersions[p.ProtocolVersion] {
+		version = p.ProtocolVersion
+	}
+	if p.ClientInfo.Name != "" {
+		logs.Infof("mcp client %s %s connected", p.ClientInfo.Name, p.ClientInfo.Version)
+	}
+
+	return &InitializeResult{
+		ProtocolVersion: version,
+		Capabilities:    map[string]any{"tools": map[string]any{"listChanged": false}},
+		ServerInfo:      s.info,
+		Instructions:    s.instructions,
+	}
+}
+
+// call 在单独的 goroutine 中执行工具; 工具返回的错误和 IsError 判定失败的结果是 isError 的结果, 参数错误是 JSON-RPC 错误.
+func (c *serverConn) call(ctx context.Context, id json.RawMessage, params json.RawMessage) {
+	var p CallToolParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		c.reply(id, nil, &Error{Code: CodeInvalidParams, Message: "invalid params: " + err.Error()})
+		return
+	}
+	t, ok := c.s.tools[p.Name]
+	if !ok {
+		c.reply(id, nil, &Error{Code: CodeInvalidParams, Message: "unknown tool: " + p.Name})
+		return
+	}
+
+	args := string(p.Arguments)
+	if len(bytes.TrimSpace(p.Arguments)) == 0 || string(p.Arguments) == "null" {
+		args = "{}"
+	} else if !isObject(p.Arguments) {
+		c.reply(id, nil, &Error{Code: CodeInvalidParams, Message: "arguments must be an object"})
+		return
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	key := string(id)
+	c.mu.Lock()
+	c.calls[key] = cancel
+	c.mu.Unlock()
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		defer c.cancel(key)
+
+		res := c.s.run(ctx, t, p.Name, args)
+		if ctx.Err() != nil && errors.Is(context.Cause(ctx), context.Canceled) {
+			// cancelled by the client, which doesn't expect an answer any more
+			return
+		}
+		c.reply(id, res, nil)
+	}()
+}
+
+func (s *Server) run(ctx context.Context, t tool.InvokableTool, name, args string) (res *CallToolResult) {
+	defer func() {
+		if r := recover(); r != nil {
+			logs.Errorf("tool %s panicked: %v", name, r)
+			res = &CallToolResult{IsError: true, Content: []Content{{Type: "text", Text: fmt.Sprintf("tool %s failed: %v", name, r)}}}
+		}
+	}()
+
+	out, err := t.InvokableRun(ctx, args)
+	if err != nil {
+		return &CallToolResult{IsError: true, Content: []Content{{Type: "text", Text: err.Error()}}}
+	}
+	return &CallToolResult{IsError: s.isError != nil && s.isError(out), Content: []Content{{Type: "text", Text: out}}}
+}
+
+func (c *serverConn) cancel(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cancel, ok := c.calls[key]; ok {
+		cancel()
+		delete(c.calls, key)
+	}
+}
+
+func (c *serverConn) reply(id json.RawMessage, result any, rpcErr *Error) {
+	if len(id) == 0 {
+		id = json.RawMessage("null")
+	}
+	msg := message{JSONRPC: "2.0", ID: id, Error: rpcErr}
+	if rpcErr == nil {
+		b, err := json.Marshal(result)
+		if err != nil {
+			msg.Error = &Error{Code: CodeInternalError, Message: err.Error()}
+		} else {
+			msg.Result = b
+		}
+	}
+
+	b, err := json.Marshal(msg)
+	if err != nil {
+		logs.Errorf("mcp: encode response: %v", err)
+		return
+	}
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	if _, err = c.w.Write(append(b, '\n')); err != nil {
+		logs.Errorf("mcp: write response: %v", err)
+	}
+}
+
+func isObject(raw json.RawMessage) bool {
+	var v map[string]json.RawMessage
+	return json.Unmarshal(raw, &v) == nil && v != nil
+}
@@ -0,0 +1,232 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"regexp"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+	"github.com/getkin/kin-openapi/openapi3"
+
+	"github.com/galihrivanto/eino-exp/internal/logs"
+)
+
+// NameSeparator separates the server from the tool in the names of remote tools, e.g.
+// weather__forecast, so that tools of different servers don't clash.
+const NameSeparator = "__"
+
+// maxToolName is the longest tool name most model APIs accept.
+const maxToolName = 64
+
+var invalidNameChars = regexp.MustCompile(`[^A-Za-z0-9_-]`)
+
+// Tools lists the tools of the server and adapts them to eino tools named
+// <server>__<tool>, see ToolName. A tool whose name clashes with an earlier one once
+// invalid characters are replaced gets a hash of its name appended. A tool whose input
+// schema can't be converted is skipped with a log.
+func Tools(ctx context.Context, c *Client) ([]tool.BaseTool, error) {
+	list, err := c.ListTools(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tools := make([]tool.BaseTool, 0, len(list))
+	seen := map[string]string{} // 模型看到的名字 -> server 上的工具名
+	for _, t := range list {
+		params, err := convertSchema(t.InputSchema)
+		if err != nil {
+			logs.Errorf("mcp server %s: skip tool %s: %v", c.name, t.Name, err)
+			continue
+		}
+
+		name := ToolName(c.name, t.Name)
+		if _, ok := seen[name]; ok {
+			name = hashedName(name, c.name+NameSeparator+t.Name)
+		}
+		if other, ok := seen[name]; ok {
+			return nil, fmt.Errorf("mcp server %s: tools %q and %q are both named %s", c.name, other, t.Name, name)
+		}
+		seen[name] = t.Name
+
+		tools = append(tools, &remoteTool{
+			client: c,
+			remote: t.Name,
+			info:   &schema.ToolInfo{Name: name, Desc: t.Description, ParamsOneOf: params},
+		})
+	}
+	return tools, nil
+}
+
+// ToolName returns the name of the tool of the server as seen by the model: characters
+// model APIs don't accept are replaced by _. A name longer than 64 characters is cut and
+// ends with a hash of the full name, so that names differing after the cut stay apart.
+func ToolName(server, name string) string {
+	full := server + NameSeparator + invalidNameChars.ReplaceAllString(name, "_")
+	if len(full) > maxToolName {
+		return hashedName(full, server+NameSeparator+name)
+	}
+	return full
+}
+
+// hashedName 把 name 截短, 后面加上 key 的哈希, 结果不超过 maxToolName.
+func hashedName(name, key string) string {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	suffix := fmt.Sprintf("_%08x", h.Sum32())
+	if len(name) > maxToolName-len(suffix) {
+		name = name[:maxToolName-len(suffix)]
+	}
+	return name + suffix
+}
+
+// remoteTool 调用 MCP server 的一个工具.
+type remoteTool struct {
+	client *Client
+	remote string // server 上的工具名
+	info   *schema.ToolInfo
+}
+
+func (t *remoteTool) Info(_ context.Context) (*schema.ToolInfo, error) {
+	return t.info, nil
+}
+
+// InvokableRun reports failures of the tool and of the connection to the model, only the
+// errors of ctx are returned.
+func (t *remoteTool) InvokableRun(ctx context.Context, argumentsInJSON string, _ ...tool.Option) (string, error) {
+	args := json.RawMessage(argumentsInJSON)
+	if strings.TrimSpace(argumentsInJSON) == "" {
+		args = json.RawMessage("{}")
+	} else if !json.Valid(args) {
+		return t.report("invalid_arguments", errors.New("the arguments are not valid JSON"), "call the tool again with a JSON object as arguments"), nil
+	}
+
+	res, err := t.client.CallTool(ctx, t.remote, args)
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		var rpcErr *Error
+		if errors.As(err, &rpcErr) {
+			return t.report("invalid_call", err, "check the arguments against the tool's parameters"), nil
+		}
+		logs.Errorf("call %s: %v", t.info.Name, err)
+		return t.report("unavailable", err, "the tool's server can't be reached, answer without it or try again later"), nil
+	}
+
+	if res.IsError {
+		return t.report("tool_error", errors.New(res.Text()), "fix the arguments or answer without this tool"), nil
+	}
+	return res.Text(), nil
+}
+
+// report renders the error as a tool result the model can read.
+func (t *remoteTool) report(kind string, err error, hint string) string {
+	b, _ := json.Marshal(map[string]string{
+		"error":   kind,
+		"tool":    t.info.Name,
+		"message": err.Error(),
+		"hint":    hint,
+	})
+	return string(b)
+}
+
+// convertSchema 把 MCP 的 JSON Schema 转换为 eino 的参数定义; 先改写 OpenAPI 3.0 不支持的写法.
+func convertSchema(raw json.RawMessage) (*schema.ParamsOneOf, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil, fmt.Errorf("invalid input schema: %w", err)
+	}
+	b, err := json.Marshal(normalize(v))
+	if err != nil {
+		return nil, err
+	}
+
+	sc := &openapi3.Schema{}
+	if err = json.Unmarshal(b, sc); err != nil {
+		return nil, fmt.Errorf("invalid input schema: %w", err)
+	}
+	if sc.Type == "" {
+		sc.Type = openapi3.TypeObject
+	}
+	if sc.Type != openapi3.TypeObject {
+		return nil, fmt.Errorf("input schema is of type %s, want object", sc.Type)
+	}
+	if len(sc.Properties) == 0 {
+		return nil, nil
+	}
+	return schema.NewParamsOneOfByOpenAPIV3(sc), nil
+}
+
+// normalize 递归地改写 JSON Schema: type 数组取第一个非 null 的类型并设置 nullable,
+// const 改为只有一个值的 enum, 去掉 $schema 和 $id.
+func normalize(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, val := range v {
+			switch k {
+			case "properties", "patternProperties", "$defs", "definitions":
+				// the keys are names, not keywords
+				if props, ok := val.(map[string]any); ok {
+					named := make(map[string]any, len(props))
+					for name, p := range props {
+						named[name] = normalize(p)
+					}
+					out[k] = named
+					continue
+				}
+			case "$schema", "$id":
+				continue
+			case "const":
+				out["enum"] = []any{val}
+				continue
+			case "type":
+				if types, ok := val.([]any); ok {
+					for _, t := range types {
+						if t == "null" {
+							out["nullable"] = true
+						} else if _, ok := out["type"]; !ok {
+							out["type"] = t
+						}
+					}
+					continue
+				}
+			}
+			out[k] = normalize(val)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, val := range v {
+			out[i] = normalize(val)
+		}
+		return out
+	default:
+		return v
+	}
+}
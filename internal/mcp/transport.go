@@ -0,0 +1,163 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ServerSpec tells the client how to reach an MCP server: a command started as a
+// subprocess speaking on its stdin/stdout, or a local socket of a running server.
+type ServerSpec struct {
+	Command string            `json:"command,omitempty"`
+	Args    []string          `json:"args,omitempty"`
+	Env     map[string]string `json:"env,omitempty"` // added to the environment of the command
+	// Socket is "unix:<path>" or "tcp:<host:port>"
+	Socket string `json:"socket,omitempty"`
+}
+
+var validServerName = regexp.MustCompile(`^[A-Za-z0-9_-]{1,32}$`)
+
+func (s *ServerSpec) validate(name string) error {
+	if !validServerName.MatchString(name) {
+		return fmt.Errorf("mcp: invalid server name %q, use 1-32 letters, digits, - or _", name)
+	}
+	if (s.Command == "") == (s.Socket == "") {
+		return fmt.Errorf("mcp: server %s needs either a command or a socket", name)
+	}
+	if s.Socket != "" {
+		if _, _, err := splitSocket(s.Socket); err != nil {
+			return fmt.Errorf("mcp: server %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// LoadConfig reads the servers from a JSON file in the format most MCP clients use:
+//
+//	{"mcpServers": {"weather": {"command": "weather-mcp", "args": ["--units", "metric"]},
+//	                "maps": {"socket": "unix:/tmp/maps.sock"}}}
+//
+// The names are returned sorted.
+func LoadConfig(path string) (names []string, specs map[string]*ServerSpec, err error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var file struct {
+		MCPServers map[string]*ServerSpec `json:"mcpServers"`
+	}
+	if err = json.Unmarshal(b, &file); err != nil {
+		return nil, nil, fmt.Errorf("mcp: parse %s: %w", path, err)
+	}
+
+	for name, spec := range file.MCPServers {
+		if spec == nil {
+			return nil, nil, fmt.Errorf("mcp: server %s in %s is empty", name, path)
+		}
+		if err = spec.validate(name); err != nil {
+			return nil, nil, err
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, file.MCPServers, nil
+}
+
+func splitSocket(socket string) (network, addr string, err error) {
+	network, addr, ok := strings.Cut(socket, ":")
+	if !ok || addr == "" || (network != "unix" && network != "tcp") {
+		return "", "", fmt.Errorf("invalid socket %q, want unix:<path> or tcp:<host:port>", socket)
+	}
+	return network, addr, nil
+}
+
+// dial 启动子进程或者连接 socket.
+func (s *ServerSpec) dial(ctx context.Context) (io.ReadWriteCloser, error) {
+	if s.Socket != "" {
+		network, addr, err := splitSocket(s.Socket)
+		if err != nil {
+			return nil, err
+		}
+		var d net.Dialer
+		return d.DialContext(ctx, network, addr)
+	}
+
+	// not CommandContext, the process lives as long as the connection, not the dial
+	cmd := exec.Command(s.Command, s.Args...)
+	cmd.Env = os.Environ()
+	for k, v := range s.Env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	cmd.Stderr = os.Stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err = cmd.Start(); err != nil {
+		return nil, err
+	}
+	return &process{cmd: cmd, stdin: stdin, stdout: stdout}, nil
+}
+
+// process 子进程的 stdin/stdout.
+type process struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout io.ReadCloser
+}
+
+func (p *process) Read(b []byte) (int, error)  { return p.stdout.Read(b) }
+func (p *process) Write(b []byte) (int, error) { return p.stdin.Write(b) }
+
+// Close closes stdin, which tells a stdio server to exit, and kills it if it doesn't
+// within two seconds.
+func (p *process) Close() error {
+	_ = p.stdin.Close()
+
+	done := make(chan error, 1)
+	go func() { done <- p.cmd.Wait() }()
+	select {
+	case err := <-done:
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return nil
+		}
+		return err
+	case <-time.After(2 * time.Second):
+		_ = p.cmd.Process.Kill()
+		<-done
+		return nil
+	}
+}
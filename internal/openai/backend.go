@@ -0,0 +1,146 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package openai
+
+import (
+	"context"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/flow/agent"
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/galihrivanto/eino-exp/internal/agentrun"
+)
+
+// Call is a request mapped onto eino.
+type Call struct {
+	Messages []*schema.Message
+	// Tools offered by the client, only set for backends that accept them
+	Tools []*schema.ToolInfo
+	// Options are the sampling options of the request
+	Options []model.Option
+}
+
+// Backend answers the requests for one model name.
+type Backend interface {
+	// Stream answers the call. usage returns the token usage of the whole call once the
+	// stream ended, nil to take it from the ResponseMeta of the chunks.
+	Stream(ctx context.Context, call *Call) (sr *schema.StreamReader[*schema.Message], usage func() *schema.TokenUsage, err error)
+	// AcceptsTools reports whether the client may pass tools, whose calls are returned to it.
+	AcceptsTools() bool
+}
+
+// AgentBackend serves an agent such as react.Agent. The agent calls its own tools, only
+// the final answer is returned, the usage covers all its model turns. begin is called
+// with the context of every run, it may be nil.
+func AgentBackend(a agentrun.Agent, begin func(ctx context.Context) context.Context, opts ...agent.AgentOption) Backend {
+	return &agentBackend{agent: a, begin: begin, opts: opts}
+}
+
+type agentBackend struct {
+	agent agentrun.Agent
+	begin func(ctx context.Context) context.Context
+	opts  []agent.AgentOption
+}
+
+func (b *agentBackend) AcceptsTools() bool { return false }
+
+func (b *agentBackend) Stream(ctx context.Context, call *Call) (*schema.StreamReader[*schema.Message], func() *schema.TokenUsage, error) {
+	if b.begin != nil {
+		ctx = b.begin(ctx)
+	}
+
+	rec := agentrun.NewRecorder()
+	opts := append(b.opts[:len(b.opts):len(b.opts)], agent.WithComposeOptions(
+		compose.WithCallbacks(rec.Handler()),
+		compose.WithChatModelOption(call.Options...),
+	))
+	sr, err := b.agent.Stream(ctx, call.Messages, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return sr, func() *schema.TokenUsage { return usageOf(rec) }, nil
+}
+
+// GraphBackend serves a compiled graph from messages to a message, such as the
+// writer/critic graph. The usage covers all its chat model nodes.
+func GraphBackend(r compose.Runnable[[]*schema.Message, *schema.Message], opts ...compose.Option) Backend {
+	return &graphBackend{runnable: r, opts: opts}
+}
+
+type graphBackend struct {
+	runnable compose.Runnable[[]*schema.Message, *schema.Message]
+	opts     []compose.Option
+}
+
+func (b *graphBackend) AcceptsTools() bool { return false }
+
+func (b *graphBackend) Stream(ctx context.Context, call *Call) (*schema.StreamReader[*schema.Message], func() *schema.TokenUsage, error) {
+	rec := agentrun.NewRecorder()
+	opts := append(b.opts[:len(b.opts):len(b.opts)],
+		compose.WithCallbacks(rec.Handler()),
+		compose.WithChatModelOption(call.Options...),
+	)
+	sr, err := b.runnable.Stream(ctx, call.Messages, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return sr, func() *schema.TokenUsage { return usageOf(rec) }, nil
+}
+
+// usageOf 没有模型报告用量时返回 nil, 由 chunk 的 ResponseMeta 决定.
+func usageOf(rec *agentrun.Recorder) *schema.TokenUsage {
+	res := rec.Result(nil)
+	for _, s := range res.Steps {
+		if s.Usage != nil {
+			return &res.Usage
+		}
+	}
+	return nil
+}
+
+// ChatModelBackend serves a chat model directly. Tools offered by the client are bound to a
+// model created for the request with newModel, since BindTools changes the model, and the
+// tool calls are returned to the client to run.
+func ChatModelBackend(newModel func(ctx context.Context) (model.ChatModel, error)) Backend {
+	return &modelBackend{newModel: newModel}
+}
+
+type modelBackend struct {
+	newModel func(ctx context.Context) (model.ChatModel, error)
+}
+
+func (b *modelBackend) AcceptsTools() bool { return true }
+
+func (b *modelBackend) Stream(ctx context.Context, call *Call) (*schema.StreamReader[*schema.Message], func() *schema.TokenUsage, error) {
+	m, err := b.newModel(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(call.Tools) > 0 {
+		if err = m.BindTools(call.Tools); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	sr, err := m.Stream(ctx, call.Messages, call.Options...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return sr, nil, nil
+}
@@ -0,0 +1,371 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package openai serves eino agents, graphs and chat models with the OpenAI chat
+// completions API, so that OpenAI clients can use them.
+package openai
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/galihrivanto/eino-exp/internal/logs"
+)
+
+// Model is a model name served by a backend.
+type Model struct {
+	ID      string
+	OwnedBy string // default "eino-exp"
+	Backend Backend
+}
+
+// Handler serves GET /v1/models and POST /v1/chat/completions.
+type Handler struct {
+	models  []Model
+	byID    map[string]*Model
+	created int64
+	mux     *http.ServeMux
+}
+
+// NewHandler creates the handler, the models are listed in the given order.
+func NewHandler(models ...Model) (*Handler, error) {
+	h := &Handler{byID: map[string]*Model{}, created: time.Now().Unix(), mux: http.NewServeMux()}
+	for _, m := range models {
+		if m.ID == "" || m.Backend == nil {
+			return nil, errors.New("openai: models need an ID and a backend")
+		}
+		if _, ok := h.byID[m.ID]; ok {
+			return nil, fmt.Errorf("openai: duplicate model %q", m.ID)
+		}
+		if m.OwnedBy == "" {
+			m.OwnedBy = "eino-exp"
+		}
+		h.models = append(h.models, m)
+		h.byID[m.ID] = &h.models[len(h.models)-1]
+	}
+
+	h.mux.HandleFunc("GET /v1/models", h.listModels)
+	h.mux.HandleFunc("POST /v1/chat/completions", h.completions)
+	return h, nil
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h *Handler) listModels(w http.ResponseWriter, r *http.Request) {
+	data := make([]ModelInfo, 0, len(h.models))
+	for _, m := range h.models {
+		data = append(data, ModelInfo{ID: m.ID, Object: "model", Created: h.created, OwnedBy: m.OwnedBy})
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"object": "list", "data": data})
+}
+
+func (h *Handler) completions(w http.ResponseWriter, r *http.Request) {
+	var req Request
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4<<20)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", "", "invalid request body: "+err.Error())
+		return
+	}
+
+	m, ok := h.byID[req.Model]
+	if !ok {
+		writeError(w, http.StatusNotFound, "invalid_request_error", "model_not_found",
+			fmt.Sprintf("the model %q does not exist, see GET /v1/models", req.Model))
+		return
+	}
+	call, err := h.newCall(&req, m)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", "", err.Error())
+		return
+	}
+
+	ctx := r.Context()
+	sr, usage, err := m.Backend.Stream(ctx, call)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "server_error", "", err.Error())
+		return
+	}
+	defer sr.Close()
+
+	c := &completion{id: "chatcmpl-" + randomID(), created: time.Now().Unix(), model: m.ID, usage: usage}
+	if req.Stream {
+		c.stream(ctx, w, sr, req.StreamOptions != nil && req.StreamOptions.IncludeUsage)
+	} else {
+		c.generate(ctx, w, sr)
+	}
+}
+
+func (h *Handler) newCall(req *Request, m *Model) (*Call, error) {
+	if len(req.Messages) == 0 {
+		return nil, errors.New("messages must not be empty")
+	}
+	msgs, err := toSchema(req.Messages)
+	if err != nil {
+		return nil, err
+	}
+	call := &Call{Messages: msgs}
+
+	if len(req.Tools) > 0 && req.ToolChoice != "none" {
+		if !m.Backend.AcceptsTools() {
+			return nil, fmt.Errorf("the model %q runs its own tools and doesn't accept tools from the request", m.ID)
+		}
+		if call.Tools, err = toolInfos(req.Tools); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.Temperature != nil {
+		call.Options = append(call.Options, model.WithTemperature(*req.Temperature))
+	}
+	if req.TopP != nil {
+		call.Options = append(call.Options, model.WithTopP(*req.TopP))
+	}
+	if req.MaxCompletionTokens != nil {
+		call.Options = append(call.Options, model.WithMaxTokens(*req.MaxCompletionTokens))
+	} else if req.MaxTokens != nil {
+		call.Options = append(call.Options, model.WithMaxTokens(*req.MaxTokens))
+	}
+	if len(req.Stop) > 0 {
+		call.Options = append(call.Options, model.WithStop(req.Stop))
+	}
+	return call, nil
+}
+
+// completion 一次请求的回答.
+type completion struct {
+	id      string
+	created int64
+	model   string
+	usage   func() *schema.TokenUsage
+}
+
+func (c *completion) generate(ctx context.Context, w http.ResponseWriter, sr *schema.StreamReader[*schema.Message]) {
+	var chunks []*schema.Message
+	for {
+		chunk, err := sr.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				logs.Infof("request cancelled: %v", ctx.Err())
+				return
+			}
+			writeError(w, http.StatusInternalServerError, "server_error", "", err.Error())
+			return
+		}
+		chunks = append(chunks, chunk)
+	}
+
+	msg := schema.AssistantMessage("", nil)
+	if len(chunks) > 0 {
+		var err error
+		if msg, err = schema.ConcatMessages(chunks); err != nil {
+			writeError(w, http.StatusInternalServerError, "server_error", "", err.Error())
+			return
+		}
+	}
+
+	content := msg.Content
+	out := &RespMessage{Role: "assistant", Content: &content}
+	if len(msg.ToolCalls) > 0 {
+		out.ToolCalls = toWireToolCalls(msg.ToolCalls, nil)
+	}
+
+	finish := finishReason(msg.ResponseMeta, len(msg.ToolCalls) > 0)
+	writeJSON(w, http.StatusOK, &Response{
+		ID:      c.id,
+		Object:  "chat.completion",
+		Created: c.created,
+		Model:   c.model,
+		Choices: []Choice{{Message: out, FinishReason: &finish}},
+		Usage:   c.totalUsage(msg.ResponseMeta),
+	})
+}
+
+// stream 以 OpenAI 的 chunk 格式发送回答, 最后是 data: [DONE].
+func (c *completion) stream(ctx context.Context, w http.ResponseWriter, sr *schema.StreamReader[*schema.Message], includeUsage bool) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "server_error", "", "streaming is not supported")
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	send := func(v any) bool {
+		b, err := json.Marshal(v)
+		if err == nil {
+			_, err = fmt.Fprintf(w, "data: %s\n\n", b)
+		}
+		if err != nil {
+			logs.Infof("client disconnected: %v", err)
+			return false
+		}
+		flusher.Flush()
+		return true
+	}
+
+	var (
+		meta  schema.ResponseMeta
+		calls = newToolIndexer()
+		first = true
+	)
+	for {
+		chunk, err := sr.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			if ctx.Err() == nil {
+				// the status is already sent, the error goes into the stream
+				send(errorBody{Error: errorDetail{Message: err.Error(), Type: "server_error"}})
+			}
+			return
+		}
+		mergeMeta(&meta, chunk.ResponseMeta)
+
+		delta := &RespMessage{}
+		if first {
+			delta.Role = "assistant"
+		}
+		if chunk.Content != "" || first {
+			content := chunk.Content
+			delta.Content = &content
+		}
+		if len(chunk.ToolCalls) > 0 {
+			delta.ToolCalls = toWireToolCalls(chunk.ToolCalls, calls)
+		}
+		if delta.Role == "" && delta.Content == nil && delta.ToolCalls == nil {
+			continue
+		}
+		first = false
+		if !send(c.chunk(delta, nil, nil)) {
+			return
+		}
+	}
+
+	finish := finishReason(&meta, calls.count > 0)
+	if !send(c.chunk(&RespMessage{}, &finish, nil)) {
+		return
+	}
+	if includeUsage {
+		usage := c.totalUsage(&meta)
+		if usage == nil {
+			usage = &Usage{}
+		}
+		if !send(&Response{ID: c.id, Object: "chat.completion.chunk", Created: c.created, Model: c.model, Choices: []Choice{}, Usage: usage}) {
+			return
+		}
+	}
+	_, _ = fmt.Fprint(w, "data: [DONE]\n\n")
+	flusher.Flush()
+}
+
+func (c *completion) chunk(delta *RespMessage, finish *string, usage *Usage) *Response {
+	return &Response{
+		ID:      c.id,
+		Object:  "chat.completion.chunk",
+		Created: c.created,
+		Model:   c.model,
+		Choices: []Choice{{Delta: delta, FinishReason: finish}},
+		Usage:   usage,
+	}
+}
+
+// totalUsage 优先使用 backend 统计的整个调用的用量, 其次是回答本身的用量.
+func (c *completion) totalUsage(meta *schema.ResponseMeta) *Usage {
+	var u *schema.TokenUsage
+	if c.usage != nil {
+		u = c.usage()
+	}
+	if u == nil && meta != nil {
+		u = meta.Usage
+	}
+	if u == nil {
+		return nil
+	}
+	total := u.TotalTokens
+	if total == 0 {
+		total = u.PromptTokens + u.CompletionTokens
+	}
+	return &Usage{PromptTokens: u.PromptTokens, CompletionTokens: u.CompletionTokens, TotalTokens: total}
+}
+
+// mergeMeta 和 ConcatMessages 一样合并 chunk 的 ResponseMeta, 但不修改 chunk.
+func mergeMeta(dst *schema.ResponseMeta, src *schema.ResponseMeta) {
+	if src == nil {
+		return
+	}
+	if src.FinishReason != "" {
+		dst.FinishReason = src.FinishReason
+	}
+	if src.Usage == nil {
+		return
+	}
+	if dst.Usage == nil {
+		dst.Usage = &schema.TokenUsage{}
+	}
+	dst.Usage.PromptTokens = max(dst.Usage.PromptTokens, src.Usage.PromptTokens)
+	dst.Usage.CompletionTokens = max(dst.Usage.CompletionTokens, src.Usage.CompletionTokens)
+	dst.Usage.TotalTokens = max(dst.Usage.TotalTokens, src.Usage.TotalTokens)
+}
+
+func finishReason(meta *schema.ResponseMeta, hasToolCalls bool) string {
+	if hasToolCalls {
+		return "tool_calls"
+	}
+	if meta != nil {
+		switch meta.FinishReason {
+		case "length", "content_filter":
+			return meta.FinishReason
+		}
+	}
+	return "stop"
+}
+
+func randomID() string {
+	b := make([]byte, 12)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, typ, code, msg string) {
+	detail := errorDetail{Message: msg, Type: typ}
+	if code != "" {
+		detail.Code = &code
+	}
+	writeJSON(w, status, errorBody{Error: detail})
+}
@@ -0,0 +1,313 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package openai
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// scriptModel 按给定的分块回答, 并记录绑定的工具.
+type scriptModel struct {
+	chunks []*schema.Message
+	tools  []*schema.ToolInfo
+}
+
+func (m *scriptModel) Generate(context.Context, []*schema.Message, ...model.Option) (*schema.Message, error) {
+	return schema.ConcatMessages(m.chunks)
+}
+
+func (m *scriptModel) Stream(context.Context, []*schema.Message, ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	return schema.StreamReaderFromArray(m.chunks), nil
+}
+
+func (m *scriptModel) BindTools(tools []*schema.ToolInfo) error {
+	m.tools = tools
+	return nil
+}
+
+func newServer(t *testing.T, m *scriptModel) *httptest.Server {
+	t.Helper()
+	h, err := NewHandler(
+		Model{ID: "script", Backend: ChatModelBackend(func(context.Context) (model.ChatModel, error) { return m, nil })},
+		Model{ID: "other", OwnedBy: "tests", Backend: ChatModelBackend(func(context.Context) (model.ChatModel, error) { return m, nil })},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func post(t *testing.T, srv *httptest.Server, body string) *http.Response {
+	t.Helper()
+	resp, err := http.Post(srv.URL+"/v1/chat/completions", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+// events 读出 SSE 的 data 行.
+func events(t *testing.T, resp *http.Response) []string {
+	t.Helper()
+	var res []string
+	sc := bufio.NewScanner(resp.Body)
+	for sc.Scan() {
+		if data, ok := strings.CutPrefix(sc.Text(), "data: "); ok {
+			res = append(res, data)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+func textChunks(parts ...string) []*schema.Message {
+	var res []*schema.Message
+	for _, p := range parts {
+		res = append(res, schema.AssistantMessage(p, nil))
+	}
+	return res
+}
+
+func TestListModels(t *testing.T) {
+	srv := newServer(t, &scriptModel{})
+	resp, err := http.Get(srv.URL + "/v1/models")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var list struct {
+		Object string      `json:"object"`
+		Data   []ModelInfo `json:"data"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || list.Object != "list" || len(list.Data) != 2 {
+		t.Fatalf("got %d %+v", resp.StatusCode, list)
+	}
+	if m := list.Data[0]; m.ID != "script" || m.Object != "model" || m.OwnedBy != "eino-exp" {
+		t.Errorf("first model = %+v", m)
+	}
+	if m := list.Data[1]; m.ID != "other" || m.OwnedBy != "tests" {
+		t.Errorf("second model = %+v", m)
+	}
+}
+
+func TestUnknownModel(t *testing.T) {
+	srv := newServer(t, &scriptModel{})
+	resp := post(t, srv, `{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}]}`)
+
+	var body errorBody
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusNotFound || body.Error.Code == nil || *body.Error.Code != "model_not_found" {
+		t.Errorf("got %d %+v, want model_not_found", resp.StatusCode, body.Error)
+	}
+}
+
+func TestCompletion(t *testing.T) {
+	chunks := textChunks("Try the ", "mapo tofu.")
+	chunks[1].ResponseMeta = &schema.ResponseMeta{
+		FinishReason: "stop",
+		Usage:        &schema.TokenUsage{PromptTokens: 10, CompletionTokens: 5},
+	}
+	srv := newServer(t, &scriptModel{chunks: chunks})
+	resp := post(t, srv, `{"model":"script","messages":[{"role":"user","content":[{"type":"text","text":"hi"}]}]}`)
+
+	var got Response
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || got.Object != "chat.completion" || got.Model != "script" || len(got.Choices) != 1 {
+		t.Fatalf("got %d %+v", resp.StatusCode, got)
+	}
+	c := got.Choices[0]
+	if c.Message.Content == nil || *c.Message.Content != "Try the mapo tofu." || c.Message.Role != "assistant" {
+		t.Errorf("message = %+v", c.Message)
+	}
+	if c.FinishReason == nil || *c.FinishReason != "stop" {
+		t.Errorf("finish_reason = %v", c.FinishReason)
+	}
+	if got.Usage == nil || *got.Usage != (Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15}) {
+		t.Errorf("usage = %+v", got.Usage)
+	}
+}
+
+func TestStream(t *testing.T) {
+	chunks := textChunks("", "Try the ", "mapo tofu.")
+	chunks[2].ResponseMeta = &schema.ResponseMeta{Usage: &schema.TokenUsage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15}}
+
+	for _, includeUsage := range []bool{false, true} {
+		t.Run(map[bool]string{false: "without usage", true: "include usage"}[includeUsage], func(t *testing.T) {
+			srv := newServer(t, &scriptModel{chunks: chunks})
+			body := `{"model":"script","stream":true,"messages":[{"role":"user","content":"hi"}]}`
+			if includeUsage {
+				body = `{"model":"script","stream":true,"stream_options":{"include_usage":true},"messages":[{"role":"user","content":"hi"}]}`
+			}
+			resp := post(t, srv, body)
+			if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+				t.Errorf("Content-Type = %q", ct)
+			}
+
+			data := events(t, resp)
+			if len(data) == 0 || data[len(data)-1] != "[DONE]" {
+				t.Fatalf("events %q, want [DONE] last", data)
+			}
+			var got []Response
+			for _, d := range data[:len(data)-1] {
+				var r Response
+				if err := json.Unmarshal([]byte(d), &r); err != nil {
+					t.Fatalf("chunk %s: %v", d, err)
+				}
+				if r.Object != "chat.completion.chunk" || len(got) > 0 && r.ID != got[0].ID {
+					t.Errorf("chunk %s: wrong object or id", d)
+				}
+				got = append(got, r)
+			}
+
+			// 角色在第一个分块, 空的分块不发送, 然后是 finish_reason, 最后是用量
+			want := 4
+			if includeUsage {
+				want = 5
+			}
+			if len(got) != want {
+				t.Fatalf("got %d chunks, want %d: %q", len(got), want, data)
+			}
+			var content strings.Builder
+			for _, r := range got[:3] {
+				if r.Choices[0].Delta.Content != nil {
+					content.WriteString(*r.Choices[0].Delta.Content)
+				}
+			}
+			if got[0].Choices[0].Delta.Role != "assistant" || content.String() != "Try the mapo tofu." {
+				t.Errorf("deltas give role %q and %q", got[0].Choices[0].Delta.Role, content.String())
+			}
+			if f := got[3].Choices[0].FinishReason; f == nil || *f != "stop" {
+				t.Errorf("finish_reason = %v", f)
+			}
+			for _, r := range got[:4] {
+				if r.Usage != nil {
+					t.Errorf("usage in a delta chunk: %+v", r.Usage)
+				}
+			}
+			if includeUsage {
+				last := got[4]
+				if len(last.Choices) != 0 || last.Usage == nil || last.Usage.TotalTokens != 15 {
+					t.Errorf("usage chunk = %+v", last)
+				}
+			}
+		})
+	}
+}
+
+func toolDelta(id, name, args string) *schema.Message {
+	return schema.AssistantMessage("", []schema.ToolCall{{ID: id, Function: schema.FunctionCall{Name: name, Arguments: args}}})
+}
+
+func TestToolPassthrough(t *testing.T) {
+	const req = `{"model":"script","stream":%s,"messages":[{"role":"user","content":"hi"}],
+		"tools":[{"type":"function","function":{"name":"query_dishes","parameters":{"type":"object","properties":{"restaurant_id":{"type":"string"}}}}},
+		         {"type":"function","function":{"name":"query_restaurants"}}]}`
+
+	t.Run("stream", func(t *testing.T) {
+		// 没有 Index 的分块: 参数分在几个分块中, 然后是第二个调用
+		m := &scriptModel{chunks: []*schema.Message{
+			toolDelta("call_a", "query_dishes", ""),
+			toolDelta("", "", `{"restaurant_id":`),
+			toolDelta("", "", `"1001"}`),
+			toolDelta("call_b", "query_restaurants", `{}`),
+			toolDelta("call_a", "", ""),
+		}}
+		srv := newServer(t, m)
+		data := events(t, post(t, srv, strings.Replace(req, "%s", "true", 1)))
+
+		var (
+			indexes []int
+			args    = map[int]string{}
+			finish  string
+		)
+		for _, d := range data[:len(data)-1] {
+			var r Response
+			if err := json.Unmarshal([]byte(d), &r); err != nil {
+				t.Fatal(err)
+			}
+			c := r.Choices[0]
+			for _, tc := range c.Delta.ToolCalls {
+				indexes = append(indexes, *tc.Index)
+				args[*tc.Index] += tc.Function.Arguments
+			}
+			if c.FinishReason != nil {
+				finish = *c.FinishReason
+			}
+		}
+		if !slices.Equal(indexes, []int{0, 0, 0, 1, 0}) {
+			t.Errorf("indexes %v, want the calls being continued", indexes)
+		}
+		if args[0] != `{"restaurant_id":"1001"}` || args[1] != `{}` {
+			t.Errorf("arguments %q", args)
+		}
+		if finish != "tool_calls" {
+			t.Errorf("finish_reason = %q", finish)
+		}
+		if len(m.tools) != 2 || m.tools[0].Name != "query_dishes" || m.tools[0].ParamsOneOf == nil {
+			t.Errorf("bound tools %+v", m.tools)
+		}
+	})
+
+	t.Run("generate", func(t *testing.T) {
+		m := &scriptModel{chunks: []*schema.Message{toolDelta("call_a", "query_dishes", `{"restaurant_id":"1001"}`)}}
+		srv := newServer(t, m)
+		var got Response
+		if err := json.NewDecoder(post(t, srv, strings.Replace(req, "%s", "false", 1)).Body).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		msg := got.Choices[0].Message
+		if len(msg.ToolCalls) != 1 || msg.ToolCalls[0].Index != nil || msg.ToolCalls[0].ID != "call_a" ||
+			msg.ToolCalls[0].Function.Arguments != `{"restaurant_id":"1001"}` {
+			t.Errorf("tool calls %+v, want the call without an index", msg.ToolCalls)
+		}
+		if f := got.Choices[0].FinishReason; f == nil || *f != "tool_calls" {
+			t.Errorf("finish_reason = %v", f)
+		}
+	})
+
+	t.Run("tool_choice none", func(t *testing.T) {
+		m := &scriptModel{chunks: textChunks("hi")}
+		srv := newServer(t, m)
+		resp := post(t, srv, strings.Replace(strings.Replace(req, "%s", "false", 1), `"messages"`, `"tool_choice":"none","messages"`, 1))
+		if resp.StatusCode != http.StatusOK || m.tools != nil {
+			t.Errorf("got %d with bound tools %+v, want no tools", resp.StatusCode, m.tools)
+		}
+	})
+}
@@ -0,0 +1,283 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package openai
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// Request is the body of POST /v1/chat/completions, the fields the backends can honor.
+type Request struct {
+	Model         string         `json:"model"`
+	Messages      []Message      `json:"messages"`
+	Stream        bool           `json:"stream,omitempty"`
+	StreamOptions *StreamOptions `json:"stream_options,omitempty"`
+	Tools         []Tool         `json:"tools,omitempty"`
+	ToolChoice    any            `json:"tool_choice,omitempty"`
+	Temperature   *float32       `json:"temperature,omitempty"`
+	TopP          *float32       `json:"top_p,omitempty"`
+	MaxTokens     *int           `json:"max_tokens,omitempty"`
+	// MaxCompletionTokens replaces MaxTokens in newer clients
+	MaxCompletionTokens *int       `json:"max_completion_tokens,omitempty"`
+	Stop                StringList `json:"stop,omitempty"`
+}
+
+// StreamOptions of a streaming request.
+type StreamOptions struct {
+	// IncludeUsage sends a last chunk with the usage and no choices
+	IncludeUsage bool `json:"include_usage"`
+}
+
+// Message is a chat message in the OpenAI format.
+type Message struct {
+	Role       string     `json:"role"`
+	Content    Content    `json:"content"`
+	Name       string     `json:"name,omitempty"`
+	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string     `json:"tool_call_id,omitempty"`
+}
+
+// Content is a string, or an array of parts of which the text parts are kept.
+type Content string
+
+func (c *Content) UnmarshalJSON(b []byte) error {
+	var s *string
+	if err := json.Unmarshal(b, &s); err == nil {
+		if s != nil {
+			*c = Content(*s)
+		}
+		return nil
+	}
+
+	var parts []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(b, &parts); err != nil {
+		return errors.New("content must be a string or an array of parts")
+	}
+	var texts []string
+	for _, p := range parts {
+		if p.Type != "text" {
+			return fmt.Errorf("content parts of type %q are not supported", p.Type)
+		}
+		texts = append(texts, p.Text)
+	}
+	*c = Content(strings.Join(texts, "\n"))
+	return nil
+}
+
+// StringList is a string or an array of strings, like stop.
+type StringList []string
+
+func (l *StringList) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*l = StringList{s}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(b, &list); err != nil {
+		return errors.New("must be a string or an array of strings")
+	}
+	*l = list
+	return nil
+}
+
+// ToolCall is a tool call of an assistant message. Index is only set in stream deltas.
+type ToolCall struct {
+	Index    *int         `json:"index,omitempty"`
+	ID       string       `json:"id,omitempty"`
+	Type     string       `json:"type,omitempty"`
+	Function FunctionCall `json:"function"`
+}
+
+// FunctionCall is the function of a ToolCall.
+type FunctionCall struct {
+	Name      string `json:"name,omitempty"`
+	Arguments string `json:"arguments"`
+}
+
+// Tool is a tool the client offers the model.
+type Tool struct {
+	Type     string   `json:"type"`
+	Function Function `json:"function"`
+}
+
+// Function describes a tool, Parameters is a JSON schema.
+type Function struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Parameters  json.RawMessage `json:"parameters,omitempty"`
+}
+
+// Response is a non-streaming answer.
+type Response struct {
+	ID      string   `json:"id"`
+	Object  string   `json:"object"`
+	Created int64    `json:"created"`
+	Model   string   `json:"model"`
+	Choices []Choice `json:"choices"`
+	Usage   *Usage   `json:"usage,omitempty"`
+}
+
+// Choice of a Response.
+type Choice struct {
+	Index        int          `json:"index"`
+	Message      *RespMessage `json:"message,omitempty"`
+	Delta        *RespMessage `json:"delta,omitempty"`
+	FinishReason *string      `json:"finish_reason"`
+}
+
+// RespMessage is the message, or the delta of a chunk, of a Choice.
+type RespMessage struct {
+	Role      string     `json:"role,omitempty"`
+	Content   *string    `json:"content,omitempty"`
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+}
+
+// Usage is the token usage of a request.
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// ModelInfo is an entry of GET /v1/models.
+type ModelInfo struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"`
+	Created int64  `json:"created"`
+	OwnedBy string `json:"owned_by"`
+}
+
+type errorBody struct {
+	Error errorDetail `json:"error"`
+}
+
+type errorDetail struct {
+	Message string  `json:"message"`
+	Type    string  `json:"type"`
+	Param   *string `json:"param"`
+	Code    *string `json:"code"`
+}
+
+// toSchema 把请求的消息转换成 eino 的消息.
+func toSchema(msgs []Message) ([]*schema.Message, error) {
+	res := make([]*schema.Message, 0, len(msgs))
+	for i, m := range msgs {
+		msg := &schema.Message{Content: string(m.Content), Name: m.Name}
+		switch m.Role {
+		case "system", "developer":
+			msg.Role = schema.System
+		case "user":
+			msg.Role = schema.User
+		case "assistant":
+			msg.Role = schema.Assistant
+			for _, tc := range m.ToolCalls {
+				msg.ToolCalls = append(msg.ToolCalls, schema.ToolCall{
+					ID:       tc.ID,
+					Type:     "function",
+					Function: schema.FunctionCall{Name: tc.Function.Name, Arguments: tc.Function.Arguments},
+				})
+			}
+		case "tool":
+			if m.ToolCallID == "" {
+				return nil, fmt.Errorf("messages[%d]: tool messages need a tool_call_id", i)
+			}
+			msg.Role = schema.Tool
+			msg.ToolCallID = m.ToolCallID
+		default:
+			return nil, fmt.Errorf("messages[%d]: unknown role %q", i, m.Role)
+		}
+		res = append(res, msg)
+	}
+	return res, nil
+}
+
+// toolInfos 把请求中的工具转换成 eino 的工具描述.
+func toolInfos(tools []Tool) ([]*schema.ToolInfo, error) {
+	infos := make([]*schema.ToolInfo, 0, len(tools))
+	for i, t := range tools {
+		if t.Type != "function" || t.Function.Name == "" {
+			return nil, fmt.Errorf("tools[%d]: only function tools with a name are supported", i)
+		}
+
+		info := &schema.ToolInfo{Name: t.Function.Name, Desc: t.Function.Description}
+		if len(t.Function.Parameters) > 0 && string(t.Function.Parameters) != "null" {
+			var params openapi3.Schema
+			if err := json.Unmarshal(t.Function.Parameters, &params); err != nil {
+				return nil, fmt.Errorf("tools[%d]: invalid parameters: %w", i, err)
+			}
+			info.ParamsOneOf = schema.NewParamsOneOfByOpenAPIV3(&params)
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+// toWireToolCalls 转换工具调用, ids 为空时不设置 Index, 如非流式的回答.
+func toWireToolCalls(calls []schema.ToolCall, ids *toolIndexer) []ToolCall {
+	res := make([]ToolCall, 0, len(calls))
+	for _, tc := range calls {
+		wire := ToolCall{
+			ID:       tc.ID,
+			Type:     "function",
+			Function: FunctionCall{Name: tc.Function.Name, Arguments: tc.Function.Arguments},
+		}
+		if ids != nil {
+			idx := ids.index(tc)
+			wire.Index = &idx
+		}
+		res = append(res, wire)
+	}
+	return res
+}
+
+// toolIndexer 给流中的工具调用分块编号. 没有 Index 的分块, 新的 ID 或名称开始下一个调用,
+// 已知的 ID 和没有 ID 与名称的分块接着原来的调用.
+type toolIndexer struct {
+	byID  map[string]int
+	last  int // 上一个分块所属的调用, 还没有时为 -1
+	count int // 调用的个数
+}
+
+func newToolIndexer() *toolIndexer {
+	return &toolIndexer{byID: map[string]int{}, last: -1}
+}
+
+func (x *toolIndexer) index(tc schema.ToolCall) int {
+	switch i, known := x.byID[tc.ID]; {
+	case tc.Index != nil:
+		x.last = *tc.Index
+	case tc.ID != "" && known:
+		x.last = i
+	case tc.ID != "" || tc.Function.Name != "" || x.last < 0:
+		x.last = x.count
+	}
+	if tc.ID != "" {
+		x.byID[tc.ID] = x.last
+	}
+	x.count = max(x.count, x.last+1)
+	return x.last
+}
@@ -0,0 +1,195 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package openapitool
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+// GenerateConfig configures Generate.
+type GenerateConfig struct {
+	Package    string // the package of the generated file
+	Source     string // where the specification came from, for the header
+	ServerURL  string // the default base URL, see ServerURL
+	Operations []*Operation
+}
+
+// Generate writes a Go file with the operations as literals, so that the tools need
+// neither the specification nor kin-openapi at runtime. The file has:
+//
+//	var ServerURL string                     // the default base URL
+//	var Operations []*openapitool.Operation
+//	func Tools(conf *openapitool.Config) ([]tool.BaseTool, error)
+//	func New<Operation>Tool(conf *openapitool.Config) (tool.InvokableTool, error) // for each operation
+func Generate(conf *GenerateConfig) ([]byte, error) {
+	if !isIdent(conf.Package) {
+		return nil, fmt.Errorf("openapi: invalid package name %q", conf.Package)
+	}
+
+	funcs := map[string]string{}
+	for _, op := range conf.Operations {
+		fn := "New" + exportedName(op.Name) + "Tool"
+		for _, other := range funcs {
+			if other == fn {
+				return nil, fmt.Errorf("openapi: operations %s and another are both generated as %s", op.Name, fn)
+			}
+		}
+		funcs[op.Name] = fn
+	}
+
+	var buf bytes.Buffer
+	err := fileTemplate.Execute(&buf, map[string]any{
+		"Source":     conf.Source,
+		"Package":    conf.Package,
+		"ServerURL":  conf.ServerURL,
+		"Operations": conf.Operations,
+		"Funcs":      funcs,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("openapi: format the generated code: %w\n%s", err, buf.Bytes())
+	}
+	return src, nil
+}
+
+// exportedName 把 operation 名字转为导出的 Go 标识符, 如 list-menu_items -> ListMenuItems.
+func exportedName(name string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range name {
+		if r == '_' || r == '-' {
+			upper = true
+			continue
+		}
+		if b.Len() == 0 && unicode.IsDigit(r) {
+			b.WriteString("Op")
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	if b.Len() == 0 {
+		return "Op"
+	}
+	return b.String()
+}
+
+func isIdent(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		if !unicode.IsLetter(r) && r != '_' && (i == 0 || !unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return true
+}
+
+var fileTemplate = template.Must(template.New("file").Funcs(template.FuncMap{
+	"quote": strconv.Quote,
+	"raw": func(b []byte) string {
+		if bytes.ContainsRune(b, '`') {
+			return strconv.Quote(string(b))
+		}
+		return "`" + string(b) + "`"
+	},
+	"comment": func(s string) string {
+		s = strings.Join(strings.Fields(s), " ")
+		if r := []rune(s); len(r) > 100 {
+			s = string(r[:100]) + "..."
+		}
+		if s != "" && !strings.HasSuffix(s, ".") {
+			s += "."
+		}
+		return s
+	},
+}).Parse(`// Code generated by genopenapi{{if .Source}} from {{.Source}}{{end}}. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"encoding/json"
+
+	"github.com/cloudwego/eino/components/tool"
+
+	"github.com/galihrivanto/eino-exp/internal/openapitool"
+)
+
+// ServerURL is the base URL used when the config has none.
+var ServerURL = {{quote .ServerURL}}
+
+// Operations are the operations of the service the tools call.
+var Operations = []*openapitool.Operation{
+{{- range .Operations}}
+	{
+		Name:   {{quote .Name}},
+		Desc:   {{quote .Desc}},
+		Method: {{quote .Method}},
+		Path:   {{quote .Path}},
+		{{- if .Params}}
+		Params: []openapitool.Param{
+			{{- range .Params}}
+			{Name: {{quote .Name}}, In: {{quote .In}}, Arg: {{quote .Arg}}},
+			{{- end}}
+		},
+		{{- end}}
+		{{- if .Body}}
+		Body: &openapitool.Body{Arg: {{quote .Body.Arg}}, Required: {{.Body.Required}}},
+		{{- end}}
+		{{- if .Auth}}
+		Auth: []openapitool.Scheme{
+			{{- range .Auth}}
+			{Name: {{quote .Name}}, Type: {{quote .Type}}{{with .In}}, In: {{quote .}}{{end}}{{with .Param}}, Param: {{quote .}}{{end}}{{with .Scheme}}, Scheme: {{quote .}}{{end}}},
+			{{- end}}
+		},
+		{{- end}}
+		InputSchema: json.RawMessage({{raw .InputSchema}}),
+	},
+{{- end}}
+}
+
+// Tools returns a tool for every operation.
+func Tools(conf *openapitool.Config) ([]tool.BaseTool, error) {
+	return openapitool.NewTools(Operations, withServerURL(conf))
+}
+{{range $i, $op := .Operations}}
+// {{index $.Funcs $op.Name}} returns the tool of {{$op.Method}} {{$op.Path}}{{with comment $op.Desc}}: {{.}}{{else}}.{{end}}
+func {{index $.Funcs $op.Name}}(conf *openapitool.Config) (tool.InvokableTool, error) {
+	return openapitool.NewTool(Operations[{{$i}}], withServerURL(conf))
+}
+{{end}}
+func withServerURL(conf *openapitool.Config) *openapitool.Config {
+	c := *conf
+	if c.BaseURL == "" {
+		c.BaseURL = ServerURL
+	}
+	return &c
+}
+`))
@@ -0,0 +1,88 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package openapitool
+
+import (
+	"bytes"
+	"flag"
+	"go/parser"
+	"go/token"
+	"os"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+func TestGenerate(t *testing.T) {
+	doc := loadMenu(t)
+	ops, err := Operations(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := Generate(&GenerateConfig{
+		Package:    "menu",
+		Source:     "testdata/menu.yaml",
+		ServerURL:  ServerURL(doc, ""),
+		Operations: ops,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = parser.ParseFile(token.NewFileSet(), "menu_gen.go", src, 0); err != nil {
+		t.Fatalf("the generated code doesn't parse: %v", err)
+	}
+
+	const golden = "testdata/menu_gen.go.golden"
+	if *update {
+		if err = os.WriteFile(golden, src, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(src, want) {
+		t.Errorf("Generate output differs from %s, run go test -update if the change is intended:\n%s", golden, src)
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	if _, err := Generate(&GenerateConfig{Package: "menu-tools"}); err == nil {
+		t.Error("no error for an invalid package name")
+	}
+
+	// list_menu 和 list-menu 生成同名的函数
+	ops := []*Operation{{Name: "list_menu"}, {Name: "list-menu"}}
+	if _, err := Generate(&GenerateConfig{Package: "menu", Operations: ops}); err == nil {
+		t.Error("no error for operations generated as the same function")
+	}
+}
+
+func TestExportedName(t *testing.T) {
+	tests := map[string]string{
+		"listDishes":      "ListDishes",
+		"list-menu_items": "ListMenuItems",
+		"2fa_check":       "Op2faCheck",
+		"_":               "Op",
+	}
+	for name, want := range tests {
+		if got := exportedName(name); got != want {
+			t.Errorf("exportedName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
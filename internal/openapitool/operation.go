@@ -0,0 +1,430 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package openapitool turns the operations of an OpenAPI 3 specification into eino tools
+// that call the HTTP service, either at runtime from the specification or from Go code
+// generated by react/genopenapi.
+package openapitool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+
+	"github.com/galihrivanto/eino-exp/internal/logs"
+)
+
+// Operation describes how a tool calls one operation of the service. It is read from the
+// specification by Operations, or written as a literal by the generator.
+type Operation struct {
+	Name   string // the tool name: the operationId, or method_path when it has none
+	Desc   string
+	Method string
+	Path   string // with {param} placeholders, relative to the base URL
+	Params []Param
+	Body   *Body    // nil when the operation takes no JSON body
+	Auth   []Scheme // the security schemes the operation accepts
+	// InputSchema is the OpenAPI 3 schema of the tool arguments, an object with the
+	// parameters and the properties of the body as its properties.
+	InputSchema json.RawMessage
+}
+
+// Param is a path, query, header or cookie parameter.
+type Param struct {
+	Name string // in the request
+	In   string // path, query, header or cookie
+	Arg  string // in the tool arguments, the name unless it clashes with another parameter
+}
+
+// Body is the JSON request body.
+type Body struct {
+	// Arg is the argument holding the body. When empty, the body is an object made of
+	// the arguments that aren't parameters.
+	Arg      string
+	Required bool
+}
+
+// Scheme is a security scheme of the specification, see Config.Credentials.
+type Scheme struct {
+	Name   string // the key in components.securitySchemes
+	Type   string // apiKey or http
+	In     string // header, query or cookie, for apiKey
+	Param  string // the header, query or cookie name, for apiKey
+	Scheme string // bearer or basic, for http
+}
+
+// maxDepth 内联的最大深度, 更深的 schema 用空 schema 代替.
+const maxDepth = 8
+
+var (
+	invalidNameChars = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+	underscores      = regexp.MustCompile(`__+`)
+)
+
+// Load reads and validates a specification from a file or an http(s) URL, in JSON or YAML.
+func Load(ctx context.Context, location string) (*openapi3.T, error) {
+	loader := openapi3.NewLoader()
+	loader.Context = ctx
+	loader.IsExternalRefsAllowed = true
+
+	var (
+		doc *openapi3.T
+		err error
+	)
+	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+		u, perr := url.Parse(location)
+		if perr != nil {
+			return nil, perr
+		}
+		doc, err = loader.LoadFromURI(u)
+	} else {
+		doc, err = loader.LoadFromFile(location)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("openapi: load %s: %w", location, err)
+	}
+	if err = doc.Validate(ctx); err != nil {
+		return nil, fmt.Errorf("openapi: invalid specification %s: %w", location, err)
+	}
+	return doc, nil
+}
+
+// ServerURL returns the URL of the first server of the specification with the defaults
+// of its variables, resolved against location when it is relative. It is empty when the
+// specification lists no server.
+func ServerURL(doc *openapi3.T, location string) string {
+	if len(doc.Servers) == 0 {
+		return ""
+	}
+	s := doc.Servers[0]
+	u := s.URL
+	for name, v := range s.Variables {
+		u = strings.ReplaceAll(u, "{"+name+"}", v.Default)
+	}
+
+	if base, err := url.Parse(location); err == nil && base.IsAbs() {
+		if ref, err := url.Parse(u); err == nil {
+			return base.ResolveReference(ref).String()
+		}
+	}
+	return u
+}
+
+// Operations returns the operations of the specification sorted by name, only those
+// named when names are given. Operations with a body that isn't JSON are skipped.
+func Operations(doc *openapi3.T, names ...string) ([]*Operation, error) {
+	want := map[string]bool{}
+	for _, n := range names {
+		want[n] = true
+	}
+
+	var ops []*Operation
+	seen := map[string]string{}
+	for path, item := range doc.Paths {
+		for method, o := range item.Operations() {
+			op, err := newOperation(doc, path, method, item, o)
+			if err != nil {
+				return nil, fmt.Errorf("openapi: %s %s: %w", method, path, err)
+			}
+			if op == nil || (len(want) > 0 && !want[op.Name]) {
+				continue
+			}
+			if other, ok := seen[op.Name]; ok {
+				return nil, fmt.Errorf("openapi: %s %s and %s are both named %s", method, path, other, op.Name)
+			}
+			seen[op.Name] = method + " " + path
+			ops = append(ops, op)
+		}
+	}
+
+	for _, n := range names {
+		if _, ok := seen[n]; !ok {
+			return nil, fmt.Errorf("openapi: no operation named %s", n)
+		}
+	}
+	sort.Slice(ops, func(i, j int) bool { return ops[i].Name < ops[j].Name })
+	return ops, nil
+}
+
+func newOperation(doc *openapi3.T, path, method string, item *openapi3.PathItem, o *openapi3.Operation) (*Operation, error) {
+	op := &Operation{
+		Name:   toolName(o.OperationID, method, path),
+		Desc:   describe(o),
+		Method: method,
+		Path:   path,
+	}
+
+	args := &openapi3.Schema{Type: openapi3.TypeObject, Properties: openapi3.Schemas{}}
+	for _, p := range parameters(item, o) {
+		if p.In == openapi3.ParameterInHeader && isReservedHeader(p.Name) {
+			continue
+		}
+
+		arg := p.Name
+		if _, ok := args.Properties[arg]; ok {
+			arg = p.In + "_" + p.Name
+		}
+		op.Params = append(op.Params, Param{Name: p.Name, In: p.In, Arg: arg})
+
+		sc := paramSchema(p)
+		if sc.Description == "" {
+			sc.Description = p.Description
+		}
+		args.Properties[arg] = &openapi3.SchemaRef{Value: sc}
+		if p.Required || p.In == openapi3.ParameterInPath {
+			args.Required = append(args.Required, arg)
+		}
+	}
+
+	if o.RequestBody != nil && o.RequestBody.Value != nil {
+		rb := o.RequestBody.Value
+		mt := rb.Content.Get("application/json")
+		if mt == nil {
+			for ct, m := range rb.Content {
+				if strings.HasSuffix(strings.SplitN(ct, ";", 2)[0], "+json") {
+					mt = m
+					break
+				}
+			}
+		}
+		if mt == nil {
+			logs.Infof("openapi: skip %s %s, its request body isn't JSON", method, path)
+			return nil, nil
+		}
+
+		op.Body = &Body{Required: rb.Required}
+		body := inline(mt.Schema, 0)
+		if body == nil {
+			body = &openapi3.SchemaRef{Value: &openapi3.Schema{}}
+		}
+		if flattenable(body, args) {
+			for name, p := range body.Value.Properties {
+				args.Properties[name] = p
+			}
+			if rb.Required {
+				args.Required = append(args.Required, body.Value.Required...)
+			}
+		} else {
+			op.Body.Arg = "body"
+			if _, ok := args.Properties["body"]; ok {
+				op.Body.Arg = "request_body"
+			}
+			if body.Value.Description == "" {
+				body.Value.Description = rb.Description
+			}
+			args.Properties[op.Body.Arg] = body
+			if rb.Required {
+				args.Required = append(args.Required, op.Body.Arg)
+			}
+		}
+	}
+	sort.Strings(args.Required)
+
+	var err error
+	if op.InputSchema, err = json.Marshal(args); err != nil {
+		return nil, err
+	}
+	op.Auth = securitySchemes(doc, o)
+	return op, nil
+}
+
+// toolName 使用 operationId, 没有时用 method 和 path 拼接; 只保留模型 API 接受的字符.
+func toolName(operationID, method, path string) string {
+	name := operationID
+	if name == "" {
+		name = strings.ToLower(method) + "_" + path
+	}
+	name = underscores.ReplaceAllString(invalidNameChars.ReplaceAllString(name, "_"), "_")
+	name = strings.Trim(name, "_")
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	return name
+}
+
+func describe(o *openapi3.Operation) string {
+	desc := strings.TrimSpace(o.Summary)
+	if d := strings.TrimSpace(o.Description); d != "" && d != desc {
+		if desc != "" {
+			desc += "\n"
+		}
+		desc += d
+	}
+	if len(desc) > 1024 {
+		desc = desc[:1024]
+	}
+	return desc
+}
+
+// parameters 合并 path item 和 operation 的参数, operation 的优先.
+func parameters(item *openapi3.PathItem, o *openapi3.Operation) []*openapi3.Parameter {
+	var params []*openapi3.Parameter
+	index := map[string]int{}
+	for _, refs := range []openapi3.Parameters{item.Parameters, o.Parameters} {
+		for _, ref := range refs {
+			p := ref.Value
+			if p == nil {
+				continue
+			}
+			key := p.In + ":" + p.Name
+			if i, ok := index[key]; ok {
+				params[i] = p
+				continue
+			}
+			index[key] = len(params)
+			params = append(params, p)
+		}
+	}
+	return params
+}
+
+// isReservedHeader reports the headers OpenAPI ignores as parameters.
+func isReservedHeader(name string) bool {
+	switch strings.ToLower(name) {
+	case "accept", "content-type", "authorization":
+		return true
+	}
+	return false
+}
+
+func paramSchema(p *openapi3.Parameter) *openapi3.Schema {
+	ref := p.Schema
+	if ref == nil {
+		for _, mt := range p.Content {
+			ref = mt.Schema
+			break
+		}
+	}
+	if ref == nil || ref.Value == nil {
+		return &openapi3.Schema{Type: openapi3.TypeString}
+	}
+	return inline(ref, 0).Value
+}
+
+// flattenable 请求体是没有重名属性的 object 时, 它的属性直接作为参数.
+func flattenable(body *openapi3.SchemaRef, args *openapi3.Schema) bool {
+	sc := body.Value
+	if sc.Type != openapi3.TypeObject || len(sc.Properties) == 0 || (sc.AdditionalProperties.Has != nil && *sc.AdditionalProperties.Has) ||
+		sc.AdditionalProperties.Schema != nil || len(sc.OneOf) > 0 || len(sc.AnyOf) > 0 || len(sc.AllOf) > 0 {
+		return false
+	}
+	for name := range sc.Properties {
+		if _, ok := args.Properties[name]; ok {
+			return false
+		}
+	}
+	return true
+}
+
+// inline 复制 schema 并把 $ref 替换为引用的 schema, 模型只能看到一个完整的 schema.
+// 递归引用的 schema 第二次出现时不再展开.
+func inline(ref *openapi3.SchemaRef, depth int) *openapi3.SchemaRef {
+	return inlineRef(ref, depth, map[string]bool{})
+}
+
+func inlineRef(ref *openapi3.SchemaRef, depth int, path map[string]bool) *openapi3.SchemaRef {
+	if ref == nil {
+		return nil
+	}
+	if ref.Value == nil || depth > maxDepth {
+		return &openapi3.SchemaRef{Value: &openapi3.Schema{Description: "nested too deeply, any value"}}
+	}
+	if ref.Ref != "" {
+		if path[ref.Ref] {
+			name := ref.Ref[strings.LastIndex(ref.Ref, "/")+1:]
+			return &openapi3.SchemaRef{Value: &openapi3.Schema{
+				Type:        ref.Value.Type,
+				Description: fmt.Sprintf("a %s, nested like the enclosing one", name),
+			}}
+		}
+		path[ref.Ref] = true
+		defer delete(path, ref.Ref)
+	}
+
+	sc := *ref.Value
+	sc.Extensions = nil
+	sc.Properties = nil
+	if len(ref.Value.Properties) > 0 {
+		sc.Properties = make(openapi3.Schemas, len(ref.Value.Properties))
+		for name, p := range ref.Value.Properties {
+			sc.Properties[name] = inlineRef(p, depth+1, path)
+		}
+	}
+	sc.Items = inlineRef(ref.Value.Items, depth+1, path)
+	sc.Not = inlineRef(ref.Value.Not, depth+1, path)
+	sc.AdditionalProperties.Schema = inlineRef(ref.Value.AdditionalProperties.Schema, depth+1, path)
+	sc.OneOf = inlineAll(ref.Value.OneOf, depth, path)
+	sc.AnyOf = inlineAll(ref.Value.AnyOf, depth, path)
+	sc.AllOf = inlineAll(ref.Value.AllOf, depth, path)
+	return &openapi3.SchemaRef{Value: &sc}
+}
+
+func inlineAll(refs openapi3.SchemaRefs, depth int, path map[string]bool) openapi3.SchemaRefs {
+	if len(refs) == 0 {
+		return nil
+	}
+	out := make(openapi3.SchemaRefs, len(refs))
+	for i, r := range refs {
+		out[i] = inlineRef(r, depth+1, path)
+	}
+	return out
+}
+
+// securitySchemes 返回 operation (没有时是整个文档) 的 security requirement 用到的 scheme.
+func securitySchemes(doc *openapi3.T, o *openapi3.Operation) []Scheme {
+	reqs := doc.Security
+	if o.Security != nil {
+		reqs = *o.Security
+	}
+	if doc.Components == nil {
+		return nil
+	}
+
+	var schemes []Scheme
+	seen := map[string]bool{}
+	for _, req := range reqs {
+		names := make([]string, 0, len(req))
+		for name := range req {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			ref, ok := doc.Components.SecuritySchemes[name]
+			if !ok || ref.Value == nil || seen[name] {
+				continue
+			}
+			seen[name] = true
+			s := ref.Value
+			switch {
+			case s.Type == "apiKey":
+				schemes = append(schemes, Scheme{Name: name, Type: s.Type, In: s.In, Param: s.Name})
+			case s.Type == "http" && (strings.EqualFold(s.Scheme, "bearer") || strings.EqualFold(s.Scheme, "basic")):
+				schemes = append(schemes, Scheme{Name: name, Type: s.Type, Scheme: strings.ToLower(s.Scheme)})
+			case s.Type == "oauth2" || s.Type == "openIdConnect":
+				// the credential is an access token obtained elsewhere
+				schemes = append(schemes, Scheme{Name: name, Type: "http", Scheme: "bearer"})
+			}
+		}
+	}
+	return schemes
+}
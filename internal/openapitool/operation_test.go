@@ -0,0 +1,133 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package openapitool
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+func loadMenu(t *testing.T) *openapi3.T {
+	t.Helper()
+	doc, err := Load(context.Background(), "testdata/menu.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+func TestOperations(t *testing.T) {
+	doc := loadMenu(t)
+	if got := ServerURL(doc, ""); got != "http://menu.example.com/api" {
+		t.Errorf("ServerURL = %q", got)
+	}
+
+	ops, err := Operations(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	byName := map[string]*Operation{}
+	var names []string
+	for _, op := range ops {
+		byName[op.Name] = op
+		names = append(names, op.Name)
+	}
+	if !slices.Equal(names, []string{"add-review", "createOrder", "listDishes"}) {
+		t.Fatalf("operations %v, want them sorted by name", names)
+	}
+
+	list := byName["listDishes"]
+	wantParams := []Param{
+		{Name: "restaurant_id", In: "path", Arg: "restaurant_id"},
+		{Name: "tag", In: "query", Arg: "tag"},
+		{Name: "X-Locale", In: "header", Arg: "X-Locale"},
+		{Name: "session", In: "cookie", Arg: "session"},
+	}
+	if !reflect.DeepEqual(list.Params, wantParams) || list.Body != nil {
+		t.Errorf("listDishes params %+v, body %+v", list.Params, list.Body)
+	}
+	// 没有自己的 security 时使用文档的
+	if !reflect.DeepEqual(list.Auth, []Scheme{{Name: "apiKey", Type: "apiKey", In: "header", Param: "X-API-Key"}}) {
+		t.Errorf("listDishes auth %+v", list.Auth)
+	}
+
+	// 请求体的属性直接作为参数
+	order := byName["createOrder"]
+	if order.Body == nil || order.Body.Arg != "" || !order.Body.Required {
+		t.Errorf("createOrder body %+v, want a required flattened body", order.Body)
+	}
+	checkSchema(t, order, []string{"dish_id", "quantity"}, []string{"dish_id"})
+
+	// 请求体的 id 和 path 参数重名, 放在 body 参数中
+	review := byName["add-review"]
+	if review.Body == nil || review.Body.Arg != "body" || review.Body.Required {
+		t.Errorf("add-review body %+v, want an optional wrapped body", review.Body)
+	}
+	checkSchema(t, review, []string{"body", "id"}, []string{"id"})
+	wantAuth := []Scheme{
+		{Name: "basic", Type: "http", Scheme: "basic"},
+		{Name: "queryKey", Type: "apiKey", In: "query", Param: "key"},
+	}
+	if !reflect.DeepEqual(review.Auth, wantAuth) {
+		t.Errorf("add-review auth %+v", review.Auth)
+	}
+
+	if _, err = Operations(doc, "listDishes", "deleteDish"); err == nil {
+		t.Error("no error for an unknown operation")
+	}
+	if ops, err = Operations(doc, "createOrder"); err != nil || len(ops) != 1 {
+		t.Errorf("got %d operations, %v, want createOrder only", len(ops), err)
+	}
+}
+
+// checkSchema 检查参数 schema 的属性和必填项.
+func checkSchema(t *testing.T, op *Operation, props, required []string) {
+	t.Helper()
+	var sc struct {
+		Properties map[string]json.RawMessage `json:"properties"`
+		Required   []string                   `json:"required"`
+	}
+	if err := json.Unmarshal(op.InputSchema, &sc); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for name := range sc.Properties {
+		got = append(got, name)
+	}
+	slices.Sort(got)
+	if !slices.Equal(got, props) || !slices.Equal(sc.Required, required) {
+		t.Errorf("%s schema has %v required %v, want %v required %v", op.Name, got, sc.Required, props, required)
+	}
+}
+
+func TestToolName(t *testing.T) {
+	tests := []struct{ id, method, path, want string }{
+		{"listDishes", "GET", "/dishes", "listDishes"},
+		{"", "GET", "/restaurants/{id}/dishes", "get_restaurants_id_dishes"},
+		{"menu.v2/list", "GET", "/", "menu_v2_list"},
+	}
+	for _, tt := range tests {
+		if got := toolName(tt.id, tt.method, tt.path); got != tt.want {
+			t.Errorf("toolName(%q, %q, %q) = %q, want %q", tt.id, tt.method, tt.path, got, tt.want)
+		}
+	}
+}
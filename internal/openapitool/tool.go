@@ -0,0 +1,429 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package openapitool
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+	"github.com/getkin/kin-openapi/openapi3"
+
+	"github.com/galihrivanto/eino-exp/internal/logs"
+)
+
+// Config configures the tools of a service.
+type Config struct {
+	// BaseURL is prepended to the paths of the operations. Required.
+	BaseURL string
+	// Client sends the requests, default a client with a 30 second timeout.
+	Client *http.Client
+	// Headers are added to every request, e.g. a fixed Authorization header.
+	Headers map[string]string
+	// Credentials are the secrets of the security schemes by scheme name, "*" for any
+	// scheme without its own. An apiKey is sent as is, a bearer scheme sends
+	// "Bearer <secret>", a basic scheme takes "user:password".
+	Credentials map[string]string
+
+	// MaxResponseBytes cuts longer results, default 8 KiB.
+	MaxResponseBytes int
+	// MaxItems cuts longer JSON arrays of the results, default 20, negative keeps all.
+	MaxItems int
+	// MaxReadBytes is the most read of a response body, default 1 MiB.
+	MaxReadBytes int64
+}
+
+// New returns a tool for every operation of the specification, see Operations. The base
+// URL defaults to the first server of the specification.
+func New(ctx context.Context, doc *openapi3.T, conf *Config, names ...string) ([]tool.BaseTool, error) {
+	ops, err := Operations(doc, names...)
+	if err != nil {
+		return nil, err
+	}
+
+	c := *conf
+	if c.BaseURL == "" {
+		c.BaseURL = ServerURL(doc, "")
+	}
+	return NewTools(ops, &c)
+}
+
+// NewTools returns a tool for every operation.
+func NewTools(ops []*Operation, conf *Config) ([]tool.BaseTool, error) {
+	tools := make([]tool.BaseTool, 0, len(ops))
+	for _, op := range ops {
+		t, err := NewTool(op, conf)
+		if err != nil {
+			return nil, err
+		}
+		tools = append(tools, t)
+	}
+	return tools, nil
+}
+
+// NewTool returns the tool calling the operation.
+func NewTool(op *Operation, conf *Config) (tool.InvokableTool, error) {
+	c := *conf
+	if c.BaseURL == "" {
+		return nil, fmt.Errorf("openapi: tool %s: the base URL is missing, the specification lists no server", op.Name)
+	}
+	base, err := url.Parse(strings.TrimSuffix(c.BaseURL, "/"))
+	if err != nil || !base.IsAbs() {
+		return nil, fmt.Errorf("openapi: tool %s: invalid base URL %q", op.Name, c.BaseURL)
+	}
+	if c.Client == nil {
+		c.Client = &http.Client{Timeout: 30 * time.Second}
+	}
+	if c.MaxResponseBytes <= 0 {
+		c.MaxResponseBytes = 8 << 10
+	}
+	if c.MaxItems == 0 {
+		c.MaxItems = 20
+	}
+	if c.MaxReadBytes <= 0 {
+		c.MaxReadBytes = 1 << 20
+	}
+
+	info := &schema.ToolInfo{Name: op.Name, Desc: op.Desc}
+	if len(op.InputSchema) > 0 {
+		sc := &openapi3.Schema{}
+		if err = json.Unmarshal(op.InputSchema, sc); err != nil {
+			return nil, fmt.Errorf("openapi: tool %s: invalid input schema: %w", op.Name, err)
+		}
+		if len(sc.Properties) > 0 {
+			info.ParamsOneOf = schema.NewParamsOneOfByOpenAPIV3(sc)
+		}
+	}
+
+	return &httpTool{op: op, conf: &c, base: base, info: info}, nil
+}
+
+// httpTool 通过 HTTP 调用一个 operation.
+type httpTool struct {
+	op   *Operation
+	conf *Config
+	base *url.URL
+	info *schema.ToolInfo
+}
+
+func (t *httpTool) Info(_ context.Context) (*schema.ToolInfo, error) {
+	return t.info, nil
+}
+
+// InvokableRun reports bad arguments, failed requests and error responses to the model,
+// only the errors of ctx are returned.
+func (t *httpTool) InvokableRun(ctx context.Context, argumentsInJSON string, _ ...tool.Option) (string, error) {
+	args := map[string]json.RawMessage{}
+	if strings.TrimSpace(argumentsInJSON) != "" {
+		if err := json.Unmarshal([]byte(argumentsInJSON), &args); err != nil {
+			return t.report("invalid_arguments", "the arguments are not a JSON object: "+err.Error(),
+				"call the tool again with a JSON object as arguments"), nil
+		}
+	}
+
+	req, err := t.newRequest(ctx, args)
+	if err != nil {
+		return t.report("invalid_arguments", err.Error(), "check the arguments against the tool's parameters"), nil
+	}
+
+	resp, err := t.conf.Client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		logs.Errorf("call %s: %v", t.op.Name, err)
+		return t.report("unavailable", err.Error(), "the service can't be reached, answer without it or try again later"), nil
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, t.conf.MaxReadBytes))
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		return t.report("unavailable", "read the response: "+err.Error(), "try again later or answer without it"), nil
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		msg := resp.Status
+		if text := strings.TrimSpace(t.trim(body, resp.Header.Get("Content-Type"))); text != "" {
+			msg += ": " + text
+		}
+		hint := "the service failed, try again later or answer without it"
+		if resp.StatusCode < http.StatusInternalServerError {
+			hint = "the request was refused, fix the arguments or answer without this tool"
+		}
+		return t.report("http_error", msg, hint), nil
+	}
+
+	if len(bytes.TrimSpace(body)) == 0 {
+		b, _ := json.Marshal(map[string]string{"status": resp.Status})
+		return string(b), nil
+	}
+	return t.trim(body, resp.Header.Get("Content-Type")), nil
+}
+
+// newRequest 按 operation 的定义把参数放进 path, query, header, cookie 和请求体.
+func (t *httpTool) newRequest(ctx context.Context, args map[string]json.RawMessage) (*http.Request, error) {
+	path := t.op.Path
+	query := url.Values{}
+	header := http.Header{}
+	var cookies []*http.Cookie
+
+	used := map[string]bool{}
+	for _, p := range t.op.Params {
+		raw, ok := args[p.Arg]
+		used[p.Arg] = true
+		if !ok || string(raw) == "null" {
+			if p.In == openapi3.ParameterInPath {
+				return nil, fmt.Errorf("the path parameter %s is missing", p.Arg)
+			}
+			continue
+		}
+
+		values, err := paramValues(raw)
+		if err != nil {
+			return nil, fmt.Errorf("parameter %s: %w", p.Arg, err)
+		}
+		switch p.In {
+		case openapi3.ParameterInPath:
+			path = strings.ReplaceAll(path, "{"+p.Name+"}", url.PathEscape(strings.Join(values, ",")))
+		case openapi3.ParameterInQuery:
+			for _, v := range values {
+				query.Add(p.Name, v)
+			}
+		case openapi3.ParameterInHeader:
+			header.Set(p.Name, strings.Join(values, ","))
+		case openapi3.ParameterInCookie:
+			cookies = append(cookies, &http.Cookie{Name: p.Name, Value: strings.Join(values, ",")})
+		}
+	}
+
+	var body io.Reader
+	if b := t.op.Body; b != nil {
+		var (
+			raw json.RawMessage
+			err error
+		)
+		if b.Arg != "" {
+			raw = args[b.Arg]
+		} else {
+			rest := map[string]json.RawMessage{}
+			for k, v := range args {
+				if !used[k] {
+					rest[k] = v
+				}
+			}
+			if len(rest) > 0 {
+				if raw, err = json.Marshal(rest); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if len(raw) == 0 && b.Required {
+			raw = json.RawMessage("{}")
+		}
+		if len(raw) > 0 {
+			body = bytes.NewReader(raw)
+			header.Set("Content-Type", "application/json")
+		}
+	}
+
+	// the path parameters are escaped, the path can be appended as is
+	u, err := url.Parse(t.base.String() + path)
+	if err != nil {
+		return nil, err
+	}
+	q := u.Query()
+	for k, vs := range query {
+		q[k] = append(q[k], vs...)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, t.op.Method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header = header
+	req.Header.Set("Accept", "application/json")
+	for k, v := range t.conf.Headers {
+		req.Header.Set(k, v)
+	}
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
+	t.authorize(req, q)
+	req.URL.RawQuery = q.Encode()
+	return req, nil
+}
+
+// paramValues 把参数值转为字符串, 数组的每个元素一个值.
+func paramValues(raw json.RawMessage) ([]string, error) {
+	var list []json.RawMessage
+	if json.Unmarshal(raw, &list) == nil {
+		values := make([]string, 0, len(list))
+		for _, item := range list {
+			v, err := scalar(item)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+		}
+		return values, nil
+	}
+
+	v, err := scalar(raw)
+	if err != nil {
+		return nil, err
+	}
+	return []string{v}, nil
+}
+
+func scalar(raw json.RawMessage) (string, error) {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s, nil
+	}
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return "", err
+	}
+	switch v.(type) {
+	case float64, bool:
+		return string(bytes.TrimSpace(raw)), nil
+	}
+	return "", errors.New("objects are not supported as parameter values")
+}
+
+// authorize 为 operation 的每个 security scheme 加上配置的凭证.
+func (t *httpTool) authorize(req *http.Request, query url.Values) {
+	for _, s := range t.op.Auth {
+		secret, ok := t.conf.Credentials[s.Name]
+		if !ok {
+			secret, ok = t.conf.Credentials["*"]
+		}
+		if !ok || secret == "" {
+			continue
+		}
+
+		switch {
+		case s.Type == "apiKey" && s.In == openapi3.ParameterInHeader:
+			req.Header.Set(s.Param, secret)
+		case s.Type == "apiKey" && s.In == openapi3.ParameterInQuery:
+			query.Set(s.Param, secret)
+		case s.Type == "apiKey" && s.In == openapi3.ParameterInCookie:
+			req.AddCookie(&http.Cookie{Name: s.Param, Value: secret})
+		case s.Type == "http" && s.Scheme == "bearer":
+			req.Header.Set("Authorization", "Bearer "+secret)
+		case s.Type == "http" && s.Scheme == "basic":
+			req.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(secret)))
+		}
+	}
+}
+
+// trim 精简返回给模型的结果: JSON 去掉 null 和空值, 截断过长的数组, 最后按字节数截断.
+func (t *httpTool) trim(body []byte, contentType string) string {
+	text := string(body)
+	if strings.Contains(contentType, "json") || json.Valid(body) {
+		dec := json.NewDecoder(bytes.NewReader(body))
+		dec.UseNumber()
+		var v any
+		if dec.Decode(&v) == nil {
+			cut := false
+			v = prune(v, t.conf.MaxItems, &cut)
+			if cut {
+				v = map[string]any{
+					"result": v,
+					"note":   fmt.Sprintf("lists are cut to their first %d items", t.conf.MaxItems),
+				}
+			}
+			if b, err := json.Marshal(v); err == nil {
+				text = string(b)
+			}
+		}
+	}
+
+	if len(text) <= t.conf.MaxResponseBytes {
+		return text
+	}
+	n := t.conf.MaxResponseBytes
+	for n > 0 && !utf8.RuneStart(text[n]) {
+		n--
+	}
+	return text[:n] + fmt.Sprintf("... (cut, %d of %d bytes)", n, len(text))
+}
+
+func prune(v any, maxItems int, cut *bool) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, val := range v {
+			val = prune(val, maxItems, cut)
+			if isEmpty(val) {
+				delete(v, k)
+				continue
+			}
+			v[k] = val
+		}
+		return v
+	case []any:
+		if maxItems >= 0 && len(v) > maxItems {
+			v = v[:maxItems]
+			*cut = true
+		}
+		for i := range v {
+			v[i] = prune(v[i], maxItems, cut)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+func isEmpty(v any) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case map[string]any:
+		return len(v) == 0
+	case []any:
+		return len(v) == 0
+	}
+	return false
+}
+
+// report renders the error as a tool result the model can read.
+func (t *httpTool) report(kind, msg, hint string) string {
+	b, _ := json.Marshal(map[string]string{
+		"error":   kind,
+		"tool":    t.op.Name,
+		"message": msg,
+		"hint":    hint,
+	})
+	return string(b)
+}
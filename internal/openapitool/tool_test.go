@@ -0,0 +1,300 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package openapitool
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/components/tool"
+)
+
+// recorded 服务收到的请求.
+type recorded struct {
+	method, path string
+	query        map[string][]string
+	header       http.Header
+	cookies      map[string]string
+	body         string
+}
+
+// newService 记录收到的请求, 用 respond 回答.
+func newService(t *testing.T, respond http.HandlerFunc) (*httptest.Server, *recorded) {
+	t.Helper()
+	rec := &recorded{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		*rec = recorded{
+			method:  r.Method,
+			path:    r.URL.EscapedPath(),
+			query:   r.URL.Query(),
+			header:  r.Header,
+			cookies: map[string]string{},
+			body:    string(b),
+		}
+		for _, c := range r.Cookies() {
+			rec.cookies[c.Name] = c.Value
+		}
+		if respond != nil {
+			respond(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"ok":true}`)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, rec
+}
+
+func menuTool(t *testing.T, name string, conf *Config) tool.InvokableTool {
+	t.Helper()
+	tools, err := New(context.Background(), loadMenu(t), conf, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tools[0].(tool.InvokableTool)
+}
+
+// reported 解析报告给模型的错误.
+func reported(t *testing.T, out string) map[string]string {
+	t.Helper()
+	var r map[string]string
+	if err := json.Unmarshal([]byte(out), &r); err != nil || r["error"] == "" {
+		t.Fatalf("got %q, want a reported error", out)
+	}
+	return r
+}
+
+func TestParams(t *testing.T) {
+	srv, rec := newService(t, nil)
+	lt := menuTool(t, "listDishes", &Config{BaseURL: srv.URL + "/api/"})
+
+	out, err := lt.InvokableRun(context.Background(),
+		`{"restaurant_id":"sichuan house/1","tag":["spicy","vegan"],"X-Locale":"de-DE","session":"s1"}`)
+	if err != nil || out != `{"ok":true}` {
+		t.Fatalf("got %q, %v", out, err)
+	}
+	if rec.method != http.MethodGet || rec.path != "/api/restaurants/sichuan%20house%2F1/dishes" {
+		t.Errorf("request %s %s, want the path parameter escaped", rec.method, rec.path)
+	}
+	if !slices.Equal(rec.query["tag"], []string{"spicy", "vegan"}) {
+		t.Errorf("query %v, want the tag repeated", rec.query)
+	}
+	if rec.header.Get("X-Locale") != "de-DE" || rec.cookies["session"] != "s1" {
+		t.Errorf("header %v, cookies %v", rec.header, rec.cookies)
+	}
+	if rec.header.Get("Accept") != "application/json" || rec.body != "" {
+		t.Errorf("accept %q, body %q", rec.header.Get("Accept"), rec.body)
+	}
+
+	out, err = lt.InvokableRun(context.Background(), `{"tag":"spicy"}`)
+	if err != nil || reported(t, out)["error"] != "invalid_arguments" {
+		t.Errorf("got %q, %v, want the missing path parameter reported", out, err)
+	}
+}
+
+func TestAuth(t *testing.T) {
+	basic := "Basic " + base64.StdEncoding.EncodeToString([]byte("ann:secret"))
+	tests := []struct {
+		name, op, args string
+		creds          map[string]string
+		check          func(*recorded) bool
+	}{
+		{
+			name: "api key in a header", op: "listDishes", args: `{"restaurant_id":"1"}`,
+			creds: map[string]string{"apiKey": "k1"},
+			check: func(r *recorded) bool { return r.header.Get("X-API-Key") == "k1" },
+		},
+		{
+			name: "any scheme", op: "listDishes", args: `{"restaurant_id":"1"}`,
+			creds: map[string]string{"*": "k2"},
+			check: func(r *recorded) bool { return r.header.Get("X-API-Key") == "k2" },
+		},
+		{
+			name: "bearer", op: "createOrder", args: `{"dish_id":"d1"}`,
+			creds: map[string]string{"bearer": "tok", "apiKey": "unused"},
+			check: func(r *recorded) bool {
+				return r.header.Get("Authorization") == "Bearer tok" && r.header.Get("X-API-Key") == ""
+			},
+		},
+		{
+			name: "basic and api key in the query", op: "add-review", args: `{"id":"1"}`,
+			creds: map[string]string{"basic": "ann:secret", "queryKey": "k3"},
+			check: func(r *recorded) bool {
+				return r.header.Get("Authorization") == basic && slices.Equal(r.query["key"], []string{"k3"})
+			},
+		},
+		{
+			name: "no credentials", op: "listDishes", args: `{"restaurant_id":"1"}`,
+			check: func(r *recorded) bool { return r.header.Get("X-API-Key") == "" },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, rec := newService(t, nil)
+			it := menuTool(t, tt.op, &Config{BaseURL: srv.URL, Credentials: tt.creds})
+			if _, err := it.InvokableRun(context.Background(), tt.args); err != nil {
+				t.Fatal(err)
+			}
+			if !tt.check(rec) {
+				t.Errorf("header %v, query %v", rec.header, rec.query)
+			}
+		})
+	}
+}
+
+func TestBody(t *testing.T) {
+	tests := []struct {
+		name, op, args, path, body string
+	}{
+		{"flattened", "createOrder", `{"dish_id":"d1","quantity":2}`, "/orders", `{"dish_id":"d1","quantity":2}`},
+		{"required without arguments", "createOrder", `{}`, "/orders", `{}`},
+		{"wrapped", "add-review", `{"id":"r1","body":{"id":"r1","text":"great noodles"}}`, "/restaurants/r1/reviews", `{"id":"r1","text":"great noodles"}`},
+		{"optional without body", "add-review", `{"id":"r1"}`, "/restaurants/r1/reviews", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, rec := newService(t, nil)
+			it := menuTool(t, tt.op, &Config{BaseURL: srv.URL})
+			if _, err := it.InvokableRun(context.Background(), tt.args); err != nil {
+				t.Fatal(err)
+			}
+			if rec.method != http.MethodPost || rec.path != tt.path {
+				t.Errorf("request %s %s, want POST %s", rec.method, rec.path, tt.path)
+			}
+			if rec.body != tt.body {
+				t.Errorf("body %s, want %s", rec.body, tt.body)
+			}
+			if ct := rec.header.Get("Content-Type"); (tt.body != "") != (ct == "application/json") {
+				t.Errorf("Content-Type %q with body %q", ct, rec.body)
+			}
+		})
+	}
+}
+
+func TestTrim(t *testing.T) {
+	dishes := make([]string, 30)
+	for i := range dishes {
+		dishes[i] = fmt.Sprintf(`{"id":"%d","name":"dish %d","note":null,"tags":[]}`, i, i)
+	}
+	list := "[" + strings.Join(dishes, ",") + "]"
+	srv, _ := newService(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, list)
+	})
+
+	t.Run("max items", func(t *testing.T) {
+		it := menuTool(t, "listDishes", &Config{BaseURL: srv.URL, MaxItems: 3})
+		out, err := it.InvokableRun(context.Background(), `{"restaurant_id":"1"}`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got struct {
+			Result []map[string]any `json:"result"`
+			Note   string           `json:"note"`
+		}
+		if err = json.Unmarshal([]byte(out), &got); err != nil {
+			t.Fatalf("%s: %v", out, err)
+		}
+		// 空值被去掉, 数组只保留前 3 个
+		if len(got.Result) != 3 || len(got.Result[0]) != 2 || !strings.Contains(got.Note, "first 3 items") {
+			t.Errorf("got %s", out)
+		}
+	})
+
+	t.Run("all items", func(t *testing.T) {
+		it := menuTool(t, "listDishes", &Config{BaseURL: srv.URL, MaxItems: -1, MaxResponseBytes: 1 << 20})
+		out, err := it.InvokableRun(context.Background(), `{"restaurant_id":"1"}`)
+		var got []map[string]any
+		if err != nil || json.Unmarshal([]byte(out), &got) != nil || len(got) != 30 {
+			t.Errorf("got %d items of %q, %v, want all 30", len(got), out, err)
+		}
+	})
+
+	t.Run("max response bytes", func(t *testing.T) {
+		it := menuTool(t, "listDishes", &Config{BaseURL: srv.URL, MaxResponseBytes: 100})
+		out, err := it.InvokableRun(context.Background(), `{"restaurant_id":"1"}`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, notice, ok := strings.Cut(out, "... (cut, ")
+		if !ok || len(body) != 100 || !strings.HasPrefix(notice, "100 of ") {
+			t.Errorf("got %q, want 100 bytes and the cut notice", out)
+		}
+	})
+}
+
+func TestErrorResponses(t *testing.T) {
+	tests := []struct {
+		status int
+		hint   string
+	}{
+		{http.StatusNotFound, "refused"},
+		{http.StatusServiceUnavailable, "failed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			srv, _ := newService(t, func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+				_, _ = io.WriteString(w, `{"message":"no such restaurant","detail":null}`)
+			})
+			it := menuTool(t, "listDishes", &Config{BaseURL: srv.URL})
+			out, err := it.InvokableRun(context.Background(), `{"restaurant_id":"1"}`)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := reported(t, out)
+			if r["error"] != "http_error" || r["tool"] != "listDishes" ||
+				!strings.Contains(r["message"], fmt.Sprint(tt.status)) || !strings.Contains(r["message"], `{"message":"no such restaurant"}`) ||
+				!strings.Contains(r["hint"], tt.hint) {
+				t.Errorf("got %v", r)
+			}
+		})
+	}
+
+	t.Run("unreachable", func(t *testing.T) {
+		srv, _ := newService(t, nil)
+		srv.Close()
+		it := menuTool(t, "listDishes", &Config{BaseURL: srv.URL})
+		out, err := it.InvokableRun(context.Background(), `{"restaurant_id":"1"}`)
+		if err != nil || reported(t, out)["error"] != "unavailable" {
+			t.Errorf("got %q, %v", out, err)
+		}
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		srv, _ := newService(t, nil)
+		it := menuTool(t, "listDishes", &Config{BaseURL: srv.URL})
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if out, err := it.InvokableRun(ctx, `{"restaurant_id":"1"}`); err == nil {
+			t.Errorf("got %q, want the error of ctx", out)
+		}
+	})
+}
@@ -0,0 +1,179 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	bucketSessions = []byte("sessions") // id => Session without messages
+	bucketMessages = []byte("messages") // id => []*schema.Message
+)
+
+// BoltStore keeps sessions in an embedded bbolt database file.
+// Metadata and messages are stored in separate buckets, so List doesn't decode the histories.
+type BoltStore struct {
+	db *bolt.DB
+}
+
+// NewBoltStore opens or creates the database file.
+func NewBoltStore(path string) (*BoltStore, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("open session db: %w", err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{bucketSessions, bucketMessages} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("init session db: %w", err)
+	}
+
+	return &BoltStore{db: db}, nil
+}
+
+func (bs *BoltStore) Save(ctx context.Context, s *Session) error {
+	if err := ValidateID(s.ID); err != nil {
+		return err
+	}
+
+	return bs.db.Update(func(tx *bolt.Tx) error {
+		sessions := tx.Bucket(bucketSessions)
+		key := []byte(s.ID)
+
+		var prev *Session
+		if b := sessions.Get(key); b != nil {
+			prev = &Session{}
+			if err := json.Unmarshal(b, prev); err != nil {
+				return fmt.Errorf("decode session %s: %w", s.ID, err)
+			}
+		}
+		s.touch(prev)
+
+		meta := *s
+		meta.Messages = nil
+		b, err := json.Marshal(&meta)
+		if err != nil {
+			return err
+		}
+		if err = sessions.Put(key, b); err != nil {
+			return err
+		}
+
+		if b, err = json.Marshal(s.Messages); err != nil {
+			return err
+		}
+		return tx.Bucket(bucketMessages).Put(key, b)
+	})
+}
+
+func (bs *BoltStore) Load(ctx context.Context, id string) (*Session, error) {
+	if err := ValidateID(id); err != nil {
+		return nil, err
+	}
+
+	s := &Session{}
+	err := bs.db.View(func(tx *bolt.Tx) error {
+		key := []byte(id)
+		b := tx.Bucket(bucketSessions).Get(key)
+		if b == nil {
+			return fmt.Errorf("%w: %s", ErrNotFound, id)
+		}
+		if err := json.Unmarshal(b, s); err != nil {
+			return fmt.Errorf("decode session %s: %w", id, err)
+		}
+
+		if b = tx.Bucket(bucketMessages).Get(key); b != nil {
+			if err := json.Unmarshal(b, &s.Messages); err != nil {
+				return fmt.Errorf("decode messages of session %s: %w", id, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (bs *BoltStore) List(ctx context.Context) ([]*Info, error) {
+	var infos []*Info
+	err := bs.db.View(func(tx *bolt.Tx) error {
+		messages := tx.Bucket(bucketMessages)
+		return tx.Bucket(bucketSessions).ForEach(func(k, v []byte) error {
+			s := &Session{}
+			if err := json.Unmarshal(v, s); err != nil {
+				return fmt.Errorf("decode session %s: %w", k, err)
+			}
+
+			info := s.info()
+			// count the messages without decoding them
+			var raw []json.RawMessage
+			if b := messages.Get(k); b != nil {
+				if err := json.Unmarshal(b, &raw); err != nil {
+					return fmt.Errorf("decode messages of session %s: %w", k, err)
+				}
+			}
+			info.MessageCount = len(raw)
+
+			infos = append(infos, info)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(infos, func(i, j int) bool { return infos[i].UpdatedAt.After(infos[j].UpdatedAt) })
+	return infos, nil
+}
+
+func (bs *BoltStore) Delete(ctx context.Context, id string) error {
+	if err := ValidateID(id); err != nil {
+		return err
+	}
+
+	return bs.db.Update(func(tx *bolt.Tx) error {
+		key := []byte(id)
+		sessions := tx.Bucket(bucketSessions)
+		if sessions.Get(key) == nil {
+			return fmt.Errorf("%w: %s", ErrNotFound, id)
+		}
+		if err := sessions.Delete(key); err != nil {
+			return err
+		}
+		return tx.Bucket(bucketMessages).Delete(key)
+	})
+}
+
+func (bs *BoltStore) Close() error {
+	return bs.db.Close()
+}
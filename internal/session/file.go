@@ -0,0 +1,155 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// FileStore keeps every session in its own json file in a directory.
+type FileStore struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewFileStore creates the directory if needed.
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create session dir: %w", err)
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+func (st *FileStore) path(id string) string {
+	return filepath.Join(st.dir, id+".json")
+}
+
+func (st *FileStore) Save(ctx context.Context, s *Session) error {
+	if err := ValidateID(s.ID); err != nil {
+		return err
+	}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	prev, err := st.load(s.ID)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	s.touch(prev)
+
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// write to a temp file and rename, so a crash never leaves a half written session
+	tmp, err := os.CreateTemp(st.dir, s.ID+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), st.path(s.ID))
+}
+
+func (st *FileStore) Load(ctx context.Context, id string) (*Session, error) {
+	if err := ValidateID(id); err != nil {
+		return nil, err
+	}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return st.load(id)
+}
+
+func (st *FileStore) load(id string) (*Session, error) {
+	b, err := os.ReadFile(st.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Session{}
+	if err = json.Unmarshal(b, s); err != nil {
+		return nil, fmt.Errorf("decode session %s: %w", id, err)
+	}
+	return s, nil
+}
+
+func (st *FileStore) List(ctx context.Context) ([]*Info, error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	entries, err := os.ReadDir(st.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]*Info, 0, len(entries))
+	for _, entry := range entries {
+		id, ok := strings.CutSuffix(entry.Name(), ".json")
+		if entry.IsDir() || !ok || ValidateID(id) != nil {
+			continue
+		}
+
+		s, err := st.load(id)
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, s.info())
+	}
+
+	sort.Slice(infos, func(i, j int) bool { return infos[i].UpdatedAt.After(infos[j].UpdatedAt) })
+	return infos, nil
+}
+
+func (st *FileStore) Delete(ctx context.Context, id string) error {
+	if err := ValidateID(id); err != nil {
+		return err
+	}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	err := os.Remove(st.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	return err
+}
+
+func (st *FileStore) Close() error {
+	return nil
+}
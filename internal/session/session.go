@@ -0,0 +1,121 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package session persists conversation history so an agent can resume it across restarts.
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// ErrNotFound is returned when a session ID does not exist in the store.
+var ErrNotFound = errors.New("session not found")
+
+// Session is a conversation with its metadata.
+type Session struct {
+	ID        string            `json:"id"`
+	Model     string            `json:"model"`
+	Persona   string            `json:"persona"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+	Messages  []*schema.Message `json:"messages"`
+}
+
+// Info is the metadata of a session, returned by Store.List.
+type Info struct {
+	ID           string    `json:"id"`
+	Model        string    `json:"model"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	MessageCount int       `json:"message_count"`
+}
+
+// Store saves and loads sessions.
+type Store interface {
+	// Save creates or replaces the session, CreatedAt and UpdatedAt are maintained by the store.
+	Save(ctx context.Context, s *Session) error
+	// Load returns ErrNotFound when the session does not exist.
+	Load(ctx context.Context, id string) (*Session, error)
+	// List returns the sessions ordered by UpdatedAt, the most recent first.
+	List(ctx context.Context) ([]*Info, error)
+	// Delete returns ErrNotFound when the session does not exist.
+	Delete(ctx context.Context, id string) error
+	Close() error
+}
+
+// Open opens a store from a "kind:path" spec, e.g. "file:./sessions" or "bolt:./sessions.db".
+func Open(spec string) (Store, error) {
+	kind, path, ok := strings.Cut(spec, ":")
+	if !ok || path == "" {
+		return nil, fmt.Errorf("invalid session store %q, want file:<dir> or bolt:<file>", spec)
+	}
+
+	switch kind {
+	case "file":
+		return NewFileStore(path)
+	case "bolt":
+		return NewBoltStore(path)
+	}
+	return nil, fmt.Errorf("unknown session store kind %q, want file or bolt", kind)
+}
+
+// NewID returns a random session ID.
+func NewID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+var validID = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// ValidateID rejects IDs which are not safe to use as file names.
+func ValidateID(id string) error {
+	if !validID.MatchString(id) {
+		return fmt.Errorf("invalid session id %q, use 1-64 letters, digits, - or _", id)
+	}
+	return nil
+}
+
+func (s *Session) info() *Info {
+	return &Info{
+		ID:           s.ID,
+		Model:        s.Model,
+		CreatedAt:    s.CreatedAt,
+		UpdatedAt:    s.UpdatedAt,
+		MessageCount: len(s.Messages),
+	}
+}
+
+// touch sets the timestamps before saving, keeping CreatedAt of an existing session.
+func (s *Session) touch(prev *Session) {
+	now := time.Now()
+	switch {
+	case prev != nil:
+		s.CreatedAt = prev.CreatedAt
+	case s.CreatedAt.IsZero():
+		s.CreatedAt = now
+	}
+	s.UpdatedAt = now
+}
@@ -0,0 +1,237 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// forEachStore 对文件和 bbolt 两种存储分别运行 f, 每次都是新的空存储.
+func forEachStore(t *testing.T, f func(t *testing.T, st Store)) {
+	for _, kind := range []string{"file", "bolt"} {
+		t.Run(kind, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "sessions")
+			if kind == "bolt" {
+				path += ".db"
+			}
+			st, err := Open(kind + ":" + path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer st.Close()
+			f(t, st)
+		})
+	}
+}
+
+func newSession(id string, n int) *Session {
+	s := &Session{ID: id, Model: "qwen2.5", Persona: "a food critic"}
+	for i := range n {
+		s.Messages = append(s.Messages, schema.UserMessage(fmt.Sprintf("question %d", i)))
+	}
+	return s
+}
+
+func TestRoundTrip(t *testing.T) {
+	forEachStore(t, func(t *testing.T, st Store) {
+		ctx := context.Background()
+		s := newSession("trip", 0)
+		s.Messages = []*schema.Message{
+			schema.UserMessage("spicy dishes in Beijing?"),
+			schema.AssistantMessage("", []schema.ToolCall{{ID: "call_1", Function: schema.FunctionCall{Name: "query_dishes", Arguments: `{"restaurant_id":"1001"}`}}}),
+			schema.ToolMessage(`[{"name":"mapo tofu"}]`, "call_1"),
+			schema.AssistantMessage("Try the mapo tofu.", nil),
+		}
+		if err := st.Save(ctx, s); err != nil {
+			t.Fatal(err)
+		}
+		created := s.CreatedAt
+		if created.IsZero() || s.UpdatedAt.IsZero() {
+			t.Fatalf("timestamps not set: %+v", s)
+		}
+
+		got, err := st.Load(ctx, "trip")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Model != s.Model || got.Persona != s.Persona || !got.CreatedAt.Equal(created) || len(got.Messages) != 4 {
+			t.Fatalf("loaded %+v, want %+v", got, s)
+		}
+		for i, msg := range got.Messages {
+			want := s.Messages[i]
+			if msg.Role != want.Role || msg.Content != want.Content || msg.ToolCallID != want.ToolCallID || len(msg.ToolCalls) != len(want.ToolCalls) {
+				t.Errorf("message %d = %+v, want %+v", i, msg, want)
+			}
+		}
+		if tc := got.Messages[1].ToolCalls; len(tc) != 1 || tc[0].Function.Arguments != `{"restaurant_id":"1001"}` {
+			t.Errorf("tool calls = %+v", tc)
+		}
+
+		// 再次保存保留创建时间, 更新修改时间
+		time.Sleep(time.Millisecond)
+		got.Messages = append(got.Messages, schema.UserMessage("and in Shanghai?"))
+		if err = st.Save(ctx, got); err != nil {
+			t.Fatal(err)
+		}
+		again, err := st.Load(ctx, "trip")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !again.CreatedAt.Equal(created) || !again.UpdatedAt.After(created) || len(again.Messages) != 5 {
+			t.Errorf("after the second save got created %v updated %v with %d messages", again.CreatedAt, again.UpdatedAt, len(again.Messages))
+		}
+	})
+}
+
+func TestList(t *testing.T) {
+	forEachStore(t, func(t *testing.T, st Store) {
+		ctx := context.Background()
+		if infos, err := st.List(ctx); err != nil || len(infos) != 0 {
+			t.Fatalf("empty store listed %v, %v", infos, err)
+		}
+
+		for i, id := range []string{"a", "b", "c"} {
+			time.Sleep(time.Millisecond)
+			if err := st.Save(ctx, newSession(id, i+1)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		// 更新 a 之后它排在最前面
+		time.Sleep(time.Millisecond)
+		if err := st.Save(ctx, newSession("a", 4)); err != nil {
+			t.Fatal(err)
+		}
+
+		infos, err := st.List(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, info := range infos {
+			got = append(got, fmt.Sprintf("%s:%d", info.ID, info.MessageCount))
+			if info.Model != "qwen2.5" {
+				t.Errorf("%s model = %q", info.ID, info.Model)
+			}
+		}
+		if fmt.Sprint(got) != "[a:4 c:3 b:2]" {
+			t.Errorf("list = %v, want [a:4 c:3 b:2]", got)
+		}
+	})
+}
+
+func TestDelete(t *testing.T) {
+	forEachStore(t, func(t *testing.T, st Store) {
+		ctx := context.Background()
+		for _, id := range []string{"keep", "drop"} {
+			if err := st.Save(ctx, newSession(id, 1)); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if err := st.Delete(ctx, "drop"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := st.Load(ctx, "drop"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("load after delete: %v, want ErrNotFound", err)
+		}
+		if err := st.Delete(ctx, "drop"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("second delete: %v, want ErrNotFound", err)
+		}
+		if infos, err := st.List(ctx); err != nil || len(infos) != 1 || infos[0].ID != "keep" {
+			t.Errorf("list after delete = %v, %v, want only keep", infos, err)
+		}
+	})
+}
+
+func TestInvalidID(t *testing.T) {
+	forEachStore(t, func(t *testing.T, st Store) {
+		ctx := context.Background()
+		for _, id := range []string{"", "../escape", "a/b", "with space"} {
+			if err := st.Save(ctx, newSession(id, 1)); err == nil {
+				t.Errorf("saved %q", id)
+			}
+			if _, err := st.Load(ctx, id); err == nil || errors.Is(err, ErrNotFound) {
+				t.Errorf("load %q: %v, want an invalid ID error", id, err)
+			}
+			if err := st.Delete(ctx, id); err == nil || errors.Is(err, ErrNotFound) {
+				t.Errorf("delete %q: %v, want an invalid ID error", id, err)
+			}
+		}
+	})
+}
+
+func TestConcurrentSave(t *testing.T) {
+	forEachStore(t, func(t *testing.T, st Store) {
+		ctx := context.Background()
+		const writers, rounds = 8, 10
+
+		var wg sync.WaitGroup
+		errs := make(chan error, writers*rounds*2)
+		for w := range writers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for r := range rounds {
+					// 所有 writer 写同一个会话, 每个 writer 也写自己的会话
+					for _, id := range []string{"shared", fmt.Sprintf("w%d", w)} {
+						if err := st.Save(ctx, newSession(id, r+1)); err != nil {
+							errs <- err
+						}
+					}
+					if _, err := st.Load(ctx, "shared"); err != nil && !errors.Is(err, ErrNotFound) {
+						errs <- err
+					}
+				}
+			}()
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			t.Error(err)
+		}
+
+		infos, err := st.List(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(infos) != writers+1 {
+			t.Fatalf("listed %d sessions, want %d", len(infos), writers+1)
+		}
+		for _, info := range infos {
+			// 每个会话是某一次完整的保存, 不会是写了一半的内容
+			s, err := st.Load(ctx, info.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(s.Messages) == 0 || len(s.Messages) > rounds || len(s.Messages) != info.MessageCount {
+				t.Errorf("%s has %d messages, listed %d", info.ID, len(s.Messages), info.MessageCount)
+			}
+			if info.ID != "shared" && len(s.Messages) != rounds {
+				t.Errorf("%s has %d messages, want the last save with %d", info.ID, len(s.Messages), rounds)
+			}
+		}
+	})
+}
@@ -0,0 +1,195 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package structured makes an agent answer with JSON matching a schema and
+// decodes it into a Go value, asking the model again when the answer is invalid.
+package structured
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/getkin/kin-openapi/openapi3"
+
+	"github.com/galihrivanto/eino-exp/internal/logs"
+)
+
+// ErrInvalidOutput is returned when the model still answers with invalid JSON after all retries.
+var ErrInvalidOutput = errors.New("invalid structured output")
+
+// ValidationError lists what is wrong with an answer.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "answer doesn't match the schema: " + strings.Join(e.Problems, "; ")
+}
+
+func (e *ValidationError) Unwrap() error {
+	return ErrInvalidOutput
+}
+
+// Output decodes answers into T after validating them against a JSON schema.
+type Output[T any] struct {
+	schema *openapi3.Schema
+	raw    string
+}
+
+// New creates an Output from a JSON schema, in the OpenAPI 3 dialect used by eino's ToolInfo.
+func New[T any](schemaJSON []byte) (*Output[T], error) {
+	s := &openapi3.Schema{}
+	if err := json.Unmarshal(schemaJSON, s); err != nil {
+		return nil, fmt.Errorf("parse schema: %w", err)
+	}
+	if err := s.Validate(context.Background()); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+
+	raw, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	return &Output[T]{schema: s, raw: string(raw)}, nil
+}
+
+// Instruction is appended to the system prompt so the model knows the expected answer.
+func (o *Output[T]) Instruction() string {
+	return `When you give the final answer, after any tool calls, reply with a single JSON object
+that matches this JSON schema and nothing else, no markdown and no explanation:
+` + o.raw
+}
+
+// Parse extracts the JSON object from content, validates it and decodes it into T.
+// The error is a *ValidationError when the answer can be corrected by the model.
+func (o *Output[T]) Parse(content string) (*T, error) {
+	raw, ok := extractJSON(content)
+	if !ok {
+		return nil, &ValidationError{Problems: []string{"no JSON object found in the answer"}}
+	}
+
+	var v any
+	if err := json.Unmarshal([]byte(raw), &v); err != nil {
+		return nil, &ValidationError{Problems: []string{err.Error()}}
+	}
+
+	if err := o.schema.VisitJSON(v, openapi3.MultiErrors()); err != nil {
+		return nil, &ValidationError{Problems: problems(err)}
+	}
+
+	out := new(T)
+	if err := json.Unmarshal([]byte(raw), out); err != nil {
+		return nil, &ValidationError{Problems: []string{err.Error()}}
+	}
+	return out, nil
+}
+
+// Feedback is the message sent back to the model after an invalid answer.
+func (o *Output[T]) Feedback(err error) *schema.Message {
+	var ve *ValidationError
+	if !errors.As(err, &ve) {
+		ve = &ValidationError{Problems: []string{err.Error()}}
+	}
+	return schema.UserMessage(fmt.Sprintf(`Your answer is not valid:
+- %s
+Reply again with only the corrected JSON object matching the schema.`, strings.Join(ve.Problems, "\n- ")))
+}
+
+// Result is the outcome of Run.
+type Result[T any] struct {
+	Value *T
+	// Answer is the last answer of the model, the one Value was decoded from.
+	Answer *schema.Message
+	// Attempts is the number of answers it took, 1 when the first was valid.
+	Attempts int
+}
+
+// Run asks and, while the answer is invalid, re-prompts with the problems found, up to maxRetries
+// times. ask runs the agent, e.g. printing the answer as it streams.
+func (o *Output[T]) Run(ctx context.Context, input []*schema.Message, maxRetries int,
+	ask func(ctx context.Context, input []*schema.Message) (*schema.Message, error)) (*Result[T], error) {
+
+	msgs := input
+	for attempt := 1; ; attempt++ {
+		answer, err := ask(ctx, msgs)
+		if err != nil {
+			return nil, err
+		}
+
+		v, err := o.Parse(answer.Content)
+		if err == nil {
+			return &Result[T]{Value: v, Answer: answer, Attempts: attempt}, nil
+		}
+		if attempt > maxRetries {
+			return nil, fmt.Errorf("after %d attempts: %w", attempt, err)
+		}
+
+		logs.Errorf("attempt %d: %v, asking again", attempt, err)
+		msgs = append(msgs[:len(msgs):len(msgs)], answer, o.Feedback(err))
+	}
+}
+
+// problems flattens the errors of VisitJSON into "path: reason" lines.
+func problems(err error) []string {
+	var me openapi3.MultiError
+	if errors.As(err, &me) {
+		var res []string
+		for _, e := range me {
+			res = append(res, problems(e)...)
+		}
+		return res
+	}
+
+	var se *openapi3.SchemaError
+	if errors.As(err, &se) {
+		path := "/" + strings.Join(se.JSONPointer(), "/")
+		return []string{fmt.Sprintf("%s: %s", path, se.Reason)}
+	}
+	return []string{err.Error()}
+}
+
+var (
+	thinkBlock = regexp.MustCompile(`(?s)<think>.*?</think>`)
+	jsonFence  = regexp.MustCompile("(?s)```(?:json)?\\s*\n?(.*?)```")
+)
+
+// extractJSON finds the JSON object in an answer, skipping reasoning blocks and markdown fences.
+func extractJSON(content string) (string, bool) {
+	content = thinkBlock.ReplaceAllString(content, "")
+	if m := jsonFence.FindStringSubmatch(content); m != nil {
+		content = m[1]
+	}
+
+	for i := strings.IndexByte(content, '{'); i >= 0; {
+		dec := json.NewDecoder(strings.NewReader(content[i:]))
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err == nil {
+			return string(raw), true
+		}
+
+		next := strings.IndexByte(content[i+1:], '{')
+		if next < 0 {
+			break
+		}
+		i += next + 1
+	}
+	return "", false
+}
@@ -0,0 +1,210 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+
+package structured
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+const dishSchema = `{
+  "type": "object",
+  "required": ["name", "price"],
+  "properties": {
+    "name": {"type": "string", "minLength": 1},
+    "price": {"type": "number", "minimum": 0},
+    "tags": {"type": "array", "items": {"type": "string"}}
+  }
+}`
+
+type dish struct {
+	Name  string   `json:"name"`
+	Price float64  `json:"price"`
+	Tags  []string `json:"tags"`
+}
+
+func newOutput(t *testing.T) *Output[dish] {
+	t.Helper()
+	o, err := New[dish]([]byte(dishSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return o
+}
+
+func TestNewInvalidSchema(t *testing.T) {
+	for _, s := range []string{`not json`, `{"type": "no-such-type"}`} {
+		if _, err := New[dish]([]byte(s)); err == nil {
+			t.Errorf("New(%s) succeeded", s)
+		}
+	}
+}
+
+func TestExtractJSON(t *testing.T) {
+	for _, tt := range []struct {
+		name, content, want string
+	}{
+		{"bare", `{"a":1}`, `{"a":1}`},
+		{"fenced", "```json\n{\"a\":1}\n```", `{"a":1}`},
+		{"fenced without language", "here you go:\n```\n{\"a\":1}\n```\nenjoy", `{"a":1}`},
+		{"prose wrapped", `Sure! The answer is {"a":1} as requested.`, `{"a":1}`},
+		{"nested braces", `result: {"a":{"b":{"c":[1,{"d":2}]}},"e":"}"} done`, `{"a":{"b":{"c":[1,{"d":2}]}},"e":"}"}`},
+		{"think block", `<think>maybe {"a":0}?</think>{"a":1}`, `{"a":1}`},
+		{"brace in prose first", `use {braces} like this: {"a":1}`, `{"a":1}`},
+		{"first of two", `{"a":1} or {"a":2}`, `{"a":1}`},
+		{"none", `no JSON here`, ``},
+		{"unterminated", `{"a":1`, ``},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := extractJSON(tt.content)
+			if ok != (tt.want != "") || got != tt.want {
+				t.Errorf("extractJSON(%q) = %q, %v, want %q", tt.content, got, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	o := newOutput(t)
+
+	got, err := o.Parse("```json\n{\"name\":\"mapo tofu\",\"price\":38.5,\"tags\":[\"spicy\"]}\n```")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "mapo tofu" || got.Price != 38.5 || len(got.Tags) != 1 || got.Tags[0] != "spicy" {
+		t.Errorf("Parse = %+v", got)
+	}
+
+	for _, tt := range []struct {
+		content string
+		problem string // 其中一个问题包含的内容
+	}{
+		{`I don't know`, "no JSON object found"},
+		{`{"name":"mapo tofu"}`, "price"},
+		{`{"name":"mapo tofu","price":-1}`, "/price"},
+		{`{"name":"","price":1}`, "/name"},
+		{`{"name":"mapo tofu","price":"cheap"}`, "/price"},
+		{`{"name":"mapo tofu","price":1,"tags":[1]}`, "/tags/0"},
+	} {
+		_, err := o.Parse(tt.content)
+		var ve *ValidationError
+		if !errors.As(err, &ve) || !errors.Is(err, ErrInvalidOutput) {
+			t.Errorf("Parse(%s) = %v, want a ValidationError", tt.content, err)
+			continue
+		}
+		if !strings.Contains(strings.Join(ve.Problems, "\n"), tt.problem) {
+			t.Errorf("Parse(%s) problems %q, want one about %q", tt.content, ve.Problems, tt.problem)
+		}
+	}
+
+	// 所有问题一起报告
+	_, err = o.Parse(`{"name":"","price":-1}`)
+	var ve *ValidationError
+	if !errors.As(err, &ve) || len(ve.Problems) != 2 {
+		t.Errorf("got %v, want both problems", err)
+	}
+}
+
+// scriptModel 按顺序返回 answers, 记录每次收到的输入.
+type scriptModel struct {
+	answers []string
+	inputs  [][]*schema.Message
+}
+
+func (m *scriptModel) Generate(_ context.Context, input []*schema.Message, _ ...model.Option) (*schema.Message, error) {
+	m.inputs = append(m.inputs, input)
+	if len(m.inputs) > len(m.answers) {
+		return nil, errors.New("no more answers")
+	}
+	return schema.AssistantMessage(m.answers[len(m.inputs)-1], nil), nil
+}
+
+func (m *scriptModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	msg, err := m.Generate(ctx, input, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return schema.StreamReaderFromArray([]*schema.Message{msg}), nil
+}
+
+func (m *scriptModel) ask(ctx context.Context, input []*schema.Message) (*schema.Message, error) {
+	return m.Generate(ctx, input)
+}
+
+func TestRunRetries(t *testing.T) {
+	o := newOutput(t)
+	m := &scriptModel{answers: []string{
+		`{"name":"mapo tofu","price":"cheap"}`,
+		`{"name":"mapo tofu","price":38}`,
+	}}
+	input := []*schema.Message{schema.SystemMessage(o.Instruction()), schema.UserMessage("a spicy dish?")}
+
+	res, err := o.Run(context.Background(), input, 2, m.ask)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Attempts != 2 || res.Value.Name != "mapo tofu" || res.Value.Price != 38 || res.Answer.Content != m.answers[1] {
+		t.Errorf("result = %+v, value %+v", res, res.Value)
+	}
+
+	// 第二次请求带上了无效的回答和其中的问题
+	if len(m.inputs) != 2 || len(m.inputs[1]) != 4 {
+		t.Fatalf("inputs = %v", m.inputs)
+	}
+	retry := m.inputs[1]
+	if retry[2].Role != schema.Assistant || retry[2].Content != m.answers[0] {
+		t.Errorf("retry repeats %+v, want the invalid answer", retry[2])
+	}
+	if retry[3].Role != schema.User || !strings.Contains(retry[3].Content, "/price") {
+		t.Errorf("feedback = %q, want the problem with /price", retry[3].Content)
+	}
+	if len(input) != 2 {
+		t.Errorf("the caller's input was modified: %v", input)
+	}
+}
+
+func TestRunExhaustsRetries(t *testing.T) {
+	o := newOutput(t)
+	m := &scriptModel{answers: []string{`no idea`, `{"name":""}`, `still no idea`}}
+
+	_, err := o.Run(context.Background(), []*schema.Message{schema.UserMessage("a spicy dish?")}, 2, m.ask)
+	if !errors.Is(err, ErrInvalidOutput) || !strings.Contains(err.Error(), "after 3 attempts") {
+		t.Errorf("got %v, want ErrInvalidOutput after 3 attempts", err)
+	}
+	if len(m.inputs) != 3 {
+		t.Errorf("asked %d times, want 3", len(m.inputs))
+	}
+	// 每次重试都在前一次的对话后面继续
+	if n := len(m.inputs[2]); n != 5 {
+		t.Errorf("the last retry had %d messages, want 5", n)
+	}
+}
+
+func TestRunAskError(t *testing.T) {
+	o := newOutput(t)
+	m := &scriptModel{}
+	_, err := o.Run(context.Background(), []*schema.Message{schema.UserMessage("a spicy dish?")}, 2, m.ask)
+	if err == nil || errors.Is(err, ErrInvalidOutput) || len(m.inputs) != 1 {
+		t.Errorf("got %v after %d calls, want the model error without retries", err, len(m.inputs))
+	}
+}
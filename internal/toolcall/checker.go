@@ -0,0 +1,126 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package toolcall
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"unicode"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// CheckerConfig tunes when the stream checker decides.
+type CheckerConfig struct {
+	// TextChars is the number of non-whitespace characters of plain text after which the
+	// message is taken for an answer; a negative value never decides on text and reads to
+	// the end. By default it is 1 without Formats. With Formats it is the length of the
+	// longest opener, so the text can't be the start of a tool call any more; a tool call
+	// the model writes after a longer text is then missed, set -1 to read to the end for
+	// such models, since toolcall.Model sends those calls as the last chunk.
+	TextChars int
+	// MaxChunks is the number of chunks after which the message is taken for an answer
+	// when nothing was decided yet, 0 means no limit.
+	MaxChunks int
+
+	// Formats and Tools give the prefixes of tool calls written into the content, see Openers.
+	// Content starting with one of them is read to the end instead of being taken for an answer.
+	Formats []Format
+	Tools   []string
+	// PayloadIsToolCall reports a tool call as soon as the content starts like one, without
+	// reading to the end. Only set it when such content is always a tool call, since the tools
+	// node fails on a message without tool calls. toolcall.Model doesn't need it: it holds the
+	// payload back and sends the parsed tool calls instead.
+	PayloadIsToolCall bool
+}
+
+// NewStreamChecker returns a react.AgentConfig.StreamToolCallChecker that returns as soon as it
+// can tell, instead of reading the whole stream:
+//   - a chunk with tool calls means a tool call,
+//   - TextChars of plain text at the start of the content means an answer,
+//   - content starting like a tool call payload is read to the end, or means a tool call
+//     with PayloadIsToolCall,
+//   - MaxChunks chunks without any of the above means an answer.
+//
+// Leading whitespace and empty chunks, as sent by models that stream their tool calls after
+// a few empty chunks, never decide.
+func NewStreamChecker(conf *CheckerConfig) func(ctx context.Context, sr *schema.StreamReader[*schema.Message]) (bool, error) {
+	c := CheckerConfig{}
+	if conf != nil {
+		c = *conf
+	}
+	openers := Openers(c.Formats, c.Tools)
+	if c.TextChars == 0 {
+		c.TextChars = 1
+		for _, o := range openers {
+			c.TextChars = max(c.TextChars, nonSpace(o))
+		}
+	}
+
+	return func(ctx context.Context, sr *schema.StreamReader[*schema.Message]) (bool, error) {
+		defer sr.Close()
+
+		var content strings.Builder
+		payload := false
+		for n := 1; ; n++ {
+			msg, err := sr.Recv()
+			if errors.Is(err, io.EOF) {
+				return false, nil
+			}
+			if err != nil {
+				return false, err
+			}
+
+			if len(msg.ToolCalls) > 0 {
+				return true, nil
+			}
+			if payload {
+				continue
+			}
+
+			content.WriteString(msg.Content)
+			switch leadsTo(content.String(), openers) {
+			case candidate:
+				if c.PayloadIsToolCall {
+					return true, nil
+				}
+				payload = true
+				continue
+			case plain:
+				if c.TextChars > 0 && nonSpace(content.String()) >= c.TextChars {
+					return false, nil
+				}
+			}
+
+			if c.MaxChunks > 0 && n >= c.MaxChunks {
+				return false, nil
+			}
+		}
+	}
+}
+
+func nonSpace(s string) int {
+	n := 0
+	for _, r := range s {
+		if !unicode.IsSpace(r) {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,213 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package toolcall
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// streamModel 按录制的分块流式返回回答.
+type streamModel struct {
+	chunks []*schema.Message
+}
+
+func (m *streamModel) Generate(context.Context, []*schema.Message, ...model.Option) (*schema.Message, error) {
+	return schema.ConcatMessages(m.chunks)
+}
+
+func (m *streamModel) Stream(context.Context, []*schema.Message, ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	return schema.StreamReaderFromArray(m.chunks), nil
+}
+
+func (m *streamModel) BindTools([]*schema.ToolInfo) error { return nil }
+
+func text(parts ...string) []*schema.Message {
+	chunks := make([]*schema.Message, 0, len(parts))
+	for _, p := range parts {
+		chunks = append(chunks, schema.AssistantMessage(p, nil))
+	}
+	return chunks
+}
+
+func nativeCall(name, args string) *schema.Message {
+	return schema.AssistantMessage("", []schema.ToolCall{{
+		ID:       "call_1",
+		Function: schema.FunctionCall{Name: name, Arguments: args},
+	}})
+}
+
+func TestStreamChecker(t *testing.T) {
+	native := &CheckerConfig{}
+	parsed := &CheckerConfig{Formats: AllFormats, Tools: testTools}
+	// 读到流结束, 文字之后的工具调用也能发现
+	toEnd := &CheckerConfig{Formats: AllFormats, Tools: testTools, TextChars: -1}
+
+	tests := []struct {
+		name   string
+		conf   *CheckerConfig
+		chunks []*schema.Message
+		// viaModel 先经过 toolcall.Model, 就像 agent 中那样
+		viaModel bool
+		want     bool
+	}{
+		{
+			name:   "native tool call in the first chunk",
+			conf:   native,
+			chunks: append([]*schema.Message{nativeCall("query_dishes", `{"restaurant_id":"1"}`)}, text("never read")...),
+			want:   true,
+		},
+		{
+			name:   "native tool call after empty chunks",
+			conf:   native,
+			chunks: append(text("", "\n", " "), nativeCall("query_dishes", `{}`)),
+			want:   true,
+		},
+		{
+			name:   "native answer",
+			conf:   native,
+			chunks: text("", "Try ", "the mapo tofu."),
+			want:   false,
+		},
+		{
+			name:     "parsed call after leading text",
+			conf:     toEnd,
+			chunks:   text("Let me look that up. ", "query_dishes(restaurant_id=", "'1001')"),
+			viaModel: true,
+			want:     true,
+		},
+		{
+			name:     "parsed call after leading text and empty chunks",
+			conf:     toEnd,
+			chunks:   text("", " ", "Sure, ", "<tool_call>", `{"name":"query_dishes","arguments":{"restaurant_id":"1"}}`, "</tool_call>"),
+			viaModel: true,
+			want:     true,
+		},
+		{
+			name:     "parsed text longer than the openers before a call",
+			conf:     parsed,
+			chunks:   text("Let me look that up for you. ", `query_dishes(restaurant_id="1")`),
+			viaModel: true,
+			want:     false,
+		},
+		{
+			name:     "parsed payload split across chunks",
+			conf:     parsed,
+			chunks:   text("<tool", "_call>", `{"name":"query_restaurants",`, `"arguments":{"location":"Beijing"}}`, "</tool_call>"),
+			viaModel: true,
+			want:     true,
+		},
+		{
+			name:     "parsed plain answer",
+			conf:     parsed,
+			chunks:   text("The ", "mapo tofu ", "at Sichuan House."),
+			viaModel: true,
+			want:     false,
+		},
+		{
+			name:   "payload prefix that isn't a call",
+			conf:   parsed,
+			chunks: text("[", "1, 2", "]"),
+			want:   false,
+		},
+		{
+			name:   "payload prefix with PayloadIsToolCall",
+			conf:   &CheckerConfig{Formats: []Format{FormatJSON}, PayloadIsToolCall: true},
+			chunks: text(" ", "{", `"name":"query_dishes"}`),
+			want:   true,
+		},
+		{
+			name:   "text chars decide before a late call",
+			conf:   &CheckerConfig{Formats: AllFormats, Tools: testTools, TextChars: 5},
+			chunks: text("Let me look. ", `query_dishes(restaurant_id="1")`),
+			want:   false,
+		},
+		{
+			name:   "max chunks",
+			conf:   &CheckerConfig{TextChars: -1, MaxChunks: 2},
+			chunks: text("a", "b", "c"),
+			want:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sr := schema.StreamReaderFromArray(tt.chunks)
+			if tt.viaModel {
+				m := WrapModel(&streamModel{chunks: tt.chunks}, NewParser(tt.conf.Formats...))
+				if err := m.BindTools([]*schema.ToolInfo{{Name: "query_restaurants"}, {Name: "query_dishes"}}); err != nil {
+					t.Fatal(err)
+				}
+				var err error
+				if sr, err = m.Stream(context.Background(), nil); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := NewStreamChecker(tt.conf)(context.Background(), sr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("tool call = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestStreamCheckerDecidesEarly 检查器不等流结束就做出判断.
+func TestStreamCheckerDecidesEarly(t *testing.T) {
+	tests := []struct {
+		name  string
+		conf  *CheckerConfig
+		first *schema.Message
+		want  bool
+	}{
+		{"native tool call", &CheckerConfig{}, nativeCall("query_dishes", `{}`), true},
+		{"text without formats", &CheckerConfig{}, schema.AssistantMessage("Sure", nil), false},
+		{"text chars", &CheckerConfig{Formats: AllFormats, TextChars: 3}, schema.AssistantMessage("Sure", nil), false},
+		// 比最长的开头还长的文字不可能再是工具调用
+		{"text longer than the openers", &CheckerConfig{Formats: []Format{FormatXML}}, schema.AssistantMessage("The mapo tofu is good.", nil), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sr, sw := schema.Pipe[*schema.Message](1)
+			defer sw.Close()
+			sw.Send(tt.first, nil)
+
+			done := make(chan bool, 1)
+			go func() {
+				got, _ := NewStreamChecker(tt.conf)(context.Background(), sr)
+				done <- got
+			}()
+
+			select {
+			case got := <-done:
+				if got != tt.want {
+					t.Errorf("tool call = %v, want %v", got, tt.want)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("the checker waited for the end of the stream")
+			}
+		})
+	}
+}
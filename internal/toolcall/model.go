@@ -0,0 +1,230 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package toolcall
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// Model wraps a ChatModel and turns tool calls written into the content into
+// schema.ToolCall entries, so the ReAct agent routes them to the tools node.
+// Messages that already carry native tool calls are returned unchanged.
+type Model struct {
+	model.ChatModel
+	parser *Parser
+
+	mu    sync.RWMutex
+	tools []string // BindTools 绑定的工具名, 只接受这些工具的调用
+}
+
+// WrapModel wraps m with the parser.
+func WrapModel(m model.ChatModel, parser *Parser) *Model {
+	return &Model{ChatModel: m, parser: parser}
+}
+
+func (m *Model) BindTools(tools []*schema.ToolInfo) error {
+	names := make([]string, 0, len(tools))
+	for _, t := range tools {
+		names = append(names, t.Name)
+	}
+
+	m.mu.Lock()
+	m.tools = names
+	m.mu.Unlock()
+	return m.ChatModel.BindTools(tools)
+}
+
+// IsCallbacksEnabled returns false, so the graph runs the callbacks on the messages of the
+// wrapper, with the tool calls parsed out of the content. The wrapped model is called without
+// callbacks, its raw content isn't reported.
+func (m *Model) IsCallbacksEnabled() bool {
+	return false
+}
+
+// withoutCallbacks 去掉 ctx 中的 callback handler, 用于调用被包装的模型.
+func withoutCallbacks(ctx context.Context) context.Context {
+	return callbacks.InitCallbacks(ctx, nil)
+}
+
+func (m *Model) GetType() string {
+	if typ, ok := components.GetType(m.ChatModel); ok {
+		return typ
+	}
+	return "ToolCallParser"
+}
+
+func (m *Model) boundTools() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.tools
+}
+
+func (m *Model) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	msg, err := m.ChatModel.Generate(withoutCallbacks(ctx), input, opts...)
+	if err != nil || len(msg.ToolCalls) > 0 {
+		return msg, err
+	}
+
+	calls, rest := m.parser.Parse(msg.Content, m.boundTools())
+	if len(calls) == 0 {
+		return msg, nil
+	}
+
+	out := *msg
+	out.Content = rest
+	out.ToolCalls = calls
+	return &out, nil
+}
+
+// Stream passes the chunks through as they arrive. When the content starts like a tool call
+// the chunks are held back until the end and, if a call is found, replaced with one message
+// carrying the tool calls; calls found later in the content are appended as a last chunk.
+func (m *Model) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	sr, err := m.ChatModel.Stream(withoutCallbacks(ctx), input, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	tools := m.boundTools()
+	openers := Openers(m.parser.Formats(), tools)
+
+	out, sw := schema.Pipe[*schema.Message](1)
+	go func() {
+		defer sr.Close()
+		defer sw.Close()
+
+		var (
+			held    []*schema.Message // 还不能确定是否为工具调用时暂存的消息
+			content strings.Builder
+			native  bool
+			holding = true
+		)
+
+		for {
+			chunk, err := sr.Recv()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				sw.Send(nil, err)
+				return
+			}
+
+			content.WriteString(chunk.Content)
+			if len(chunk.ToolCalls) > 0 {
+				native = true
+			}
+
+			if holding {
+				held = append(held, chunk)
+				if !native && leadsTo(content.String(), openers) != plain {
+					continue
+				}
+
+				// not a tool call written into the content, release what was held back
+				holding = false
+				for _, c := range held {
+					if sw.Send(c, nil) {
+						return
+					}
+				}
+				held = nil
+				continue
+			}
+
+			if sw.Send(chunk, nil) {
+				return
+			}
+		}
+
+		if native {
+			return
+		}
+
+		calls, rest := m.parser.Parse(content.String(), tools)
+		switch {
+		case len(calls) == 0:
+			for _, c := range held {
+				if sw.Send(c, nil) {
+					return
+				}
+			}
+		case holding:
+			// the whole content was held back, send it without the tool calls
+			msg := &schema.Message{Role: schema.Assistant, Content: rest, ToolCalls: calls}
+			if len(held) > 0 {
+				msg.ResponseMeta = held[len(held)-1].ResponseMeta
+			}
+			sw.Send(msg, nil)
+		default:
+			sw.Send(&schema.Message{Role: schema.Assistant, ToolCalls: calls}, nil)
+		}
+	}()
+
+	return out, nil
+}
+
+const (
+	undecided = iota // content 太短, 还不能判断
+	candidate        // content 以工具调用的开头开始
+	plain            // 普通回答
+)
+
+// Openers returns the prefixes a tool call written in one of the formats starts with.
+func Openers(formats []Format, tools []string) []string {
+	var openers []string
+	for _, f := range formats {
+		switch f {
+		case FormatJSON:
+			openers = append(openers, "{", "[", "```")
+		case FormatXML:
+			openers = append(openers, "<tool_call>", "<function=")
+		case FormatFunction:
+			for _, t := range tools {
+				openers = append(openers, t+"(")
+			}
+		}
+	}
+	return openers
+}
+
+func leadsTo(content string, openers []string) int {
+	s := strings.TrimSpace(content)
+	if s == "" {
+		return undecided
+	}
+
+	result := plain
+	for _, o := range openers {
+		if strings.HasPrefix(s, o) {
+			return candidate
+		}
+		if strings.HasPrefix(o, s) {
+			result = undecided
+		}
+	}
+	return result
+}
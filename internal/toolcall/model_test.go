@@ -0,0 +1,141 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package toolcall
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/flow/agent/react"
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/galihrivanto/eino-exp/internal/agentrun"
+)
+
+// reportingModel 像 ollama 一样自己报告 callback, 第一轮把工具调用写在内容里, 之后直接回答.
+type reportingModel struct {
+	mu    sync.Mutex
+	turns int
+}
+
+func (m *reportingModel) IsCallbacksEnabled() bool { return true }
+
+func (m *reportingModel) next() *schema.Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.turns++
+	if m.turns == 1 {
+		return schema.AssistantMessage(`<tool_call>{"name":"query_dishes","arguments":{"restaurant_id":"1001"}}</tool_call>`, nil)
+	}
+	return schema.AssistantMessage("Try the mapo tofu.", nil)
+}
+
+func (m *reportingModel) Generate(ctx context.Context, input []*schema.Message, _ ...model.Option) (*schema.Message, error) {
+	ctx = callbacks.OnStart(ctx, &model.CallbackInput{Messages: input})
+	msg := m.next()
+	callbacks.OnEnd(ctx, &model.CallbackOutput{Message: msg})
+	return msg, nil
+}
+
+func (m *reportingModel) Stream(ctx context.Context, input []*schema.Message, _ ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	ctx = callbacks.OnStart(ctx, &model.CallbackInput{Messages: input})
+	sr := schema.StreamReaderFromArray([]*schema.Message{m.next()})
+	_, sr = callbacks.OnEndWithStreamOutput(ctx, sr)
+	return sr, nil
+}
+
+func (m *reportingModel) BindTools([]*schema.ToolInfo) error { return nil }
+
+type dishTool struct{}
+
+func (dishTool) Info(context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{Name: "query_dishes", Desc: "dishes of a restaurant"}, nil
+}
+
+func (dishTool) InvokableRun(context.Context, string, ...tool.Option) (string, error) {
+	return `[{"name":"mapo tofu"}]`, nil
+}
+
+// TestModelCallbacksSeeParsedCalls 经过 Model 的 trace 中, 模型这一轮是解析出的工具调用而不是原始内容.
+func TestModelCallbacksSeeParsedCalls(t *testing.T) {
+	for _, stream := range []bool{false, true} {
+		name := "generate"
+		if stream {
+			name = "stream"
+		}
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			newAgent := func() *react.Agent {
+				a, err := react.NewAgent(ctx, &react.AgentConfig{
+					Model:                 WrapModel(&reportingModel{}, NewParser(FormatXML)),
+					ToolsConfig:           compose.ToolsNodeConfig{Tools: []tool.BaseTool{dishTool{}}},
+					StreamToolCallChecker: NewStreamChecker(&CheckerConfig{Formats: []Format{FormatXML}}),
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+				return a
+			}
+			a := newAgent()
+
+			input := []*schema.Message{schema.UserMessage("what to eat at 1001?")}
+			var (
+				res *agentrun.Result
+				err error
+			)
+			if stream {
+				res, err = agentrun.Stream(ctx, a, input, nil)
+			} else {
+				res, err = agentrun.Generate(ctx, a, input)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			turns, calls := res.ModelTurns(), res.ToolCalls()
+			if len(turns) != 2 || len(calls) != 1 {
+				t.Fatalf("got %d model turns and %d tool calls, want 2 and 1:\n%s", len(turns), len(calls), res)
+			}
+			first := turns[0].Message
+			if first == nil || len(first.ToolCalls) != 1 || first.Content != "" {
+				t.Fatalf("first turn = %+v, want the parsed tool call without the raw text", first)
+			}
+			if calls[0].ToolCallID == "" || calls[0].ToolCallID != first.ToolCalls[0].ID {
+				t.Errorf("tool call ID %q, want %q", calls[0].ToolCallID, first.ToolCalls[0].ID)
+			}
+
+			// 事件中有解析出的调用, 工具结果对应到它的 ID
+			var requested, matched string
+			for ev := range agentrun.Events(ctx, newAgent(), input) {
+				switch ev.Type {
+				case agentrun.EventToolCallRequested:
+					requested = ev.ToolCallID
+				case agentrun.EventToolResult:
+					matched = ev.ToolCallID
+				}
+			}
+			if requested == "" || matched != requested {
+				t.Errorf("tool_call_requested %q, tool_result %q, want the same ID", requested, matched)
+			}
+		})
+	}
+}
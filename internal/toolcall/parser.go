@@ -0,0 +1,477 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package toolcall recovers tool calls that models without native tool calling
+// write into the message content.
+package toolcall
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// Format is a way of writing a tool call into the content.
+type Format string
+
+const (
+	// FormatJSON is a JSON object or array of objects, optionally in a ```json fence:
+	//  {"name": "query_dishes", "arguments": {"restaurant_id": "1001"}}
+	FormatJSON Format = "json"
+	// FormatXML is JSON wrapped in tags, as written by Qwen and Hermes style templates:
+	//  <tool_call>{"name": "query_dishes", "arguments": {...}}</tool_call>
+	//  <function=query_dishes>{"restaurant_id": "1001"}</function>
+	FormatXML Format = "xml"
+	// FormatFunction is function call syntax with JSON or keyword arguments:
+	//  query_dishes({"restaurant_id": "1001"})
+	//  query_dishes(restaurant_id="1001", topn=3)
+	FormatFunction Format = "function"
+)
+
+// AllFormats is every supported format.
+var AllFormats = []Format{FormatXML, FormatJSON, FormatFunction}
+
+// ParseFormats parses a comma separated list of formats.
+func ParseFormats(s string) ([]Format, error) {
+	var formats []Format
+	for _, f := range strings.Split(s, ",") {
+		switch f = strings.TrimSpace(f); Format(f) {
+		case "":
+		case FormatJSON, FormatXML, FormatFunction:
+			formats = append(formats, Format(f))
+		default:
+			return nil, fmt.Errorf("unknown tool call format %q, want json, xml or function", f)
+		}
+	}
+	return formats, nil
+}
+
+// modelFormats 已知模型习惯使用的格式, 按模型名前缀匹配.
+var modelFormats = map[string][]Format{
+	"qwen":     {FormatXML, FormatJSON},
+	"hermes":   {FormatXML, FormatJSON},
+	"llama3":   {FormatJSON, FormatXML, FormatFunction},
+	"mistral":  {FormatJSON},
+	"deepseek": {FormatJSON, FormatFunction},
+	"gemma":    {FormatFunction, FormatJSON},
+	"phi":      {FormatJSON, FormatFunction},
+}
+
+// FormatsFor returns the formats usually written by the model, e.g. "qwen2:7b".
+// Unknown models get AllFormats.
+func FormatsFor(model string) []Format {
+	name := strings.ToLower(model)
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+
+	// 最长前缀优先, 避免 "llama3" 和 "llama" 这类前缀互相覆盖
+	prefixes := make([]string, 0, len(modelFormats))
+	for p := range modelFormats {
+		prefixes = append(prefixes, p)
+	}
+	sort.Slice(prefixes, func(i, j int) bool { return len(prefixes[i]) > len(prefixes[j]) })
+
+	for _, p := range prefixes {
+		if strings.HasPrefix(name, p) {
+			return modelFormats[p]
+		}
+	}
+	return AllFormats
+}
+
+// Parser finds tool calls in message content.
+type Parser struct {
+	formats []Format
+}
+
+// NewParser creates a parser for the given formats, all formats when none is given.
+func NewParser(formats ...Format) *Parser {
+	if len(formats) == 0 {
+		formats = AllFormats
+	}
+	return &Parser{formats: formats}
+}
+
+// Formats returns the formats the parser looks for.
+func (p *Parser) Formats() []Format {
+	return p.formats
+}
+
+// Parse returns the tool calls found in content and the content left without them.
+// When tools is not empty only calls of these tools are accepted, which keeps ordinary
+// JSON or code in an answer from being taken for a tool call.
+func (p *Parser) Parse(content string, tools []string) (calls []schema.ToolCall, rest string) {
+	known := make(map[string]bool, len(tools))
+	for _, t := range tools {
+		known[t] = true
+	}
+	accept := func(name string) bool {
+		return name != "" && (len(known) == 0 || known[name])
+	}
+
+	for _, f := range p.formats {
+		var spans []span
+		switch f {
+		case FormatXML:
+			spans = parseXML(content, accept)
+		case FormatJSON:
+			spans = parseJSON(content, accept)
+		case FormatFunction:
+			spans = parseFunction(content, tools)
+		}
+		if len(spans) == 0 {
+			continue
+		}
+
+		// 使用第一个解析出调用的格式, 不同格式混用的情况很少, 混在一起反而容易误判.
+		// 数组中的多个调用共用同一段 content, 每段只去掉一次
+		var b strings.Builder
+		last := 0
+		prefix := newCallPrefix()
+		for i, s := range spans {
+			if s.start >= last {
+				b.WriteString(content[last:s.start])
+			}
+			last = max(last, s.end)
+			calls = append(calls, schema.ToolCall{
+				ID:   fmt.Sprintf("%s_%d", prefix, i),
+				Type: "function",
+				Function: schema.FunctionCall{
+					Name:      s.name,
+					Arguments: s.args,
+				},
+			})
+		}
+		b.WriteString(content[last:])
+		return calls, strings.TrimSpace(b.String())
+	}
+
+	return nil, content
+}
+
+// newCallPrefix 生成工具调用 ID 的前缀, 让不同消息中的调用 ID 不重复.
+func newCallPrefix() string {
+	b := make([]byte, 6)
+	_, _ = rand.Read(b)
+	return "call_" + hex.EncodeToString(b)
+}
+
+// span 一个工具调用在 content 中的位置和解析结果.
+type span struct {
+	start, end int
+	name       string
+	args       string
+}
+
+var (
+	xmlToolCall = regexp.MustCompile(`(?s)<tool_call>\s*(.*?)\s*(?:</tool_call>|$)`)
+	xmlFunction = regexp.MustCompile(`(?s)<function=([A-Za-z0-9_.-]+)>\s*(.*?)\s*</function>`)
+	fence       = regexp.MustCompile("(?s)```(?:json|tool_call|tool_code)?\\s*\n?(.*?)```")
+)
+
+func parseXML(content string, accept func(string) bool) []span {
+	var spans []span
+	for _, m := range xmlToolCall.FindAllStringSubmatchIndex(content, -1) {
+		for _, c := range decodeCalls(content[m[2]:m[3]]) {
+			if accept(c.name) {
+				spans = append(spans, span{start: m[0], end: m[1], name: c.name, args: c.args})
+			}
+		}
+	}
+	for _, m := range xmlFunction.FindAllStringSubmatchIndex(content, -1) {
+		name, args := content[m[2]:m[3]], strings.TrimSpace(content[m[4]:m[5]])
+		if args == "" {
+			args = "{}"
+		}
+		if accept(name) && json.Valid([]byte(args)) {
+			spans = append(spans, span{start: m[0], end: m[1], name: name, args: args})
+		}
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+	return spans
+}
+
+func parseJSON(content string, accept func(string) bool) []span {
+	var spans []span
+
+	// fenced blocks first, then bare JSON outside of them
+	covered := make([]bool, len(content))
+	for _, m := range fence.FindAllStringSubmatchIndex(content, -1) {
+		var found []span
+		for _, c := range decodeCalls(content[m[2]:m[3]]) {
+			if accept(c.name) {
+				found = append(found, span{start: m[0], end: m[1], name: c.name, args: c.args})
+			}
+		}
+		if len(found) > 0 {
+			spans = append(spans, found...)
+			for i := m[0]; i < m[1]; i++ {
+				covered[i] = true
+			}
+		}
+	}
+
+	for i := 0; i < len(content); i++ {
+		if covered[i] || (content[i] != '{' && content[i] != '[') {
+			continue
+		}
+
+		dec := json.NewDecoder(strings.NewReader(content[i:]))
+		var v json.RawMessage
+		if err := dec.Decode(&v); err != nil {
+			continue
+		}
+		end := i + int(dec.InputOffset())
+
+		var found []span
+		for _, c := range decodeCalls(string(v)) {
+			if accept(c.name) {
+				found = append(found, span{start: i, end: end, name: c.name, args: c.args})
+			}
+		}
+		if len(found) > 0 {
+			spans = append(spans, found...)
+			i = end - 1
+		}
+	}
+
+	sort.SliceStable(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+	return spans
+}
+
+// callRegexps 缓存每个工具名的函数调用正则, 流式输出的每条消息都要解析.
+var callRegexps sync.Map // string -> *regexp.Regexp
+
+func callRegexp(name string) *regexp.Regexp {
+	if re, ok := callRegexps.Load(name); ok {
+		return re.(*regexp.Regexp)
+	}
+	re, _ := callRegexps.LoadOrStore(name, regexp.MustCompile(`\b`+regexp.QuoteMeta(name)+`\s*\(`))
+	return re.(*regexp.Regexp)
+}
+
+func parseFunction(content string, tools []string) []span {
+	var spans []span
+	for _, name := range tools {
+		re := callRegexp(name)
+		for _, m := range re.FindAllStringIndex(content, -1) {
+			end := matchParen(content, m[1]-1)
+			if end < 0 {
+				continue
+			}
+			args, ok := functionArgs(content[m[1] : end-1])
+			if ok {
+				spans = append(spans, span{start: m[0], end: end, name: name, args: args})
+			}
+		}
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+	return spans
+}
+
+// matchParen returns the index after the parenthesis closing the one at open, -1 if there is none.
+func matchParen(s string, open int) int {
+	depth := 0
+	var quote byte
+	for i := open; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '(' || c == '{' || c == '[':
+			depth++
+		case c == ')' || c == '}' || c == ']':
+			depth--
+			if depth == 0 {
+				if c != ')' {
+					return -1
+				}
+				return i + 1
+			}
+		}
+	}
+	return -1
+}
+
+// functionArgs converts the arguments in the parenthesis to a JSON object.
+func functionArgs(s string) (string, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return "{}", true
+	}
+	if strings.HasPrefix(s, "{") {
+		return s, json.Valid([]byte(s))
+	}
+
+	args := map[string]any{}
+	for _, part := range splitTopLevel(s) {
+		k, v, ok := strings.Cut(part, "=")
+		if !ok {
+			k, v, ok = strings.Cut(part, ":")
+		}
+		k, v = strings.TrimSpace(k), strings.TrimSpace(v)
+		if !ok || k == "" || strings.ContainsAny(k, " \"'") {
+			return "", false
+		}
+		args[k] = literal(v)
+	}
+
+	b, err := json.Marshal(args)
+	if err != nil {
+		return "", false
+	}
+	return string(b), true
+}
+
+// splitTopLevel splits s on the commas that are not inside quotes or brackets.
+func splitTopLevel(s string) []string {
+	var parts []string
+	depth, last := 0, 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '(' || c == '{' || c == '[':
+			depth++
+		case c == ')' || c == '}' || c == ']':
+			depth--
+		case c == ',' && depth == 0:
+			parts = append(parts, s[last:i])
+			last = i + 1
+		}
+	}
+	return append(parts, s[last:])
+}
+
+// literal converts a keyword argument value: quoted strings, numbers, booleans and JSON are
+// decoded, anything else is kept as a string.
+func literal(v string) any {
+	if len(v) >= 2 && v[0] == '\'' && v[len(v)-1] == '\'' {
+		return strings.ReplaceAll(v[1:len(v)-1], `\'`, `'`)
+	}
+	if s, err := strconv.Unquote(v); err == nil {
+		return s
+	}
+	switch v {
+	case "True", "true":
+		return true
+	case "False", "false":
+		return false
+	case "None", "null":
+		return nil
+	}
+
+	var x any
+	if err := json.Unmarshal([]byte(v), &x); err == nil {
+		return x
+	}
+	return v
+}
+
+type decoded struct {
+	name string
+	args string
+}
+
+// decodeCalls decodes a JSON object or array of objects in one of the common shapes:
+//
+//	{"name": ..., "arguments": {...}}
+//	{"function": {"name": ..., "arguments": ...}}
+//	{"tool": ..., "parameters": {...}}
+func decodeCalls(s string) []decoded {
+	s = strings.TrimSpace(s)
+
+	var list []json.RawMessage
+	if strings.HasPrefix(s, "[") {
+		if err := json.Unmarshal([]byte(s), &list); err != nil {
+			return nil
+		}
+	} else {
+		list = []json.RawMessage{json.RawMessage(s)}
+	}
+
+	var calls []decoded
+	for _, raw := range list {
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &obj); err != nil {
+			return nil
+		}
+		if fn, ok := obj["function"]; ok && bytes.HasPrefix(bytes.TrimSpace(fn), []byte("{")) {
+			if err := json.Unmarshal(fn, &obj); err != nil {
+				return nil
+			}
+		}
+
+		name := firstString(obj, "name", "tool", "tool_name", "function")
+		if name == "" {
+			return nil
+		}
+
+		args := "{}"
+		for _, k := range []string{"arguments", "parameters", "args", "input"} {
+			v, ok := obj[k]
+			if !ok {
+				continue
+			}
+			// 有的模型把参数写成 JSON 字符串
+			var str string
+			if err := json.Unmarshal(v, &str); err == nil {
+				v = json.RawMessage(str)
+			}
+			if !json.Valid(v) {
+				return nil
+			}
+			args = string(v)
+			break
+		}
+		calls = append(calls, decoded{name: name, args: args})
+	}
+	return calls
+}
+
+func firstString(obj map[string]json.RawMessage, keys ...string) string {
+	for _, k := range keys {
+		var s string
+		if v, ok := obj[k]; ok && json.Unmarshal(v, &s) == nil && s != "" {
+			return s
+		}
+	}
+	return ""
+}
@@ -0,0 +1,224 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package toolcall
+
+import (
+	"strings"
+	"testing"
+)
+
+var testTools = []string{"query_restaurants", "query_dishes"}
+
+func TestParse(t *testing.T) {
+	type call struct{ name, args string }
+	tests := []struct {
+		name    string
+		formats []Format
+		content string
+		want    []call
+		rest    string
+	}{
+		{
+			name:    "json object",
+			formats: []Format{FormatJSON},
+			content: `{"name": "query_dishes", "arguments": {"restaurant_id": "1001"}}`,
+			want:    []call{{"query_dishes", `{"restaurant_id": "1001"}`}},
+		},
+		{
+			name:    "json array",
+			formats: []Format{FormatJSON},
+			content: `[{"name":"query_dishes","arguments":{"restaurant_id":"1001"}},{"name":"query_restaurants","arguments":{"location":"Beijing","topn":2}}]`,
+			want: []call{
+				{"query_dishes", `{"restaurant_id":"1001"}`},
+				{"query_restaurants", `{"location":"Beijing","topn":2}`},
+			},
+		},
+		{
+			name:    "json array with text around",
+			formats: []Format{FormatJSON},
+			content: `Let me look. [{"name":"query_dishes","arguments":{"restaurant_id":"1"}},{"name":"query_dishes","arguments":{"restaurant_id":"2"}}] Done.`,
+			want: []call{
+				{"query_dishes", `{"restaurant_id":"1"}`},
+				{"query_dishes", `{"restaurant_id":"2"}`},
+			},
+			rest: "Let me look.  Done.",
+		},
+		{
+			name:    "json fence",
+			formats: []Format{FormatJSON},
+			content: "Sure.\n```json\n{\"name\": \"query_restaurants\", \"arguments\": {\"location\": \"Beijing\"}}\n```",
+			want:    []call{{"query_restaurants", `{"location": "Beijing"}`}},
+			rest:    "Sure.",
+		},
+		{
+			name:    "json fence with array",
+			formats: []Format{FormatJSON},
+			content: "```json\n[{\"name\":\"query_dishes\",\"arguments\":{\"restaurant_id\":\"1\"}},{\"name\":\"query_restaurants\",\"arguments\":{}}]\n```\nthanks",
+			want: []call{
+				{"query_dishes", `{"restaurant_id":"1"}`},
+				{"query_restaurants", `{}`},
+			},
+			rest: "thanks",
+		},
+		{
+			name:    "json function shape and string arguments",
+			formats: []Format{FormatJSON},
+			content: `{"function": {"name": "query_dishes", "arguments": "{\"restaurant_id\":\"1001\"}"}}`,
+			want:    []call{{"query_dishes", `{"restaurant_id":"1001"}`}},
+		},
+		{
+			name:    "json tool and parameters",
+			formats: []Format{FormatJSON},
+			content: `{"tool": "query_restaurants", "parameters": {"location": "Shanghai"}}`,
+			want:    []call{{"query_restaurants", `{"location": "Shanghai"}`}},
+		},
+		{
+			name:    "json of unknown tool is left alone",
+			formats: []Format{FormatJSON},
+			content: `{"name": "send_email", "arguments": {}}`,
+			rest:    `{"name": "send_email", "arguments": {}}`,
+		},
+		{
+			name:    "xml tool_call",
+			formats: []Format{FormatXML},
+			content: "<tool_call>\n{\"name\": \"query_dishes\", \"arguments\": {\"restaurant_id\": \"1001\"}}\n</tool_call>",
+			want:    []call{{"query_dishes", `{"restaurant_id": "1001"}`}},
+		},
+		{
+			name:    "xml tool_call with array",
+			formats: []Format{FormatXML},
+			content: `before <tool_call>[{"name":"query_dishes","arguments":{"restaurant_id":"1"}},{"name":"query_restaurants","arguments":{"location":"Beijing"}}]</tool_call> after`,
+			want: []call{
+				{"query_dishes", `{"restaurant_id":"1"}`},
+				{"query_restaurants", `{"location":"Beijing"}`},
+			},
+			rest: "before  after",
+		},
+		{
+			name:    "xml several tool_calls",
+			formats: []Format{FormatXML},
+			content: `<tool_call>{"name":"query_dishes","arguments":{"restaurant_id":"1"}}</tool_call><tool_call>{"name":"query_dishes","arguments":{"restaurant_id":"2"}}</tool_call>`,
+			want: []call{
+				{"query_dishes", `{"restaurant_id":"1"}`},
+				{"query_dishes", `{"restaurant_id":"2"}`},
+			},
+		},
+		{
+			name:    "xml unclosed tool_call",
+			formats: []Format{FormatXML},
+			content: `<tool_call>{"name":"query_dishes","arguments":{"restaurant_id":"1"}}`,
+			want:    []call{{"query_dishes", `{"restaurant_id":"1"}`}},
+		},
+		{
+			name:    "xml function tag",
+			formats: []Format{FormatXML},
+			content: `<function=query_dishes>{"restaurant_id": "1001"}</function>`,
+			want:    []call{{"query_dishes", `{"restaurant_id": "1001"}`}},
+		},
+		{
+			name:    "function with json",
+			formats: []Format{FormatFunction},
+			content: `query_dishes({"restaurant_id": "1001"})`,
+			want:    []call{{"query_dishes", `{"restaurant_id": "1001"}`}},
+		},
+		{
+			name:    "function with keywords",
+			formats: []Format{FormatFunction},
+			content: `I'll call query_restaurants(location="Beijing", topn=3, open=True) now`,
+			want:    []call{{"query_restaurants", `{"location":"Beijing","open":true,"topn":3}`}},
+			rest:    "I'll call  now",
+		},
+		{
+			name:    "function several calls",
+			formats: []Format{FormatFunction},
+			content: `query_dishes(restaurant_id='1') query_dishes(restaurant_id='2')`,
+			want: []call{
+				{"query_dishes", `{"restaurant_id":"1"}`},
+				{"query_dishes", `{"restaurant_id":"2"}`},
+			},
+		},
+		{
+			name:    "first format with calls wins",
+			formats: AllFormats,
+			content: `<tool_call>{"name":"query_dishes","arguments":{"restaurant_id":"1"}}</tool_call> query_restaurants(location="x")`,
+			want:    []call{{"query_dishes", `{"restaurant_id":"1"}`}},
+			rest:    `query_restaurants(location="x")`,
+		},
+		{
+			name:    "plain answer",
+			formats: AllFormats,
+			content: "Try the mapo tofu at Sichuan House.",
+			rest:    "Try the mapo tofu at Sichuan House.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls, rest := NewParser(tt.formats...).Parse(tt.content, testTools)
+			if len(calls) != len(tt.want) {
+				t.Fatalf("got %d calls %+v, want %d", len(calls), calls, len(tt.want))
+			}
+			ids := map[string]bool{}
+			for i, c := range calls {
+				if c.Function.Name != tt.want[i].name || c.Function.Arguments != tt.want[i].args {
+					t.Errorf("call %d = %s(%s), want %s(%s)", i, c.Function.Name, c.Function.Arguments, tt.want[i].name, tt.want[i].args)
+				}
+				if c.ID == "" || ids[c.ID] {
+					t.Errorf("call %d has empty or duplicate ID %q", i, c.ID)
+				}
+				ids[c.ID] = true
+			}
+			if rest != tt.rest {
+				t.Errorf("rest = %q, want %q", rest, tt.rest)
+			}
+		})
+	}
+}
+
+func TestParseAnyTool(t *testing.T) {
+	calls, _ := NewParser(FormatJSON).Parse(`{"name": "anything", "arguments": {}}`, nil)
+	if len(calls) != 1 || calls[0].Function.Name != "anything" {
+		t.Fatalf("got %+v, want a call of anything", calls)
+	}
+}
+
+func TestParseFormats(t *testing.T) {
+	got, err := ParseFormats(" json, xml ,,function")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join([]string{string(got[0]), string(got[1]), string(got[2])}, ",") != "json,xml,function" || len(got) != 3 {
+		t.Errorf("got %v", got)
+	}
+	if _, err = ParseFormats("yaml"); err == nil {
+		t.Error("want an error for an unknown format")
+	}
+}
+
+func TestFormatsFor(t *testing.T) {
+	tests := map[string]Format{
+		"qwen2:7b":             FormatXML,
+		"library/llama3.2":     FormatJSON,
+		"gemma2:9b":            FormatFunction,
+		"some-unknown-model:1": AllFormats[0],
+	}
+	for model, first := range tests {
+		if got := FormatsFor(model); got[0] != first {
+			t.Errorf("FormatsFor(%q) = %v, want %s first", model, got, first)
+		}
+	}
+}
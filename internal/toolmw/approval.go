@@ -0,0 +1,303 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package toolmw
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+)
+
+// ApprovalRequest is a tool call waiting for approval.
+type ApprovalRequest struct {
+	Tool      string
+	Arguments string // JSON arguments as emitted by the model
+}
+
+// ApprovalDecision is the answer to an ApprovalRequest.
+type ApprovalDecision struct {
+	Approved bool
+	// Arguments replaces the arguments of an approved call when not empty.
+	Arguments string
+	// Reason is told to the model when the call is rejected.
+	Reason string
+}
+
+// Approver decides on tool calls, e.g. by asking a person. A nil decision rejects the call.
+type Approver interface {
+	Approve(ctx context.Context, req *ApprovalRequest) (*ApprovalDecision, error)
+}
+
+// ApproverFunc adapts a function to Approver.
+type ApproverFunc func(ctx context.Context, req *ApprovalRequest) (*ApprovalDecision, error)
+
+func (f ApproverFunc) Approve(ctx context.Context, req *ApprovalRequest) (*ApprovalDecision, error) {
+	return f(ctx, req)
+}
+
+// ApprovalPolicy reports whether a tool call needs approval.
+type ApprovalPolicy func(ctx context.Context, req *ApprovalRequest) bool
+
+// RequireTools returns a policy matching the calls of the named tools, "*" matches every tool.
+func RequireTools(names ...string) ApprovalPolicy {
+	set := make(map[string]bool, len(names))
+	for _, n := range names {
+		set[n] = true
+	}
+	return func(_ context.Context, req *ApprovalRequest) bool {
+		return set["*"] || set[req.Tool]
+	}
+}
+
+// ApprovalConfig is the config of Approval.
+type ApprovalConfig struct {
+	// Policy selects the calls that need approval, required.
+	Policy ApprovalPolicy
+	// Approver decides on the selected calls, required.
+	Approver Approver
+}
+
+// Approval pauses tool calls until they are approved. Rejected calls are not run,
+// the rejection is returned to the model as the tool result instead.
+//
+// Wrap the tools with Approval last, so the time spent waiting for a person
+// doesn't count against the timeouts of Limits.
+type Approval struct {
+	conf ApprovalConfig
+	mu   sync.Mutex // 同时只询问一个调用, 并行的工具调用排队等待
+}
+
+// NewApproval creates Approval from conf.
+func NewApproval(conf *ApprovalConfig) (*Approval, error) {
+	if conf == nil || conf.Policy == nil || conf.Approver == nil {
+		return nil, errors.New("toolmw: approval needs a policy and an approver")
+	}
+	return &Approval{conf: *conf}, nil
+}
+
+// Wrap returns t guarded by the approval gate.
+func (a *Approval) Wrap(ctx context.Context, t tool.InvokableTool) (tool.InvokableTool, error) {
+	info, err := t.Info(ctx)
+	if err != nil {
+		return nil, err
+	}
+	at := &approvedTool{InvokableTool: t, name: info.Name, approval: a}
+	if st, ok := t.(tool.StreamableTool); ok {
+		return Streamable(at, func(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (*schema.StreamReader[string], error) {
+			args, rejection, err := at.decide(ctx, argumentsInJSON)
+			if err != nil {
+				return nil, err
+			}
+			if rejection != "" {
+				return single(rejection), nil
+			}
+			return st.StreamableRun(ctx, args, opts...)
+		}), nil
+	}
+	return at, nil
+}
+
+// WrapAll wraps every invokable tool in tools, other tools are returned as they are.
+func (a *Approval) WrapAll(ctx context.Context, tools []tool.BaseTool) ([]tool.BaseTool, error) {
+	res := make([]tool.BaseTool, 0, len(tools))
+	for _, t := range tools {
+		it, ok := t.(tool.InvokableTool)
+		if !ok {
+			res = append(res, t)
+			continue
+		}
+
+		wrapped, err := a.Wrap(ctx, it)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, wrapped)
+	}
+	return res, nil
+}
+
+type approvedTool struct {
+	tool.InvokableTool
+	name     string
+	approval *Approval
+}
+
+func (t *approvedTool) InvokableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (string, error) {
+	args, rejection, err := t.decide(ctx, argumentsInJSON)
+	if err != nil || rejection != "" {
+		return rejection, err
+	}
+	return t.InvokableTool.InvokableRun(ctx, args, opts...)
+}
+
+// decide 返回批准后要使用的参数, 或者拒绝时给模型的结果.
+func (t *approvedTool) decide(ctx context.Context, argumentsInJSON string) (args, rejection string, err error) {
+	req := &ApprovalRequest{Tool: t.name, Arguments: argumentsInJSON}
+	if !t.approval.conf.Policy(ctx, req) {
+		return argumentsInJSON, "", nil
+	}
+
+	t.approval.mu.Lock()
+	d, err := t.approval.conf.Approver.Approve(ctx, req)
+	t.approval.mu.Unlock()
+	if err != nil {
+		return "", "", fmt.Errorf("approve %s: %w", t.name, err)
+	}
+
+	if d == nil {
+		return "", reportRejection(t.name, ""), nil
+	}
+	if !d.Approved {
+		return "", reportRejection(t.name, d.Reason), nil
+	}
+
+	if d.Arguments != "" {
+		if !json.Valid([]byte(d.Arguments)) {
+			return "", "", fmt.Errorf("approve %s: edited arguments are not valid JSON", t.name)
+		}
+		argumentsInJSON = d.Arguments
+	}
+	return argumentsInJSON, "", nil
+}
+
+// reportRejection renders the rejection as a tool result the model can read.
+func reportRejection(name, reason string) string {
+	msg := "the user rejected this tool call"
+	if reason != "" {
+		msg += ": " + reason
+	}
+
+	b, _ := json.Marshal(map[string]string{
+		"error":   "rejected",
+		"tool":    name,
+		"message": msg,
+		"hint":    "don't repeat the same call, ask the user or answer without it",
+	})
+	return string(b)
+}
+
+// LineReader reads lines in the background, so that a read can give up when its context
+// is done and the line typed afterwards still goes to the next read.
+type LineReader struct {
+	once  sync.Once
+	sc    *bufio.Scanner
+	lines chan string
+	err   error // 关闭 lines 之前写入, 输入结束时为 io.EOF
+}
+
+// NewLineReader reads lines from r, nothing is read before the first ReadLine.
+func NewLineReader(r io.Reader) *LineReader {
+	return &LineReader{sc: bufio.NewScanner(r), lines: make(chan string)}
+}
+
+// ReadLine returns the next line, io.EOF once the input is closed, or ctx.Err() when ctx
+// is done first.
+func (l *LineReader) ReadLine(ctx context.Context) (string, error) {
+	l.once.Do(func() { go l.scan() })
+	select {
+	case line, ok := <-l.lines:
+		if !ok {
+			return "", l.err
+		}
+		return line, nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+func (l *LineReader) scan() {
+	defer close(l.lines)
+	for l.sc.Scan() {
+		l.lines <- l.sc.Text()
+	}
+	if l.err = l.sc.Err(); l.err == nil {
+		l.err = io.EOF
+	}
+}
+
+// TerminalApprover asks for approval on a terminal.
+type TerminalApprover struct {
+	in  *LineReader
+	out io.Writer
+}
+
+// NewTerminalApprover asks on out and reads the answers from in. Pass the reader
+// a REPL already reads from, so both share the input.
+func NewTerminalApprover(in *LineReader, out io.Writer) *TerminalApprover {
+	return &TerminalApprover{in: in, out: out}
+}
+
+// Approve asks until it gets a valid answer or ctx is done. When the input is closed, e.g.
+// the question was piped through stdin, the call is rejected since nobody can approve it.
+func (a *TerminalApprover) Approve(ctx context.Context, req *ApprovalRequest) (*ApprovalDecision, error) {
+	d, err := a.approve(ctx, req)
+	if errors.Is(err, io.EOF) {
+		fmt.Fprintln(a.out, "input closed, rejected")
+		return &ApprovalDecision{Reason: "nobody is available to approve it"}, nil
+	}
+	return d, err
+}
+
+func (a *TerminalApprover) approve(ctx context.Context, req *ApprovalRequest) (*ApprovalDecision, error) {
+	fmt.Fprintf(a.out, "\ntool call %s(%s)\n", req.Tool, req.Arguments)
+
+	for {
+		answer, err := a.ask(ctx, "approve? [y]es / [n]o / [e]dit: ")
+		if err != nil {
+			return nil, err
+		}
+
+		switch strings.ToLower(answer) {
+		case "y", "yes":
+			return &ApprovalDecision{Approved: true}, nil
+		case "n", "no":
+			reason, err := a.ask(ctx, "reason (optional): ")
+			if err != nil {
+				return nil, err
+			}
+			return &ApprovalDecision{Reason: reason}, nil
+		case "e", "edit":
+			for {
+				args, err := a.ask(ctx, "new arguments (JSON): ")
+				if err != nil {
+					return nil, err
+				}
+				if json.Valid([]byte(args)) {
+					return &ApprovalDecision{Approved: true, Arguments: args}, nil
+				}
+				fmt.Fprintln(a.out, "not valid JSON")
+			}
+		}
+	}
+}
+
+func (a *TerminalApprover) ask(ctx context.Context, prompt string) (string, error) {
+	fmt.Fprint(a.out, prompt)
+	line, err := a.in.ReadLine(ctx)
+	if ctx.Err() != nil {
+		fmt.Fprintln(a.out)
+	}
+	return strings.TrimSpace(line), err
+}
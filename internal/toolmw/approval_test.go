@@ -0,0 +1,153 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package toolmw
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+)
+
+// streamUnitTool 是也能流式返回的 unitTool.
+type streamUnitTool struct {
+	unitTool
+}
+
+func (t *streamUnitTool) StreamableRun(ctx context.Context, args string, opts ...tool.Option) (*schema.StreamReader[string], error) {
+	out, err := t.InvokableRun(ctx, args, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return schema.StreamReaderFromArray([]string{out}), nil
+}
+
+func TestNilDecisionRejects(t *testing.T) {
+	ctx := context.Background()
+	a, err := NewApproval(&ApprovalConfig{
+		Policy: RequireTools("*"),
+		Approver: ApproverFunc(func(context.Context, *ApprovalRequest) (*ApprovalDecision, error) {
+			return nil, nil
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tl := &unitTool{unit: "kg"}
+	wrapped, err := a.Wrap(ctx, tl)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := wrapped.InvokableRun(ctx, `{}`)
+	if err != nil || !strings.Contains(out, "rejected") || tl.calls != 0 {
+		t.Errorf("got %q, %v and %d calls, want a rejection", out, err, tl.calls)
+	}
+}
+
+func TestStreamableApproval(t *testing.T) {
+	ctx := context.Background()
+	failed := errors.New("no terminal")
+	tests := []struct {
+		name     string
+		decision *ApprovalDecision
+		err      error
+	}{
+		{"rejected", &ApprovalDecision{Reason: "too expensive"}, nil},
+		{"approver error", nil, failed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := NewApproval(&ApprovalConfig{
+				Policy: RequireTools("*"),
+				Approver: ApproverFunc(func(context.Context, *ApprovalRequest) (*ApprovalDecision, error) {
+					return tt.decision, tt.err
+				}),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			tl := &streamUnitTool{unitTool{unit: "kg"}}
+			wrapped, err := a.Wrap(ctx, tl)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			sr, err := wrapped.(tool.StreamableTool).StreamableRun(ctx, `{}`)
+			if tt.err != nil {
+				// 出错时没有流
+				if sr != nil || !errors.Is(err, failed) {
+					t.Errorf("got %v, %v, want no stream and the error", sr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer sr.Close()
+			out, err := sr.Recv()
+			if err != nil || !strings.Contains(out, "too expensive") || tl.calls != 0 {
+				t.Errorf("got %q, %v and %d calls, want the rejection", out, err, tl.calls)
+			}
+			if _, err = sr.Recv(); !errors.Is(err, io.EOF) {
+				t.Errorf("got %v after the rejection, want the end of the stream", err)
+			}
+		})
+	}
+}
+
+func TestTerminalApproverCancel(t *testing.T) {
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	in := NewLineReader(pr)
+	approver := NewTerminalApprover(in, io.Discard)
+
+	// 没有人回答, 取消后立即返回
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	done := make(chan error, 1)
+	go func() {
+		_, err := approver.Approve(ctx, &ApprovalRequest{Tool: "weight", Arguments: `{}`})
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("got %v, want the cancellation", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Approve kept waiting for input after ctx was cancelled")
+	}
+
+	// 取消后输入的行交给下一次读取
+	go func() { _, _ = io.WriteString(pw, "y\n") }()
+	d, err := approver.Approve(context.Background(), &ApprovalRequest{Tool: "weight", Arguments: `{}`})
+	if err != nil || d == nil || !d.Approved {
+		t.Errorf("got %+v, %v, want approved", d, err)
+	}
+
+	_ = pw.Close()
+	if _, err = in.ReadLine(context.Background()); !errors.Is(err, io.EOF) {
+		t.Errorf("got %v after the input was closed, want io.EOF", err)
+	}
+}
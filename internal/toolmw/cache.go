@@ -0,0 +1,352 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package toolmw provides middlewares that wrap tool.InvokableTool. A tool that is
+// streamable too stays streamable, see Streamable.
+package toolmw
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+)
+
+const defaultCacheEntries = 256
+
+// CacheConfig is the config of a tool result cache.
+type CacheConfig struct {
+	// TTL of a cached result, 0 means results never expire.
+	TTL time.Duration
+	// MaxEntries bounds the cache size, the least recently used entry is evicted first.
+	// default 256.
+	MaxEntries int
+
+	// Defaults holds the default argument values per tool name, e.g. {"query_dishes": {"topn": 5}}.
+	// Missing, null or zero arguments are replaced by the default before building the key,
+	// so that calls which end up doing the same query share a cache entry.
+	Defaults map[string]map[string]any
+
+	// Version reports the version of the data behind the tools, e.g. tools.DatasetVersion.
+	// Entries cached under another version are treated as misses.
+	Version func() uint64
+
+	// OptionsKey renders the per call tool options into the key, for tools that don't
+	// implement OptionsKeyer. When it is nil, calls of such tools with options bypass the
+	// cache since their output can't be told apart.
+	OptionsKey func(opts []tool.Option) string
+}
+
+// OptionsKeyer is implemented by tools whose output depends on options given when they
+// were built, e.g. the restaurant tools. OptionsKey renders those options merged with the
+// per call opts; the cache keys the calls of such a tool with it, also when it is wrapped
+// by the other middlewares of this package.
+type OptionsKeyer interface {
+	OptionsKey(opts []tool.Option) string
+}
+
+// wrapper 由本包的中间件实现, 返回被包装的工具.
+type wrapper interface {
+	unwrap() tool.InvokableTool
+}
+
+// findKeyer 沿着本包的中间件找到实现了 OptionsKeyer 的工具.
+func findKeyer(t tool.InvokableTool) (OptionsKeyer, bool) {
+	for {
+		if k, ok := t.(OptionsKeyer); ok {
+			return k, true
+		}
+		w, ok := t.(wrapper)
+		if !ok {
+			return nil, false
+		}
+		t = w.unwrap()
+	}
+}
+
+// CacheStats is a snapshot of cache statistics.
+type CacheStats struct {
+	Hits      int64
+	Misses    int64
+	Bypassed  int64
+	Evictions int64
+	Entries   int
+}
+
+// Cache caches results of invokable tools, one Cache can be shared by many tools.
+type Cache struct {
+	conf CacheConfig
+	now  func() time.Time // 测试中替换的时钟
+
+	mu      sync.Mutex
+	lru     *list.List // front is the most recently used
+	entries map[string]*list.Element
+	stats   CacheStats
+}
+
+type cacheEntry struct {
+	key     string
+	result  string
+	version uint64
+	expire  time.Time
+}
+
+// NewCache creates a tool result cache.
+func NewCache(conf *CacheConfig) *Cache {
+	c := *conf
+	if c.MaxEntries <= 0 {
+		c.MaxEntries = defaultCacheEntries
+	}
+
+	return &Cache{
+		conf:    c,
+		now:     time.Now,
+		lru:     list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// Wrap returns t with its results cached. A streamed result is cached once it was read
+// to the end, a hit is streamed as a single chunk.
+func (c *Cache) Wrap(ctx context.Context, t tool.InvokableTool) (tool.InvokableTool, error) {
+	info, err := t.Info(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ct := &cachedTool{
+		InvokableTool: t,
+		name:          info.Name,
+		cache:         c,
+		optionsKey:    c.conf.OptionsKey,
+	}
+	if k, ok := findKeyer(t); ok {
+		ct.optionsKey = k.OptionsKey
+	}
+	if st, ok := t.(tool.StreamableTool); ok {
+		return Streamable(ct, func(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (*schema.StreamReader[string], error) {
+			return ct.streamableRun(ctx, st, argumentsInJSON, opts...)
+		}), nil
+	}
+	return ct, nil
+}
+
+// Stats returns a snapshot of the cache statistics.
+func (c *Cache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats
+	stats.Entries = c.lru.Len()
+	return stats
+}
+
+// Invalidate drops all cached results.
+func (c *Cache) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.lru.Init()
+	c.entries = make(map[string]*list.Element)
+}
+
+func (c *Cache) version() uint64 {
+	if c.conf.Version == nil {
+		return 0
+	}
+	return c.conf.Version()
+}
+
+func (c *Cache) get(key string) (string, bool) {
+	// the callback may take its own locks, don't call it under c.mu
+	version := c.version()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*cacheEntry)
+		if entry.version == version && (entry.expire.IsZero() || c.now().Before(entry.expire)) {
+			c.lru.MoveToFront(elem)
+			c.stats.Hits++
+			return entry.result, true
+		}
+
+		// stale or expired
+		c.lru.Remove(elem)
+		delete(c.entries, key)
+	}
+
+	c.stats.Misses++
+	return "", false
+}
+
+func (c *Cache) put(key, result string, version uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := &cacheEntry{
+		key:     key,
+		result:  result,
+		version: version,
+	}
+	if c.conf.TTL > 0 {
+		entry.expire = c.now().Add(c.conf.TTL)
+	}
+
+	if elem, ok := c.entries[key]; ok {
+		elem.Value = entry
+		c.lru.MoveToFront(elem)
+		return
+	}
+
+	c.entries[key] = c.lru.PushFront(entry)
+	for c.lru.Len() > c.conf.MaxEntries {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+		c.stats.Evictions++
+	}
+}
+
+func (c *Cache) bypass() {
+	c.mu.Lock()
+	c.stats.Bypassed++
+	c.mu.Unlock()
+}
+
+// canonicalKey builds the cache key from the tool name and the arguments with sorted keys and defaults applied.
+func (c *Cache) canonicalKey(name, argumentsInJSON string, optionsKey func([]tool.Option) string, opts []tool.Option) (string, error) {
+	args := make(map[string]any)
+	if err := json.Unmarshal([]byte(argumentsInJSON), &args); err != nil {
+		return "", err
+	}
+
+	for k, v := range c.conf.Defaults[name] {
+		if cur, ok := args[k]; !ok || isZero(cur) {
+			args[k] = v
+		}
+	}
+
+	// json.Marshal sorts map keys, which gives a canonical form
+	b, err := json.Marshal(args)
+	if err != nil {
+		return "", err
+	}
+
+	key := name + "\x00" + string(b)
+	if optionsKey != nil {
+		key += "\x00" + optionsKey(opts)
+	}
+	return key, nil
+}
+
+func isZero(v any) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case float64:
+		return v == 0
+	case string:
+		return v == ""
+	}
+	return false
+}
+
+type cachedTool struct {
+	tool.InvokableTool
+	name       string
+	cache      *Cache
+	optionsKey func(opts []tool.Option) string // 为 nil 时带 option 的调用不缓存
+}
+
+func (t *cachedTool) unwrap() tool.InvokableTool {
+	return t.InvokableTool
+}
+
+func (t *cachedTool) InvokableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (string, error) {
+	key, ok := t.key(argumentsInJSON, opts)
+	if !ok {
+		return t.InvokableTool.InvokableRun(ctx, argumentsInJSON, opts...)
+	}
+
+	if result, ok := t.cache.get(key); ok {
+		return result, nil
+	}
+
+	// take the version before running, so a reload during the call doesn't keep a stale result
+	version := t.cache.version()
+	result, err := t.InvokableTool.InvokableRun(ctx, argumentsInJSON, opts...)
+	if err != nil {
+		return "", err
+	}
+
+	if !reported(result) {
+		t.cache.put(key, result, version)
+	}
+	return result, nil
+}
+
+func (t *cachedTool) streamableRun(ctx context.Context, st tool.StreamableTool, argumentsInJSON string, opts ...tool.Option) (*schema.StreamReader[string], error) {
+	key, ok := t.key(argumentsInJSON, opts)
+	if !ok {
+		return st.StreamableRun(ctx, argumentsInJSON, opts...)
+	}
+
+	if result, ok := t.cache.get(key); ok {
+		return single(result), nil
+	}
+
+	version := t.cache.version()
+	sr, err := st.StreamableRun(ctx, argumentsInJSON, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	var b strings.Builder
+	return Relay(sr, func(chunk string) { b.WriteString(chunk) }, func(err error) (string, error) {
+		if err == nil && !reported(b.String()) {
+			t.cache.put(key, b.String(), version)
+		}
+		return "", err
+	}), nil
+}
+
+// reported 判断结果是否以报告给模型的错误结束, 例如 Limits 的超时和限流, 这样的结果不缓存.
+func reported(result string) bool {
+	return IsReportedError(result[strings.LastIndexByte(result, '\n')+1:])
+}
+
+// key 返回调用的缓存 key, 不能缓存的调用返回 false.
+func (t *cachedTool) key(argumentsInJSON string, opts []tool.Option) (string, bool) {
+	if len(opts) > 0 && t.optionsKey == nil {
+		t.cache.bypass()
+		return "", false
+	}
+
+	key, err := t.cache.canonicalKey(t.name, argumentsInJSON, t.optionsKey, opts)
+	if err != nil {
+		// let the tool report the malformed arguments itself
+		t.cache.bypass()
+		return "", false
+	}
+	return key, true
+}
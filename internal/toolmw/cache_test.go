@@ -0,0 +1,252 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package toolmw
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+)
+
+// unitTool 返回带单位的固定结果, 单位在构造时给定, 和 OptionsKey 一起决定输出.
+type unitTool struct {
+	unit  string
+	calls int
+}
+
+func (t *unitTool) Info(context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{Name: "weight"}, nil
+}
+
+func (t *unitTool) InvokableRun(context.Context, string, ...tool.Option) (string, error) {
+	t.calls++
+	return "1" + t.unit, nil
+}
+
+func (t *unitTool) OptionsKey([]tool.Option) string {
+	return t.unit
+}
+
+func TestCacheKeysConstructionOptions(t *testing.T) {
+	ctx := context.Background()
+	c := NewCache(&CacheConfig{})
+	limits := NewLimits(&LimitsConfig{})
+
+	kg, lb := &unitTool{unit: "kg"}, &unitTool{unit: "lb"}
+	// the keyer is found through the other middlewares too
+	limited, err := limits.Wrap(ctx, lb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, raw := range []tool.InvokableTool{kg, limited} {
+		wrapped, err := c.Wrap(ctx, raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for range 2 {
+			if _, err = wrapped.InvokableRun(ctx, `{}`); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	if kg.calls != 1 || lb.calls != 1 {
+		t.Errorf("calls kg %d lb %d, want one each", kg.calls, lb.calls)
+	}
+	if s := c.Stats(); s.Hits != 2 || s.Misses != 2 {
+		t.Errorf("stats = %+v, want 2 hits and 2 misses", s)
+	}
+}
+
+func TestCacheVersionOutsideLock(t *testing.T) {
+	ctx := context.Background()
+	var c *Cache
+	// Version 回调中读取统计, 在锁内调用会死锁
+	c = NewCache(&CacheConfig{Version: func() uint64 {
+		_ = c.Stats()
+		return 1
+	}})
+	wrapped, err := c.Wrap(ctx, &unitTool{unit: "kg"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range 2 {
+			_, _ = wrapped.InvokableRun(ctx, `{}`)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("the cache called Version while holding its lock")
+	}
+}
+
+func TestCacheSkipsReportedErrors(t *testing.T) {
+	ctx := context.Background()
+	c := NewCache(&CacheConfig{})
+	limits := NewLimits(&LimitsConfig{
+		Default:       LimitConfig{Timeout: 50 * time.Millisecond},
+		ReportToModel: true,
+	})
+	limited, err := limits.Wrap(ctx, blockingTool{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrapped, err := c.Wrap(ctx, limited)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for range 2 {
+		if out, err := wrapped.InvokableRun(ctx, `{}`); err != nil || !IsReportedError(out) {
+			t.Fatalf("got %q, %v, want a reported timeout", out, err)
+		}
+	}
+	if s := c.Stats(); s.Hits != 0 || s.Entries != 0 {
+		t.Errorf("stats = %+v, a reported timeout shouldn't be cached", s)
+	}
+}
+
+// run 调用 wrapped, 返回工具是否真的被调用.
+func run(t *testing.T, wrapped tool.InvokableTool, tl *unitTool, args string) bool {
+	t.Helper()
+	before := tl.calls
+	if _, err := wrapped.InvokableRun(context.Background(), args); err != nil {
+		t.Fatal(err)
+	}
+	return tl.calls > before
+}
+
+func TestCacheTTL(t *testing.T) {
+	c := NewCache(&CacheConfig{TTL: time.Minute})
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+	tl := &unitTool{unit: "kg"}
+	wrapped, err := c.Wrap(context.Background(), tl)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !run(t, wrapped, tl, `{}`) {
+		t.Fatal("the first call was served from the cache")
+	}
+	now = now.Add(59 * time.Second)
+	if run(t, wrapped, tl, `{}`) {
+		t.Error("the result expired before its TTL")
+	}
+	now = now.Add(time.Second)
+	if !run(t, wrapped, tl, `{}`) {
+		t.Error("the result was served after its TTL")
+	}
+	if s := c.Stats(); s.Hits != 1 || s.Misses != 2 || s.Entries != 1 {
+		t.Errorf("stats = %+v, want the expired entry replaced", s)
+	}
+}
+
+func TestCacheLRU(t *testing.T) {
+	c := NewCache(&CacheConfig{MaxEntries: 2})
+	tl := &unitTool{unit: "kg"}
+	wrapped, err := c.Wrap(context.Background(), tl)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	run(t, wrapped, tl, `{"id":"a"}`)
+	run(t, wrapped, tl, `{"id":"b"}`)
+	// a 最近用过, 加入 c 时淘汰 b
+	if run(t, wrapped, tl, `{"id":"a"}`) {
+		t.Fatal("a wasn't cached")
+	}
+	run(t, wrapped, tl, `{"id":"c"}`)
+
+	for _, tt := range []struct {
+		args   string
+		cached bool
+	}{{`{"id":"a"}`, true}, {`{"id":"c"}`, true}, {`{"id":"b"}`, false}} {
+		if called := run(t, wrapped, tl, tt.args); called == tt.cached {
+			t.Errorf("%s cached %v, want %v", tt.args, !called, tt.cached)
+		}
+	}
+	if s := c.Stats(); s.Evictions != 2 || s.Entries != 2 {
+		t.Errorf("stats = %+v, want 2 evictions and 2 entries", s)
+	}
+}
+
+func TestCacheVersion(t *testing.T) {
+	var version atomic.Uint64
+	c := NewCache(&CacheConfig{Version: version.Load})
+	tl := &unitTool{unit: "kg"}
+	wrapped, err := c.Wrap(context.Background(), tl)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	run(t, wrapped, tl, `{}`)
+	if run(t, wrapped, tl, `{}`) {
+		t.Fatal("the result wasn't cached")
+	}
+	version.Add(1)
+	if !run(t, wrapped, tl, `{}`) {
+		t.Error("a result of the old data version was served")
+	}
+	if run(t, wrapped, tl, `{}`) {
+		t.Error("the result of the new version wasn't cached")
+	}
+
+	c.Invalidate()
+	if !run(t, wrapped, tl, `{}`) {
+		t.Error("a result was served after Invalidate")
+	}
+}
+
+func TestCacheCanonicalKey(t *testing.T) {
+	c := NewCache(&CacheConfig{Defaults: map[string]map[string]any{"weight": {"topn": 5}}})
+	tl := &unitTool{unit: "kg"}
+	wrapped, err := c.Wrap(context.Background(), tl)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	run(t, wrapped, tl, `{"location":"Beijing","tag":"spicy"}`)
+	for _, args := range []string{
+		`{"tag":"spicy","location":"Beijing"}`,
+		`{ "location": "Beijing", "tag": "spicy" }`,
+		`{"location":"Beijing","tag":"spicy","topn":5}`,
+		`{"location":"Beijing","tag":"spicy","topn":0}`,
+		`{"location":"Beijing","tag":"spicy","topn":null}`,
+	} {
+		if run(t, wrapped, tl, args) {
+			t.Errorf("%s missed the cache", args)
+		}
+	}
+	for _, args := range []string{
+		`{"location":"Beijing","tag":"spicy","topn":6}`,
+		`{"location":"Shanghai","tag":"spicy"}`,
+	} {
+		if !run(t, wrapped, tl, args) {
+			t.Errorf("%s was served from the cache", args)
+		}
+	}
+}
@@ -0,0 +1,419 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package toolmw
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+)
+
+var (
+	// ErrToolTimeout is returned when a tool call exceeds its deadline.
+	ErrToolTimeout = errors.New("tool call timed out")
+	// ErrToolThrottled is returned when a tool call is rejected by the concurrency or rate limit.
+	ErrToolThrottled = errors.New("tool call throttled")
+)
+
+// ToolError wraps ErrToolTimeout or ErrToolThrottled with the tool name.
+type ToolError struct {
+	Tool string
+	Kind error
+	Err  error // the underlying cause, may be nil
+}
+
+func (e *ToolError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %v: %v", e.Tool, e.Kind, e.Err)
+	}
+	return fmt.Sprintf("%s: %v", e.Tool, e.Kind)
+}
+
+func (e *ToolError) Unwrap() []error {
+	if e.Err == nil {
+		return []error{e.Kind}
+	}
+	return []error{e.Kind, e.Err}
+}
+
+// LimitConfig limits calls of one tool.
+type LimitConfig struct {
+	// Timeout of one call, the effective deadline is the earlier of ctx's deadline and now + Timeout.
+	// 0 means only ctx's deadline applies.
+	Timeout time.Duration
+	// MaxInFlight bounds the concurrent calls of the tool, 0 means unlimited.
+	MaxInFlight int
+	// Rate is the number of calls allowed per second on average, 0 means unlimited.
+	Rate float64
+	// Burst is the token bucket size, default 1 when Rate is set.
+	Burst int
+	// MaxWait is how long a call may wait for an in-flight slot or a rate token before it is throttled.
+	// 0 means calls are throttled immediately.
+	MaxWait time.Duration
+}
+
+// LimitsConfig is the config of Limits.
+type LimitsConfig struct {
+	// Default applies to tools without an entry in PerTool.
+	Default LimitConfig
+	// PerTool overrides Default by tool name.
+	PerTool map[string]LimitConfig
+	// MaxInFlight bounds the concurrent calls across all wrapped tools, 0 means unlimited.
+	// Useful when the model emits many parallel tool calls at once.
+	MaxInFlight int
+
+	// ReportToModel turns timeout and throttled errors into a tool result, so the ReAct agent
+	// can tell the model what happened instead of failing the whole run.
+	ReportToModel bool
+}
+
+// Limits applies timeouts, concurrency limits and rate limits to tools.
+type Limits struct {
+	conf   LimitsConfig
+	global chan struct{}
+}
+
+// NewLimits creates Limits from conf.
+func NewLimits(conf *LimitsConfig) *Limits {
+	l := &Limits{conf: *conf}
+	if conf.MaxInFlight > 0 {
+		l.global = make(chan struct{}, conf.MaxInFlight)
+	}
+	return l
+}
+
+// Wrap returns t with the limits of its name applied.
+func (l *Limits) Wrap(ctx context.Context, t tool.InvokableTool) (tool.InvokableTool, error) {
+	info, err := t.Info(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	conf, ok := l.conf.PerTool[info.Name]
+	if !ok {
+		conf = l.conf.Default
+	}
+
+	lt := &limitedTool{
+		InvokableTool: t,
+		name:          info.Name,
+		conf:          conf,
+		limits:        l,
+	}
+	if conf.MaxInFlight > 0 {
+		lt.inFlight = make(chan struct{}, conf.MaxInFlight)
+	}
+	if conf.Rate > 0 {
+		lt.bucket = newTokenBucket(conf.Rate, conf.Burst)
+	}
+	if st, ok := t.(tool.StreamableTool); ok {
+		return Streamable(lt, func(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (*schema.StreamReader[string], error) {
+			return lt.streamableRun(ctx, st, argumentsInJSON, opts...)
+		}), nil
+	}
+	return lt, nil
+}
+
+// WrapAll wraps every invokable tool in tools, other tools are returned as they are.
+// It is meant for compose.ToolsNodeConfig.Tools.
+func (l *Limits) WrapAll(ctx context.Context, tools []tool.BaseTool) ([]tool.BaseTool, error) {
+	res := make([]tool.BaseTool, 0, len(tools))
+	for _, t := range tools {
+		it, ok := t.(tool.InvokableTool)
+		if !ok {
+			res = append(res, t)
+			continue
+		}
+
+		wrapped, err := l.Wrap(ctx, it)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, wrapped)
+	}
+	return res, nil
+}
+
+type limitedTool struct {
+	tool.InvokableTool
+	name   string
+	conf   LimitConfig
+	limits *Limits
+
+	inFlight chan struct{}
+	bucket   *tokenBucket
+}
+
+func (t *limitedTool) unwrap() tool.InvokableTool {
+	return t.InvokableTool
+}
+
+func (t *limitedTool) InvokableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (string, error) {
+	result, err := t.run(ctx, argumentsInJSON, opts...)
+	if err != nil {
+		if report, ok := t.report(err); ok {
+			return report, nil
+		}
+	}
+	return result, err
+}
+
+// report 在 ReportToModel 时把超时和限流的错误转为工具结果.
+func (t *limitedTool) report(err error) (string, bool) {
+	var te *ToolError
+	if t.limits.conf.ReportToModel && errors.As(err, &te) {
+		return reportError(te), true
+	}
+	return "", false
+}
+
+func (t *limitedTool) run(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (string, error) {
+	if t.conf.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, t.conf.Timeout)
+		defer cancel()
+	}
+
+	release, err := t.admit(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	type output struct {
+		result string
+		err    error
+	}
+	done := make(chan output, 1)
+	go func() {
+		// the slot is held until the tool returns, also when the call timed out before
+		defer release()
+		result, err := t.InvokableTool.InvokableRun(ctx, argumentsInJSON, opts...)
+		done <- output{result, err}
+	}()
+
+	select {
+	case out := <-done:
+		return out.result, t.timeout(out.err)
+	case <-ctx.Done():
+		return "", t.timeout(ctx.Err())
+	}
+}
+
+// streamableRun 的超时和并发槽位持续到流结束; 超时需要工具自己在 ctx 结束时停止输出.
+func (t *limitedTool) streamableRun(ctx context.Context, st tool.StreamableTool, argumentsInJSON string, opts ...tool.Option) (*schema.StreamReader[string], error) {
+	cancel := context.CancelFunc(func() {})
+	if t.conf.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, t.conf.Timeout)
+	}
+
+	release, err := t.admit(ctx)
+	if err == nil {
+		var sr *schema.StreamReader[string]
+		if sr, err = st.StreamableRun(ctx, argumentsInJSON, opts...); err == nil {
+			sent := false
+			return Relay(sr, func(string) { sent = true }, func(err error) (string, error) {
+				defer cancel()
+				defer release()
+				if err == nil || errors.Is(err, io.ErrClosedPipe) {
+					return "", err
+				}
+				err = t.timeout(err)
+				if report, ok := t.report(err); ok {
+					if sent {
+						// the partial result was already sent, the report follows on its own line
+						report = "\n" + report
+					}
+					return report, nil
+				}
+				return "", err
+			}), nil
+		}
+		release()
+	}
+	cancel()
+
+	err = t.timeout(err)
+	if report, ok := t.report(err); ok {
+		return single(report), nil
+	}
+	return nil, err
+}
+
+// admit 等待速率令牌和并发槽位.
+func (t *limitedTool) admit(ctx context.Context) (release func(), err error) {
+	if t.bucket != nil && !t.bucket.take(ctx, t.conf.MaxWait) {
+		if err = ctx.Err(); err != nil {
+			return nil, t.timeout(err)
+		}
+		return nil, &ToolError{Tool: t.name, Kind: ErrToolThrottled, Err: errors.New("rate limit exceeded")}
+	}
+	return t.acquire(ctx)
+}
+
+// timeout 把超过 deadline 的错误转为 ErrToolTimeout.
+func (t *limitedTool) timeout(err error) error {
+	var te *ToolError
+	if err == nil || errors.As(err, &te) || !errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	return &ToolError{Tool: t.name, Kind: ErrToolTimeout, Err: err}
+}
+
+// acquire takes an in-flight slot of the tool and of the global limit.
+func (t *limitedTool) acquire(ctx context.Context) (release func(), err error) {
+	var held []chan struct{}
+	release = func() {
+		for _, sem := range held {
+			<-sem
+		}
+	}
+
+	for _, sem := range []chan struct{}{t.inFlight, t.limits.global} {
+		if sem == nil {
+			continue
+		}
+		if !acquireSlot(ctx, sem, t.conf.MaxWait) {
+			release()
+			// reaching the deadline of the call while waiting is a timeout, not throttling
+			if err = ctx.Err(); err != nil {
+				return nil, t.timeout(err)
+			}
+			return nil, &ToolError{Tool: t.name, Kind: ErrToolThrottled, Err: errors.New("too many calls in flight")}
+		}
+		held = append(held, sem)
+	}
+
+	return release, nil
+}
+
+func acquireSlot(ctx context.Context, sem chan struct{}, maxWait time.Duration) bool {
+	select {
+	case sem <- struct{}{}:
+		return true
+	default:
+	}
+	if maxWait <= 0 {
+		return false
+	}
+
+	timer := time.NewTimer(maxWait)
+	defer timer.Stop()
+	select {
+	case sem <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// reportError renders the error as a tool result the model can read.
+func reportError(te *ToolError) string {
+	kind := "throttled"
+	hint := "the tool is busy, wait before calling it again or answer with what you have"
+	if errors.Is(te.Kind, ErrToolTimeout) {
+		kind = "timeout"
+		hint = "the tool took too long, try a narrower query or answer with what you have"
+	}
+
+	b, _ := json.Marshal(map[string]string{
+		"error":   kind,
+		"tool":    te.Tool,
+		"message": te.Error(),
+		"hint":    hint,
+	})
+	return string(b)
+}
+
+// IsReportedError reports whether result is an error reported to the model as a tool
+// result, the JSON object with error, tool, message and hint that the middlewares of this
+// package, the guard and the remote tools return instead of failing the run.
+func IsReportedError(result string) bool {
+	var r struct {
+		Error   string `json:"error"`
+		Tool    string `json:"tool"`
+		Message string `json:"message"`
+		Hint    string `json:"hint"`
+	}
+	if err := json.Unmarshal([]byte(result), &r); err != nil {
+		return false
+	}
+	return r.Error != "" && r.Tool != "" && r.Message != "" && r.Hint != ""
+}
+
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	if burst <= 0 {
+		burst = 1
+	}
+	return &tokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// take takes one token, waiting up to maxWait for it.
+func (b *tokenBucket) take(ctx context.Context, maxWait time.Duration) bool {
+	b.mu.Lock()
+	now := time.Now()
+	b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		b.mu.Unlock()
+		return true
+	}
+
+	wait := time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+	if wait > maxWait {
+		b.mu.Unlock()
+		return false
+	}
+	// reserve the token now, so waiting callers are served in order
+	b.tokens--
+	b.mu.Unlock()
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		b.mu.Lock()
+		b.tokens++
+		b.mu.Unlock()
+		return false
+	}
+}
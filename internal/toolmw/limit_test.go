@@ -0,0 +1,115 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package toolmw
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+)
+
+// blockingTool 阻塞到 ctx 结束.
+type blockingTool struct{}
+
+func (blockingTool) Info(context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{Name: "block"}, nil
+}
+
+func (blockingTool) InvokableRun(ctx context.Context, _ string, _ ...tool.Option) (string, error) {
+	<-ctx.Done()
+	return "", ctx.Err()
+}
+
+// stuckTool 不理会 ctx, 一直阻塞到 unblock 关闭.
+type stuckTool struct {
+	unblock chan struct{}
+}
+
+func (stuckTool) Info(context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{Name: "stuck"}, nil
+}
+
+func (t stuckTool) InvokableRun(context.Context, string, ...tool.Option) (string, error) {
+	<-t.unblock
+	return "done", nil
+}
+
+func TestSlotHeldUntilToolReturns(t *testing.T) {
+	ctx := context.Background()
+	limits := NewLimits(&LimitsConfig{Default: LimitConfig{
+		Timeout:     50 * time.Millisecond,
+		MaxInFlight: 1,
+	}})
+	st := stuckTool{unblock: make(chan struct{})}
+	wrapped, err := limits.Wrap(ctx, st)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = wrapped.InvokableRun(ctx, `{}`); !errors.Is(err, ErrToolTimeout) {
+		t.Fatalf("got %v, want a timeout", err)
+	}
+	// 超时返回后工具仍在运行, 槽位没有释放
+	if _, err = wrapped.InvokableRun(ctx, `{}`); !errors.Is(err, ErrToolThrottled) {
+		t.Fatalf("got %v while the timed out call still runs, want throttled", err)
+	}
+
+	close(st.unblock)
+	deadline := time.Now().Add(time.Second)
+	for {
+		out, err := wrapped.InvokableRun(ctx, `{}`)
+		if err == nil && out == "done" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("got %q, %v, the slot wasn't released after the tool returned", out, err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestDeadlineWhileWaitingIsTimeout(t *testing.T) {
+	ctx := context.Background()
+	limits := NewLimits(&LimitsConfig{Default: LimitConfig{
+		Timeout:     200 * time.Millisecond,
+		MaxInFlight: 1,
+		MaxWait:     time.Second,
+	}})
+	wrapped, err := limits.Wrap(ctx, blockingTool{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() { _, _ = wrapped.InvokableRun(ctx, `{}`) }()
+	time.Sleep(50 * time.Millisecond)
+
+	// 等待槽位时到了调用的 deadline, 早于 MaxWait
+	_, err = wrapped.InvokableRun(ctx, `{}`)
+	if !errors.Is(err, ErrToolTimeout) || errors.Is(err, ErrToolThrottled) {
+		t.Errorf("got %v, want a timeout", err)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err = wrapped.InvokableRun(cancelled, `{}`); !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want the cancellation", err)
+	}
+}
@@ -0,0 +1,95 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package toolmw
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+)
+
+// StreamFunc is the StreamableRun of a wrapped tool.
+type StreamFunc func(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (*schema.StreamReader[string], error)
+
+// Streamable returns t with run as its StreamableRun. Middlewares use it to keep a
+// streamable tool streamable, a ToolsNode then streams it when the agent streams.
+func Streamable(t tool.InvokableTool, run StreamFunc) tool.InvokableTool {
+	return &streamableTool{InvokableTool: t, run: run}
+}
+
+type streamableTool struct {
+	tool.InvokableTool
+	run StreamFunc
+}
+
+func (t *streamableTool) unwrap() tool.InvokableTool {
+	return t.InvokableTool
+}
+
+func (t *streamableTool) StreamableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (*schema.StreamReader[string], error) {
+	return t.run(ctx, argumentsInJSON, opts...)
+}
+
+// Relay forwards sr to the returned stream. onChunk, if not nil, sees every chunk. onEnd,
+// if not nil, is called once when sr ends: err is nil when sr was read to the end, the
+// error of sr, or io.ErrClosedPipe when the reader closed the returned stream early.
+// What onEnd returns is sent as the last chunk, unless both are empty.
+func Relay(sr *schema.StreamReader[string], onChunk func(chunk string), onEnd func(err error) (string, error)) *schema.StreamReader[string] {
+	out, w := schema.Pipe[string](1)
+
+	go func() {
+		defer w.Close()
+		defer sr.Close()
+
+		for {
+			chunk, err := sr.Recv()
+			if err != nil {
+				if errors.Is(err, io.EOF) {
+					err = nil
+				}
+				tail, tailErr := "", err
+				if onEnd != nil {
+					tail, tailErr = onEnd(err)
+				}
+				if tail != "" || tailErr != nil {
+					w.Send(tail, tailErr)
+				}
+				return
+			}
+
+			if onChunk != nil {
+				onChunk(chunk)
+			}
+			if closed := w.Send(chunk, nil); closed {
+				if onEnd != nil {
+					_, _ = onEnd(io.ErrClosedPipe)
+				}
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// single 只有一块内容的流, 用于缓存命中, 拒绝等不需要运行工具的结果.
+func single(s string) *schema.StreamReader[string] {
+	return schema.StreamReaderFromArray([]string{s})
+}
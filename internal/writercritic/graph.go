@@ -0,0 +1,90 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package writercritic builds the graph where a writer model writes a joke and revises it
+// on the feedback of a critic model, the output is the writer's last version.
+package writercritic
+
+import (
+	"context"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+)
+
+// DefaultRounds is the number of versions the writer writes.
+const DefaultRounds = 3
+
+// Node names, e.g. to tell the writer's and the critic's output apart in callbacks.
+const (
+	NodeWriter = "writer"
+	NodeCritic = "critic"
+)
+
+const (
+	writerPrompt = "you are a writer who writes jokes and revise it according to the critic's feedback. Prepend your joke with your name which is \"writer: \""
+	criticPrompt = "you are a critic who ONLY gives feedback about jokes, emphasizing on funniness. Prepend your feedback with your name which is \"critic: \""
+)
+
+type state struct {
+	currentRound int
+	msgs         []*schema.Message
+}
+
+// New compiles the graph. Both roles use llm, the writer writes rounds versions, 0 means
+// DefaultRounds.
+func New(ctx context.Context, llm model.ChatModel, rounds int) (compose.Runnable[[]*schema.Message, *schema.Message], error) {
+	if rounds <= 0 {
+		rounds = DefaultRounds
+	}
+
+	g := compose.NewGraph[[]*schema.Message, *schema.Message](compose.WithGenLocalState(func(ctx context.Context) *state { return &state{} }))
+	_ = g.AddChatModelNode(NodeWriter, llm, compose.WithStatePreHandler(func(ctx context.Context, input []*schema.Message, state *state) ([]*schema.Message, error) {
+		state.currentRound++
+		state.msgs = append(state.msgs, input...)
+		input = append([]*schema.Message{schema.SystemMessage(writerPrompt)}, state.msgs...)
+		return input, nil
+	}), compose.WithNodeName(NodeWriter))
+	_ = g.AddChatModelNode(NodeCritic, llm, compose.WithStatePreHandler(func(ctx context.Context, input []*schema.Message, state *state) ([]*schema.Message, error) {
+		state.msgs = append(state.msgs, input...)
+		input = append([]*schema.Message{schema.SystemMessage(criticPrompt)}, state.msgs...)
+		return input, nil
+	}), compose.WithNodeName(NodeCritic))
+	_ = g.AddLambdaNode("toList1", compose.ToList[*schema.Message]())
+	_ = g.AddLambdaNode("toList2", compose.ToList[*schema.Message]())
+
+	_ = g.AddEdge(compose.START, NodeWriter)
+	_ = g.AddBranch(NodeWriter, compose.NewStreamGraphBranch(func(ctx context.Context, input *schema.StreamReader[*schema.Message]) (string, error) {
+		input.Close()
+
+		var result string = "toList1"
+		err := compose.ProcessState(ctx, func(ctx context.Context, state *state) error {
+			if state.currentRound >= rounds {
+				result = compose.END
+			}
+
+			return nil
+		})
+
+		return result, err
+	}, map[string]bool{compose.END: true, "toList1": true}))
+	_ = g.AddEdge("toList1", NodeCritic)
+	_ = g.AddEdge(NodeCritic, "toList2")
+	_ = g.AddEdge("toList2", NodeWriter)
+
+	return g.Compile(ctx)
+}
@@ -0,0 +1,322 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino-ext/components/model/ollama"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/flow/agent/react"
+
+	"github.com/galihrivanto/eino-exp/internal/grounding"
+	"github.com/galihrivanto/eino-exp/internal/guard"
+	"github.com/galihrivanto/eino-exp/internal/history"
+	"github.com/galihrivanto/eino-exp/internal/mcp"
+	"github.com/galihrivanto/eino-exp/internal/session"
+	"github.com/galihrivanto/eino-exp/internal/structured"
+	"github.com/galihrivanto/eino-exp/internal/toolcall"
+	"github.com/galihrivanto/eino-exp/internal/toolmw"
+	"github.com/galihrivanto/eino-exp/react/tools"
+)
+
+// availableTools 可以通过 -tools 启用的工具. 它们也是流式工具, 流式模式下后端每返回一条记录就输出一条.
+var availableTools = map[string]func(svc tools.RestaurantService, opts ...tool.Option) tool.InvokableTool{
+	"query_restaurants": func(svc tools.RestaurantService, opts ...tool.Option) tool.InvokableTool { // 查询餐厅信息的工具
+		return tools.NewRestaurantStreamTool(svc, opts...)
+	},
+	"query_dishes": func(svc tools.RestaurantService, opts ...tool.Option) tool.InvokableTool { // 查询餐厅菜品信息的工具
+		return tools.NewDishStreamTool(svc, opts...)
+	},
+}
+
+func toolNamesAll() []string {
+	names := make([]string, 0, len(availableTools))
+	for name := range availableTools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// app 根据配置创建的模型, 工具和 agent.
+type app struct {
+	conf       *config
+	chatModel  model.ChatModel
+	persona    string
+	tools      []tool.BaseTool
+	cache      *toolmw.Cache
+	backend    tools.RestaurantService // 餐厅工具的后端, 各个工具共用以便 -chaos 的故障序列可以复现
+	agent      *react.Agent
+	historyMgr *history.Manager   // 为 nil 时不限制对话历史
+	approval   *toolmw.Approval   // 为 nil 时工具调用不需要确认
+	guard      *guard.Guard       // 每轮的工具调用和时间预算
+	stdin      *toolmw.LineReader // REPL 和工具调用确认共用的输入
+
+	recommend *structured.Output[tools.Recommendation] // -output recommendation 时不为 nil
+	grounding *grounding.Collector                     // -grounding 时收集工具结果
+
+	store session.Store
+	sess  *session.Session // 当前会话, 没有启用会话时为 nil
+
+	mcpClients  []*mcp.Client   // -mcp-config 中的 MCP server
+	remoteTools []tool.BaseTool // MCP server 和 -openapi 服务的工具, 不受 -tools 影响
+	remoteNames []string        // 这些工具的名字
+}
+
+func newApp(ctx context.Context, conf *config) (_ *app, err error) {
+	a := &app{
+		conf:  conf,
+		stdin: toolmw.NewLineReader(os.Stdin),
+		guard: guard.New(&guard.Config{
+			MaxToolCalls: conf.MaxToolCalls,
+			MaxDuration:  conf.MaxDuration,
+		}),
+	}
+	defer func() {
+		// release the session store and the MCP servers opened before the failure
+		if err != nil {
+			a.close()
+		}
+	}()
+
+	chatModel, err := ollama.NewChatModel(ctx, &ollama.ChatModelConfig{
+		BaseURL: conf.BaseURL, // Ollama 服务地址
+		Model:   conf.Model,   // 模型名称
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create ollama chat model failed: %w", err)
+	}
+	a.chatModel = chatModel
+	if formats := conf.toolCallFormats(); len(formats) > 0 {
+		// some models write tool calls into the content instead of returning them natively
+		a.chatModel = toolcall.WrapModel(chatModel, toolcall.NewParser(formats...))
+	}
+
+	if conf.HistoryTokens > 0 {
+		// the agent binds its tools to chatModel, summaries use a model without tools
+		summarizer, err := ollama.NewChatModel(ctx, &ollama.ChatModelConfig{
+			BaseURL: conf.BaseURL,
+			Model:   conf.Model,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("create ollama summary model failed: %w", err)
+		}
+		a.historyMgr, err = history.New(&history.Config{
+			Model:     summarizer,
+			MaxTokens: conf.HistoryTokens,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(conf.Approve) > 0 {
+		a.approval, err = toolmw.NewApproval(&toolmw.ApprovalConfig{
+			Policy:   toolmw.RequireTools(conf.Approve...),
+			Approver: toolmw.NewTerminalApprover(a.stdin, os.Stdout),
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if conf.Output == outputRecommendation {
+		if a.recommend, err = structured.New[tools.Recommendation](tools.RecommendationSchema); err != nil {
+			return nil, err
+		}
+	}
+
+	if conf.Grounding {
+		a.grounding = grounding.NewCollector()
+	}
+
+	// prepare persona (system prompt)
+	if a.persona, err = conf.persona(); err != nil {
+		return nil, err
+	}
+
+	if conf.Session != "" {
+		if err = a.openSession(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	if conf.MCPConfig != "" {
+		if err = a.loadMCPTools(ctx); err != nil {
+			return nil, err
+		}
+	}
+	if conf.OpenAPI != "" {
+		if err = a.loadOpenAPITools(ctx); err != nil {
+			return nil, err
+		}
+	}
+	if err = a.checkApprove(); err != nil {
+		return nil, err
+	}
+
+	if a.tools, err = a.buildTools(ctx); err != nil {
+		return nil, err
+	}
+
+	if a.agent, err = a.buildAgent(ctx); err != nil {
+		return nil, fmt.Errorf("failed to create agent: %w", err)
+	}
+
+	return a, nil
+}
+
+func (a *app) buildTools(ctx context.Context) ([]tool.BaseTool, error) {
+	// identical calls within and across turns are served from the cache
+	if a.cache == nil {
+		a.cache = toolmw.NewCache(&toolmw.CacheConfig{
+			TTL:      5 * time.Minute,
+			Defaults: tools.ArgumentDefaults(),
+			Version:  tools.DatasetVersion,
+		})
+	}
+
+	if a.backend == nil {
+		a.backend = a.conf.backend()
+	}
+
+	// bound slow tools and parallel tool calls, timeouts and throttling are reported back to the model
+	limits := toolmw.NewLimits(&toolmw.LimitsConfig{
+		Default: toolmw.LimitConfig{
+			Timeout:     10 * time.Second,
+			MaxInFlight: 2,
+			Rate:        5,
+			Burst:       5,
+			MaxWait:     2 * time.Second,
+		},
+		MaxInFlight:   4,
+		ReportToModel: true,
+	})
+
+	limited := make([]tool.BaseTool, 0, len(a.conf.Tools)+len(a.remoteTools))
+	for _, name := range a.conf.Tools {
+		t, err := limits.Wrap(ctx, availableTools[name](a.backend, a.conf.toolOptions()...))
+		if err != nil {
+			return nil, fmt.Errorf("wrap tool %s failed: %w", name, err)
+		}
+		// the cache is outside the limits, a hit takes neither a rate token nor a slot
+		if t, err = a.cache.Wrap(ctx, t); err != nil {
+			return nil, fmt.Errorf("wrap tool %s failed: %w", name, err)
+		}
+		limited = append(limited, t)
+	}
+	// remote tools may have side effects, they aren't cached
+	remote, err := limits.WrapAll(ctx, a.remoteTools)
+	if err != nil {
+		return nil, err
+	}
+	limited = append(limited, remote...)
+
+	// stop repeated calls and calls beyond the budget of the run
+	guarded, err := a.guard.WrapTools(ctx, limited)
+	if err != nil || a.approval == nil {
+		return guarded, err
+	}
+
+	// outermost, the time waiting for approval doesn't count against the tool timeout
+	return a.approval.WrapAll(ctx, guarded)
+}
+
+func (a *app) buildAgent(ctx context.Context) (*react.Agent, error) {
+	persona := a.persona
+	if a.recommend != nil {
+		persona += "\n" + a.recommend.Instruction()
+	}
+
+	modifier := react.NewPersonaModifier(persona)
+	if a.historyMgr != nil {
+		modifier = a.historyMgr.Modifier(modifier)
+	}
+
+	return react.NewAgent(ctx, &react.AgentConfig{
+		// once the run is stopped the model is made to answer with what it has
+		Model:   a.guard.WrapModel(a.chatModel),
+		MaxStep: a.guard.MaxStep(),
+		ToolsConfig: compose.ToolsNodeConfig{
+			Tools: a.tools,
+		},
+
+		MessageModifier: modifier,
+		// decide from the first chunks whether the model calls a tool, instead of
+		// reading the whole answer before the first token is printed
+		StreamToolCallChecker: toolcall.NewStreamChecker(&toolcall.CheckerConfig{
+			TextChars: a.conf.ToolCallTextChars,
+			Formats:   a.conf.toolCallFormats(),
+			Tools:     append(append([]string{}, a.conf.Tools...), a.remoteNames...),
+		}),
+	})
+}
+
+// addRemoteTools 加入外部服务的工具, 名字不能和其他工具重复.
+func (a *app) addRemoteTools(ctx context.Context, source string, list []tool.BaseTool) error {
+	for _, t := range list {
+		info, err := t.Info(ctx)
+		if err != nil {
+			return err
+		}
+		if _, ok := availableTools[info.Name]; ok || slices.Contains(a.remoteNames, info.Name) {
+			return fmt.Errorf("tool %s of %s clashes with another tool", info.Name, source)
+		}
+		a.remoteNames = append(a.remoteNames, info.Name)
+	}
+	a.remoteTools = append(a.remoteTools, list...)
+	return nil
+}
+
+// checkApprove 检查 -approve 中的工具都存在; 外部服务的工具要连接后才知道.
+func (a *app) checkApprove() error {
+	for _, name := range a.conf.Approve {
+		if _, ok := availableTools[name]; ok || name == "*" || slices.Contains(a.remoteNames, name) {
+			continue
+		}
+		return fmt.Errorf("unknown tool %q in -approve, available: %s", name, strings.Join(append(toolNamesAll(), a.remoteNames...), ","))
+	}
+	return nil
+}
+
+// rebuild 在 persona 或启用的工具变化后重新创建 agent, 失败时保留原来的 agent.
+func (a *app) rebuild(ctx context.Context) error {
+	enabled, err := a.buildTools(ctx)
+	if err != nil {
+		return err
+	}
+
+	old := a.tools
+	a.tools = enabled
+	ragent, err := a.buildAgent(ctx)
+	if err != nil {
+		a.tools = old
+		return fmt.Errorf("failed to create agent: %w", err)
+	}
+
+	a.agent = ragent
+	return nil
+}
@@ -0,0 +1,383 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino/components/tool"
+
+	"github.com/galihrivanto/eino-exp/internal/logs"
+	"github.com/galihrivanto/eino-exp/internal/toolcall"
+	"github.com/galihrivanto/eino-exp/react/tools"
+)
+
+// exit codes
+const (
+	exitOK    = 0 // 正常结束
+	exitError = 1 // 运行时错误, 如模型调用失败
+	exitUsage = 2 // 参数错误
+	exitSetup = 3 // 初始化失败, 如创建模型或 agent 失败
+)
+
+const (
+	modeGenerate = "generate"
+	modeStream   = "stream"
+	modeEvents   = "events"
+)
+
+const (
+	outputText           = "text"
+	outputRecommendation = "recommendation" // 按 JSON schema 校验的结构化推荐结果
+)
+
+const defaultPersona = `# Character:
+You are an assistant who helps users recommend restaurants and dishes. According to the needs of users, you can query restaurant information and recommend dishes, and query restaurant information and recommend dishes.
+`
+
+// config 命令行参数, 每个参数都可以用对应的环境变量设置, 命令行优先.
+type config struct {
+	BaseURL     string
+	Model       string
+	PersonaFile string
+	Question    string
+	Mode        string
+	Tools       []string
+	Verbose     bool
+	Interactive bool
+	Serve       string // HTTP 服务监听的地址, 为空时不启动服务
+	MCP         bool   // 通过 stdio 以 MCP server 的方式提供工具
+	MCPConfig   string // 外部 MCP server 的配置文件, 它们的工具加入 agent
+
+	OpenAPI        string // HTTP 服务的 OpenAPI 规范, 它的 operation 作为工具加入 agent
+	OpenAPIBaseURL string // 为空时使用规范中的第一个 server
+	OpenAPIToken   string // 规范中 security scheme 的凭证
+
+	Trace     bool   // 每次回答后打印模型和工具调用的耗时和 token 用量
+	TraceFile string // 每次回答的完整 trace 以 JSON 追加到这个文件, 为空时不写
+
+	HistoryTokens int      // 对话历史的 token 预算, 超出时摘要较早的对话, 0 表示不限制
+	Approve       []string // 调用前需要人工确认的工具, "*" 表示所有工具
+
+	Output        string
+	OutputRetries int // 结构化回答校验失败后重新提问的次数
+
+	Grounding        bool // 检查回答中的餐厅, 菜品和价格是否都来自工具结果
+	GroundingRetries int  // 回答中有工具没有返回的内容时重新回答的次数
+
+	MaxToolCalls int           // 每轮最多的工具调用次数, 0 表示不限制
+	MaxDuration  time.Duration // 每轮的时间预算, 0 表示不限制
+
+	ToolCallFormats   string // 从回答内容中解析工具调用的格式, 为空时根据模型选择, "none" 表示不解析
+	ToolCallTextChars int    // 回答开头出现多少个非空白字符后认为没有工具调用, 负数表示读完整个回答, 0 使用 CheckerConfig 的默认值
+
+	Chaos *tools.ChaosConfig // 不为 nil 时向餐厅工具的后端注入故障
+
+	ToolFormat    tools.Format // 餐厅工具结果的输出格式
+	ToolMaxChars  int          // 餐厅工具结果的最大字符数, 0 表示不限制
+	Currency      string       // 价格换算成的币种, 为空时只展示原币种
+	Locale        string       // 价格的格式化区域
+	ExchangeRates string       // 汇率表文件, 为空时使用内置的离线汇率
+
+	Session       string // 会话 ID, 为空时不保存对话
+	SessionStore  string
+	ListSessions  bool
+	DeleteSession string
+}
+
+func parseConfig(args []string) (*config, error) {
+	conf := &config{}
+	var toolNames, approve, chaos, toolFormat string
+	env := &envDefaults{}
+
+	fs := flag.NewFlagSet("react", flag.ContinueOnError)
+	fs.StringVar(&conf.BaseURL, "base-url", envOr("REACT_BASE_URL", "http://localhost:11434"), "ollama endpoint [$REACT_BASE_URL]")
+	fs.StringVar(&conf.Model, "model", envOr("REACT_MODEL", "qwen2:7b"), "model name [$REACT_MODEL]")
+	fs.StringVar(&conf.PersonaFile, "persona-file", envOr("REACT_PERSONA_FILE", ""), "file with the system prompt, the built-in persona is used when empty [$REACT_PERSONA_FILE]")
+	fs.StringVar(&conf.Question, "question", envOr("REACT_QUESTION", ""), "question to ask, - or empty reads it from stdin [$REACT_QUESTION]")
+	fs.StringVar(&conf.Mode, "mode", envOr("REACT_MODE", modeStream), "generate, stream, or events to also show the model turns and tool calls as they happen [$REACT_MODE]")
+	fs.StringVar(&conf.Output, "output", envOr("REACT_OUTPUT", outputText), "text, or recommendation for a JSON answer validated against the recommendation schema [$REACT_OUTPUT]")
+	fs.IntVar(&conf.OutputRetries, "output-retries", env.Int("REACT_OUTPUT_RETRIES", 2), "times to ask again when the recommendation doesn't match the schema [$REACT_OUTPUT_RETRIES]")
+	fs.BoolVar(&conf.Grounding, "grounding", env.Bool("REACT_GROUNDING"), "check that the restaurants, dishes and prices in the answer were returned by the tools and print a report [$REACT_GROUNDING]")
+	fs.IntVar(&conf.GroundingRetries, "grounding-retries", env.Int("REACT_GROUNDING_RETRIES", 1), "times to ask again when the answer cites data no tool returned, 0 only reports [$REACT_GROUNDING_RETRIES]")
+	fs.IntVar(&conf.MaxToolCalls, "max-tool-calls", env.Int("REACT_MAX_TOOL_CALLS", 8), "tool calls allowed per question before the model must answer, 0 is unlimited but the model still answers after 10 rounds [$REACT_MAX_TOOL_CALLS]")
+	fs.DurationVar(&conf.MaxDuration, "max-duration", env.Duration("REACT_MAX_DURATION", 2*time.Minute), "time per question before the model must answer, 0 is unlimited [$REACT_MAX_DURATION]")
+	fs.StringVar(&toolNames, "tools", envOr("REACT_TOOLS", strings.Join(toolNamesAll(), ",")), "comma separated tools to enable, empty disables all tools [$REACT_TOOLS]")
+	fs.BoolVar(&conf.Verbose, "verbose", env.Bool("REACT_VERBOSE"), "log callback input and output of every node [$REACT_VERBOSE]")
+	fs.BoolVar(&conf.Trace, "trace", env.Bool("REACT_TRACE"), "print the model turns and tool calls of every answer with their durations and token usage [$REACT_TRACE]")
+	fs.StringVar(&conf.TraceFile, "trace-file", envOr("REACT_TRACE_FILE", ""), "append the full trace of every answer as a JSON line to this file [$REACT_TRACE_FILE]")
+	fs.StringVar(&conf.Serve, "serve", envOr("REACT_SERVE", ""), "serve the agent over HTTP on this address, e.g. :8080, until interrupted [$REACT_SERVE]")
+	fs.BoolVar(&conf.MCP, "mcp", env.Bool("REACT_MCP"), "publish the enabled tools as an MCP server over stdin/stdout instead of running the agent [$REACT_MCP]")
+	fs.StringVar(&conf.MCPConfig, "mcp-config", envOr("REACT_MCP_CONFIG", ""), "JSON file of MCP servers whose tools are added to the agent as <server>__<tool> [$REACT_MCP_CONFIG]")
+	fs.StringVar(&conf.OpenAPI, "openapi", envOr("REACT_OPENAPI", ""), "OpenAPI 3 specification, a file or URL, whose operations are added to the agent as tools [$REACT_OPENAPI]")
+	fs.StringVar(&conf.OpenAPIBaseURL, "openapi-base-url", envOr("REACT_OPENAPI_BASE_URL", ""), "base URL of the -openapi service, default the first server of the specification [$REACT_OPENAPI_BASE_URL]")
+	fs.StringVar(&conf.OpenAPIToken, "openapi-token", envOr("REACT_OPENAPI_TOKEN", ""), "credential sent for the security schemes of the -openapi service: an API key, a bearer token or user:password for basic auth [$REACT_OPENAPI_TOKEN]")
+	fs.BoolVar(&conf.Interactive, "interactive", env.Bool("REACT_INTERACTIVE"), "start a multi-turn chat session, type /help for commands [$REACT_INTERACTIVE]")
+	fs.IntVar(&conf.HistoryTokens, "history-tokens", env.Int("REACT_HISTORY_TOKENS", 4096), "token budget of the history sent to the model, older turns are summarized beyond it, 0 disables [$REACT_HISTORY_TOKENS]")
+	fs.StringVar(&conf.ToolCallFormats, "tool-call-formats", envOr("REACT_TOOL_CALL_FORMATS", ""), "formats of tool calls written into the content by models without native tool calling: json, xml, function; empty picks them by model, none disables [$REACT_TOOL_CALL_FORMATS]")
+	fs.IntVar(&conf.ToolCallTextChars, "tool-call-text-chars", env.Int("REACT_TOOL_CALL_TEXT_CHARS", 0), "characters of plain text at the start of a streamed answer after which it is taken for an answer without tool calls, -1 reads the whole answer, which finds tool calls written after some text; 0 decides after the length of the longest tool call opener when tool calls are parsed from the content, and on the first character otherwise [$REACT_TOOL_CALL_TEXT_CHARS]")
+	fs.StringVar(&chaos, "chaos", envOr("REACT_CHAOS", ""), "inject faults into the backend of the restaurant tools, e.g. seed=7,error=0.1,timeout=0.05,empty=0.1,partial=0.1,corrupt=0.05,malformed=0.05,latency=50ms-300ms,item-latency=20ms [$REACT_CHAOS]")
+	fs.StringVar(&toolFormat, "tool-format", envOr("REACT_TOOL_FORMAT", string(tools.FormatJSON)), "output format of the restaurant tools: json, pretty_json, markdown or lines, the last two save context on long lists [$REACT_TOOL_FORMAT]")
+	fs.IntVar(&conf.ToolMaxChars, "tool-max-chars", env.Int("REACT_TOOL_MAX_CHARS", 0), "characters a restaurant tool result may take, records beyond it are replaced by an omitted notice, 0 is unlimited [$REACT_TOOL_MAX_CHARS]")
+	fs.StringVar(&conf.Currency, "currency", envOr("REACT_CURRENCY", ""), "currency the restaurant tools convert prices into, e.g. USD; empty shows the original currency only [$REACT_CURRENCY]")
+	fs.StringVar(&conf.Locale, "locale", envOr("REACT_LOCALE", "en-US"), "locale the restaurant tools format prices in, e.g. de-DE [$REACT_LOCALE]")
+	fs.StringVar(&conf.ExchangeRates, "exchange-rates", envOr("REACT_EXCHANGE_RATES", ""), "JSON file with the exchange rates used by -currency, the built-in offline table is used when empty [$REACT_EXCHANGE_RATES]")
+	fs.StringVar(&approve, "approve", envOr("REACT_APPROVE", ""), "comma separated tools whose calls must be approved on the terminal, * for all [$REACT_APPROVE]")
+	fs.StringVar(&conf.Session, "session", envOr("REACT_SESSION", ""), "session ID to resume or create, \"new\" creates one with a random ID [$REACT_SESSION]")
+	fs.StringVar(&conf.SessionStore, "session-store", envOr("REACT_SESSION_STORE", defaultSessionStore()), "where sessions are kept, file:<dir> or bolt:<file> [$REACT_SESSION_STORE]")
+	fs.BoolVar(&conf.ListSessions, "list-sessions", false, "list saved sessions and exit")
+	fs.StringVar(&conf.DeleteSession, "delete-session", "", "delete the session with this ID and exit")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: react [flags]\n\nAsk the restaurant agent a question.\n\nFlags:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(fs.Output(), "\nExit codes: %d ok, %d run failed, %d bad usage, %d setup failed\n",
+			exitOK, exitError, exitUsage, exitSetup)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if env.err != nil {
+		return nil, env.err
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected arguments: %v", fs.Args())
+	}
+
+	if conf.Mode != modeGenerate && conf.Mode != modeStream && conf.Mode != modeEvents {
+		return nil, fmt.Errorf("unknown mode %q, want %s, %s or %s", conf.Mode, modeGenerate, modeStream, modeEvents)
+	}
+
+	if conf.MCP && (conf.Serve != "" || conf.Interactive || approve != "" || conf.MCPConfig != "" || conf.OpenAPI != "") {
+		return nil, errors.New("-mcp can't be used with -serve, -interactive, -approve, -mcp-config or -openapi")
+	}
+
+	if conf.Serve != "" && (conf.Interactive || approve != "" || conf.Grounding || conf.Output != outputText) {
+		return nil, errors.New("-serve can't be used with -interactive, -approve, -grounding or -output recommendation")
+	}
+
+	if conf.Output != outputText && conf.Output != outputRecommendation {
+		return nil, fmt.Errorf("unknown output %q, want %s or %s", conf.Output, outputText, outputRecommendation)
+	}
+
+	if conf.ToolCallFormats != "" && conf.ToolCallFormats != "none" {
+		if _, err := toolcall.ParseFormats(conf.ToolCallFormats); err != nil {
+			return nil, err
+		}
+	}
+
+	if conf.MaxToolCalls < 0 || conf.MaxDuration < 0 {
+		return nil, errors.New("-max-tool-calls and -max-duration must not be negative")
+	}
+
+	if conf.HistoryTokens < 0 {
+		return nil, fmt.Errorf("-history-tokens must not be negative, got %d", conf.HistoryTokens)
+	}
+
+	var err error
+	if conf.ToolFormat, err = tools.ParseFormat(toolFormat); err != nil {
+		return nil, fmt.Errorf("-tool-format: %w", err)
+	}
+	if conf.ToolMaxChars < 0 {
+		return nil, fmt.Errorf("-tool-max-chars must not be negative, got %d", conf.ToolMaxChars)
+	}
+
+	if conf.ExchangeRates != "" {
+		if err = tools.LoadExchangeRates(conf.ExchangeRates); err != nil {
+			return nil, fmt.Errorf("-exchange-rates: %w", err)
+		}
+	}
+	if conf.Currency != "" {
+		conf.Currency = strings.ToUpper(conf.Currency)
+		// a currency without a rate fails every conversion
+		if !tools.HasExchangeRate(conf.Currency) {
+			return nil, fmt.Errorf("-currency: no exchange rate for %s, want one of %s", conf.Currency, strings.Join(tools.Currencies(), ", "))
+		}
+	}
+
+	if chaos != "" {
+		if conf.Chaos, err = tools.ParseChaos(chaos); err != nil {
+			return nil, fmt.Errorf("-chaos: %w", err)
+		}
+	}
+
+	for _, name := range strings.Split(toolNames, ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		if _, ok := availableTools[name]; !ok {
+			return nil, fmt.Errorf("unknown tool %q, available: %s", name, strings.Join(toolNamesAll(), ","))
+		}
+		conf.Tools = append(conf.Tools, name)
+	}
+
+	for _, name := range strings.Split(approve, ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		// tools of MCP servers and OpenAPI services are only known once loaded, see checkApprove
+		if _, ok := availableTools[name]; !ok && name != "*" && conf.MCPConfig == "" && conf.OpenAPI == "" {
+			return nil, fmt.Errorf("unknown tool %q in -approve, available: %s", name, strings.Join(toolNamesAll(), ","))
+		}
+		conf.Approve = append(conf.Approve, name)
+	}
+
+	return conf, nil
+}
+
+// toolCallFormats returns nil when parsing tool calls from the content is disabled.
+func (c *config) toolCallFormats() []toolcall.Format {
+	switch c.ToolCallFormats {
+	case "none":
+		return nil
+	case "":
+		return toolcall.FormatsFor(c.Model)
+	}
+	formats, _ := toolcall.ParseFormats(c.ToolCallFormats) // validated by parseConfig
+	return formats
+}
+
+// toolOptions returns the default options of the restaurant tools.
+func (c *config) toolOptions() []tool.Option {
+	return []tool.Option{
+		tools.WithFormat(c.ToolFormat),
+		tools.WithMaxChars(c.ToolMaxChars),
+		tools.WithCurrency(c.Currency),
+		tools.WithLocale(c.Locale),
+	}
+}
+
+// backend returns the service of the restaurant tools, with the -chaos faults injected.
+func (c *config) backend() tools.RestaurantService {
+	if c.Chaos == nil {
+		return tools.DefaultService()
+	}
+
+	chaos := *c.Chaos
+	chaos.OnFault = func(method string, fault tools.Fault) {
+		if fault != tools.FaultNone {
+			logs.Infof("chaos: %s fault in %s", fault, method)
+		}
+	}
+	return tools.NewChaosService(tools.DefaultService(), &chaos)
+}
+
+func (c *config) persona() (string, error) {
+	if c.PersonaFile == "" {
+		return defaultPersona, nil
+	}
+
+	b, err := os.ReadFile(c.PersonaFile)
+	if err != nil {
+		return "", fmt.Errorf("read persona file: %w", err)
+	}
+	return string(b), nil
+}
+
+// question returns the question from the flag, or stdin when it's empty or "-".
+func (c *config) question() (string, error) {
+	if c.Question != "" && c.Question != "-" {
+		return c.Question, nil
+	}
+
+	if c.Question == "" {
+		// don't block on an interactive terminal waiting for input nobody knows to type
+		if fi, err := os.Stdin.Stat(); err == nil && fi.Mode()&os.ModeCharDevice != 0 {
+			return "", errors.New("no question, use -question or pipe it through stdin")
+		}
+	}
+
+	b, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return "", fmt.Errorf("read question from stdin: %w", err)
+	}
+
+	q := strings.TrimSpace(string(b))
+	if q == "" {
+		return "", errors.New("empty question")
+	}
+	return q, nil
+}
+
+func envOr(key, def string) string {
+	if v, ok := os.LookupEnv(key); ok {
+		return v
+	}
+	return def
+}
+
+// envDefaults 读取作为参数默认值的环境变量, 未设置或为空时使用默认值;
+// 记住第一个无效的值, 解析参数后作为参数错误报告.
+type envDefaults struct {
+	err error
+}
+
+func (e *envDefaults) lookup(key string) (string, bool) {
+	v := os.Getenv(key)
+	return v, v != ""
+}
+
+func (e *envDefaults) fail(key, v, want string) {
+	if e.err == nil {
+		e.err = fmt.Errorf("invalid $%s %q, want %s", key, v, want)
+	}
+}
+
+func (e *envDefaults) Bool(key string) bool {
+	v, ok := e.lookup(key)
+	if !ok {
+		return false
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		e.fail(key, v, "true or false")
+	}
+	return b
+}
+
+func (e *envDefaults) Int(key string, def int) int {
+	v, ok := e.lookup(key)
+	if !ok {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		e.fail(key, v, "an integer")
+		return def
+	}
+	return n
+}
+
+func (e *envDefaults) Duration(key string, def time.Duration) time.Duration {
+	v, ok := e.lookup(key)
+	if !ok {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		e.fail(key, v, "a duration such as 30s or 2m")
+		return def
+	}
+	return d
+}
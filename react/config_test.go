@@ -0,0 +1,62 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("REACT_GROUNDING", "true")
+	t.Setenv("REACT_MAX_TOOL_CALLS", "3")
+	t.Setenv("REACT_MAX_DURATION", "45s")
+	t.Setenv("REACT_TRACE", "")
+
+	conf, err := parseConfig([]string{"-max-tool-calls", "5"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 命令行参数优先, 空的环境变量使用默认值
+	if !conf.Grounding || conf.MaxToolCalls != 5 || conf.MaxDuration != 45*time.Second || conf.Trace {
+		t.Errorf("got grounding %v, max tool calls %d, max duration %v, trace %v",
+			conf.Grounding, conf.MaxToolCalls, conf.MaxDuration, conf.Trace)
+	}
+}
+
+func TestConfigInvalidEnv(t *testing.T) {
+	for _, tt := range []struct {
+		key, value string
+	}{
+		{"REACT_VERBOSE", "yes please"},
+		{"REACT_HISTORY_TOKENS", "4k"},
+		{"REACT_MAX_DURATION", "90"},
+	} {
+		t.Run(tt.key, func(t *testing.T) {
+			t.Setenv(tt.key, tt.value)
+			_, err := parseConfig(nil)
+			if err == nil || !strings.Contains(err.Error(), "$"+tt.key) {
+				t.Fatalf("got %v, want an error naming $%s", err, tt.key)
+			}
+			if code := run(nil); code != exitUsage {
+				t.Errorf("run exited with %d, want %d", code, exitUsage)
+			}
+		})
+	}
+}
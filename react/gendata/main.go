@@ -0,0 +1,192 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// gendata 生成大规模的餐厅数据集, 用于测试工具在数据量大时的表现.
+//
+//	go run ./react/gendata -locations 50 -restaurants 200 -dishes 30 -seed 7 -out restaurants.json
+//
+// 生成的文件可以通过 tools.LoadDatabase 加载. 用它压测餐厅工具:
+//
+//	go test ./react/tools -run '^$' -bench . -dataset $PWD/restaurants.json
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/galihrivanto/eino-exp/internal/logs"
+)
+
+// 与 react/tools 中数据集文件的格式保持一致.
+type restaurant struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Desc     string `json:"desc"`
+	Place    string `json:"place"`
+	Score    int    `json:"score"`
+	Currency string `json:"currency"`
+	Dishes   []dish `json:"dishes"`
+}
+
+type dish struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Desc  string `json:"desc"`
+	Price int    `json:"price"`
+	Score int    `json:"score"`
+}
+
+type city struct {
+	Name     string
+	Currency string
+	PriceMul float64 // 相对 CNY 的价格倍数
+}
+
+var (
+	cities = []city{
+		{"Beijing", "CNY", 1}, {"Shanghai", "CNY", 1}, {"Chengdu", "CNY", 1}, {"Guangzhou", "CNY", 1},
+		{"Hangzhou", "CNY", 1}, {"Xi'an", "CNY", 1}, {"Hong Kong", "HKD", 1.1}, {"Singapore", "SGD", 0.19},
+		{"Tokyo", "JPY", 21}, {"Jakarta", "IDR", 2300}, {"Paris", "EUR", 0.13}, {"London", "GBP", 0.11},
+		{"New York", "USD", 0.14},
+	}
+	districts = []string{"Old Town", "Riverside", "Harbour", "University", "Station", "Lakeside", "Market", "Hill"}
+
+	nameAdjectives = []string{"Golden", "Red Lantern", "Jade", "Lucky", "Spicy", "Humble", "Cloud", "Bamboo", "Silver", "Drunken", "Old", "Happy", "Peach Blossom", "Dragon", "Twin Fish"}
+	nameNouns      = []string{"Kitchen", "Restaurant", "House", "Bistro", "Garden", "Pavilion", "Canteen", "Noodle Bar", "Dumpling House", "Hot Pot"}
+	cuisines       = []string{"Sichuan", "Cantonese", "Hunan", "Shandong", "Jiangsu", "Northeastern", "fusion", "home-style", "street food"}
+	ambiences      = []string{"cozy", "busy", "quiet", "family-friendly", "luxurious", "hidden", "modern", "traditional"}
+
+	methods     = []string{"Braised", "Stir-fried", "Steamed", "Twice-Cooked", "Crispy", "Spicy", "Sweet and Sour", "Dry-fried", "Smoked", "Cold"}
+	ingredients = []string{"Pork", "Beef", "Chicken", "Duck", "Fish", "Shrimp", "Tofu", "Eggplant", "Cabbage", "Noodles", "Dumplings", "Lamb", "Mushrooms", "Potatoes"}
+	flavors     = []string{"spicy", "numbing", "sweet", "sour", "savory", "smoky", "light", "garlicky", "rich"}
+	textures    = []string{"tender", "crispy", "silky", "chewy", "juicy", "crunchy"}
+)
+
+func main() {
+	var (
+		locations   = flag.Int("locations", 20, "number of locations (N)")
+		restaurants = flag.Int("restaurants", 50, "restaurants per location (M)")
+		dishes      = flag.Int("dishes", 20, "dishes per restaurant (K)")
+		seed        = flag.Int64("seed", 1, "random seed, the same seed always produces the same dataset")
+		out         = flag.String("out", "restaurants.json", "output file, - for stdout")
+	)
+	flag.Parse()
+
+	if *locations <= 0 || *restaurants <= 0 || *dishes <= 0 {
+		logs.Fatalf("locations, restaurants and dishes must be positive")
+	}
+
+	data := generate(rand.New(rand.NewSource(*seed)), *locations, *restaurants, *dishes)
+
+	b, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		logs.Fatalf("marshal dataset failed: %v", err)
+	}
+
+	if *out == "-" {
+		_, err = os.Stdout.Write(b)
+	} else {
+		err = os.WriteFile(*out, b, 0o644)
+	}
+	if err != nil {
+		logs.Fatalf("write dataset failed: %v", err)
+	}
+
+	if *out != "-" {
+		logs.Infof("generated %d locations x %d restaurants x %d dishes into %s",
+			*locations, *restaurants, *dishes, *out)
+	}
+}
+
+func generate(r *rand.Rand, locations, restaurants, dishes int) map[string][]restaurant {
+	data := make(map[string][]restaurant, locations)
+	nextID := 100001
+
+	for i := 0; i < locations; i++ {
+		c := cities[i%len(cities)]
+		location := c.Name
+		if round := i / len(cities); round > 0 {
+			location = fmt.Sprintf("%s %s", c.Name, districts[(round-1)%len(districts)])
+			if round > len(districts) {
+				location += " " + strconv.Itoa((round-1)/len(districts)+1)
+			}
+		}
+
+		rests := make([]restaurant, 0, restaurants)
+		for j := 0; j < restaurants; j++ {
+			rests = append(rests, genRestaurant(r, strconv.Itoa(nextID), location, c, dishes))
+			nextID++
+		}
+
+		// 工具按顺序取 topn, 因此按评分从高到低排序
+		sort.SliceStable(rests, func(a, b int) bool { return rests[a].Score > rests[b].Score })
+		data[location] = rests
+	}
+
+	return data
+}
+
+func genRestaurant(r *rand.Rand, id, location string, c city, dishes int) restaurant {
+	name := fmt.Sprintf("%s %s", pick(r, nameAdjectives), pick(r, nameNouns))
+	rest := restaurant{
+		ID:       id,
+		Name:     name,
+		Place:    location,
+		Desc:     fmt.Sprintf("A %s %s restaurant in %s, known for %s flavors", pick(r, ambiences), pick(r, cuisines), location, pick(r, flavors)),
+		Score:    score(r, 6, 2),
+		Currency: c.Currency,
+		Dishes:   make([]dish, 0, dishes),
+	}
+
+	seen := make(map[string]bool, dishes)
+	for k := 0; k < dishes; k++ {
+		name := fmt.Sprintf("%s %s", pick(r, methods), pick(r, ingredients))
+		if seen[name] {
+			name = fmt.Sprintf("%s %s No.%d", pick(r, flavors), name, k+1)
+		}
+		seen[name] = true
+
+		// 价格服从对数正态分布, 大多数菜在几十块钱, 少数很贵
+		price := math.Exp(r.NormFloat64()*0.6+3.4) * c.PriceMul
+		rest.Dishes = append(rest.Dishes, dish{
+			Name:  name,
+			Desc:  fmt.Sprintf("%s and %s, %s", pick(r, textures), pick(r, flavors), pick(r, []string{"a house favourite", "goes well with rice", "for sharing", "seasonal", "chef's recommendation"})),
+			Price: int(math.Max(1, math.Round(price))),
+			Score: score(r, 7, 1.8),
+		})
+	}
+	sort.SliceStable(rest.Dishes, func(a, b int) bool { return rest.Dishes[a].Score > rest.Dishes[b].Score })
+	for k := range rest.Dishes {
+		rest.Dishes[k].ID = fmt.Sprintf("%s-%d", id, k+1)
+	}
+
+	return rest
+}
+
+// score 生成 0 - 10 之间的正态分布评分.
+func score(r *rand.Rand, mean, stddev float64) int {
+	return int(math.Max(0, math.Min(10, math.Round(r.NormFloat64()*stddev+mean))))
+}
+
+func pick(r *rand.Rand, words []string) string {
+	return words[r.Intn(len(words))]
+}
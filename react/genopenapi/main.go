@@ -0,0 +1,114 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// genopenapi 根据 OpenAPI 3 规范生成调用 HTTP 服务的工具代码, 生成的代码运行时不需要规范文件.
+//
+//	go run ./react/genopenapi -spec menu.yaml -pkg menu -ops listMenus,getMenu -out menu/tools_gen.go
+//
+// 生成的包提供 Tools(conf) 和每个 operation 的 New<Operation>Tool(conf), 见 openapitool.Generate.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/galihrivanto/eino-exp/internal/logs"
+	"github.com/galihrivanto/eino-exp/internal/openapitool"
+)
+
+func main() {
+	if err := run(context.Background(), os.Args[1:], os.Stdout); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		logs.Fatalf("%v", err)
+	}
+}
+
+// run 解析参数并生成代码, 写到 -out 或 stdout.
+func run(ctx context.Context, args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("genopenapi", flag.ContinueOnError)
+	var (
+		spec = fs.String("spec", "", "OpenAPI 3 specification, a file or an http(s) URL, JSON or YAML")
+		pkg  = fs.String("pkg", "", "package of the generated file, default the name of the directory of -out")
+		ops  = fs.String("ops", "", "comma separated operations to generate, empty for all")
+		out  = fs.String("out", "-", "output file, - for stdout")
+	)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *spec == "" {
+		return errors.New("-spec is required")
+	}
+	if *pkg == "" {
+		if *out == "-" {
+			return errors.New("-pkg is required when writing to stdout")
+		}
+		*pkg = packageOf(*out)
+	}
+
+	doc, err := openapitool.Load(ctx, *spec)
+	if err != nil {
+		return err
+	}
+
+	var names []string
+	for _, n := range strings.Split(*ops, ",") {
+		if n = strings.TrimSpace(n); n != "" {
+			names = append(names, n)
+		}
+	}
+	operations, err := openapitool.Operations(doc, names...)
+	if err != nil {
+		return err
+	}
+
+	src, err := openapitool.Generate(&openapitool.GenerateConfig{
+		Package:    *pkg,
+		Source:     *spec,
+		ServerURL:  openapitool.ServerURL(doc, *spec),
+		Operations: operations,
+	})
+	if err != nil {
+		return err
+	}
+
+	if *out == "-" {
+		_, err = stdout.Write(src)
+		return err
+	}
+	if err = os.WriteFile(*out, src, 0o644); err != nil {
+		return fmt.Errorf("write %s failed: %w", *out, err)
+	}
+	logs.Infof("generated %d tools into %s", len(operations), *out)
+	return nil
+}
+
+// packageOf 输出文件所在目录的名字作为包名.
+func packageOf(out string) string {
+	dir, err := filepath.Abs(filepath.Dir(out))
+	if err != nil {
+		dir = filepath.Dir(out)
+	}
+	return strings.NewReplacer("-", "_", ".", "_").Replace(filepath.Base(dir))
+}
@@ -0,0 +1,78 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const spec = "../../internal/openapitool/testdata/menu.yaml"
+
+func TestRun(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("stdout", func(t *testing.T) {
+		var out bytes.Buffer
+		if err := run(ctx, []string{"-spec", spec, "-pkg", "menu", "-ops", "listDishes, createOrder"}, &out); err != nil {
+			t.Fatal(err)
+		}
+		src := out.String()
+		if !strings.Contains(src, "package menu\n") || !strings.Contains(src, "func NewListDishesTool(") ||
+			!strings.Contains(src, "func NewCreateOrderTool(") || strings.Contains(src, "NewAddReviewTool") {
+			t.Errorf("generated:\n%s", src)
+		}
+	})
+
+	t.Run("file", func(t *testing.T) {
+		// 包名取自输出目录
+		dir := filepath.Join(t.TempDir(), "menu-api")
+		if err := os.Mkdir(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		file := filepath.Join(dir, "tools_gen.go")
+		if err := run(ctx, []string{"-spec", spec, "-out", file}, nil); err != nil {
+			t.Fatal(err)
+		}
+		src, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Contains(src, []byte("package menu_api\n")) || !bytes.Contains(src, []byte("func NewAddReviewTool(")) {
+			t.Errorf("generated:\n%s", src)
+		}
+	})
+
+	errs := map[string][]string{
+		"no spec":           {"-pkg", "menu"},
+		"no package":        {"-spec", spec},
+		"unknown operation": {"-spec", spec, "-pkg", "menu", "-ops", "deleteDish"},
+		"missing spec":      {"-spec", "testdata/none.yaml", "-pkg", "menu"},
+		"unknown flag":      {"-format", "go"},
+	}
+	for name, args := range errs {
+		t.Run(name, func(t *testing.T) {
+			if err := run(ctx, args, &bytes.Buffer{}); err == nil {
+				t.Errorf("run(%q) succeeded", args)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/galihrivanto/eino-exp/internal/guard"
+	"github.com/galihrivanto/eino-exp/internal/logs"
+	"github.com/galihrivanto/eino-exp/internal/mcp"
+	"github.com/galihrivanto/eino-exp/internal/toolmw"
+)
+
+// serveMCP 通过 stdio 以 MCP server 的方式提供 -tools 启用的工具, 不需要模型.
+// stdout 用于协议, 日志写到 stderr.
+func serveMCP(ctx context.Context, conf *config) error {
+	logs.SetOutput(os.Stderr)
+
+	// the same cache and limits as for the agent; the guard has no run here and lets every call through
+	a := &app{conf: conf, guard: guard.New(&guard.Config{})}
+	tools, err := a.buildTools(ctx)
+	if err != nil {
+		return err
+	}
+
+	srv, err := mcp.NewServer(ctx, &mcp.ServerConfig{
+		Name:         "eino-exp-restaurants",
+		Instructions: "Look up restaurants by location with query_restaurants, then their dishes by restaurant id with query_dishes.",
+		Tools:        tools,
+		// timeouts, throttling and guard refusals are results for a model, for an MCP client they are failures
+		IsError: toolmw.IsReportedError,
+	})
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	logs.Infof("serving %d tools over MCP on stdio", len(tools))
+	return srv.Serve(ctx, os.Stdin, os.Stdout)
+}
+
+// loadMCPTools 连接 -mcp-config 中的每个 MCP server 并读取它们的工具.
+// server 之后断开时, 下一次调用会重新连接.
+func (a *app) loadMCPTools(ctx context.Context) error {
+	names, specs, err := mcp.LoadConfig(a.conf.MCPConfig)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		c, err := mcp.NewClient(name, specs[name])
+		if err != nil {
+			return err
+		}
+		a.mcpClients = append(a.mcpClients, c)
+
+		tools, err := mcp.Tools(ctx, c)
+		if err != nil {
+			return fmt.Errorf("list tools of mcp server %s: %w", name, err)
+		}
+		if err = a.addRemoteTools(ctx, "mcp server "+name, tools); err != nil {
+			return err
+		}
+		logs.Infof("mcp server %s: %d tools", name, len(tools))
+	}
+	return nil
+}
@@ -0,0 +1,226 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/galihrivanto/eino-exp/internal/mcp"
+)
+
+// 设置了这个环境变量时测试二进制作为 react 运行, 参数来自 REACT_* 环境变量.
+const subprocessEnv = "REACT_TEST_SUBPROCESS"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(subprocessEnv) == "1" {
+		os.Exit(run(nil))
+	}
+	os.Exit(m.Run())
+}
+
+// rpcMessage 客户端看到的 JSON-RPC 响应.
+type rpcMessage struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result"`
+	Error   *mcp.Error      `json:"error"`
+}
+
+// mcpProcess 以子进程运行的 react -mcp.
+type mcpProcess struct {
+	t     *testing.T
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+	lines chan string // stdout 的每一行, 结束时关闭
+}
+
+func startMCP(t *testing.T, env ...string) *mcpProcess {
+	t.Helper()
+	cmd := exec.Command(os.Args[0])
+	cmd.Env = append(os.Environ(), subprocessEnv+"=1", "REACT_MCP=1")
+	cmd.Env = append(cmd.Env, env...)
+	cmd.Stderr = os.Stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	p := &mcpProcess{t: t, cmd: cmd, stdin: stdin, lines: make(chan string)}
+	go func() {
+		defer close(p.lines)
+		sc := bufio.NewScanner(stdout)
+		for sc.Scan() {
+			p.lines <- sc.Text()
+		}
+	}()
+	t.Cleanup(func() {
+		_ = stdin.Close()
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	})
+	return p
+}
+
+func (p *mcpProcess) send(id int, method string, params any) {
+	p.t.Helper()
+	msg := map[string]any{"jsonrpc": "2.0", "method": method}
+	if id > 0 {
+		msg["id"] = id
+	}
+	if params != nil {
+		msg["params"] = params
+	}
+	b, _ := json.Marshal(msg)
+	if _, err := p.stdin.Write(append(b, '\n')); err != nil {
+		p.t.Fatalf("send %s: %v", method, err)
+	}
+}
+
+// next 读取下一个响应, stdout 中只能有 JSON-RPC 消息; stdout 结束时 ok 为 false.
+func (p *mcpProcess) next() (msg *rpcMessage, ok bool) {
+	p.t.Helper()
+	select {
+	case line, ok := <-p.lines:
+		if !ok {
+			return nil, false
+		}
+		msg = &rpcMessage{}
+		if err := json.Unmarshal([]byte(line), msg); err != nil || msg.JSONRPC != "2.0" {
+			p.t.Fatalf("stdout has a line that isn't JSON-RPC: %q", line)
+		}
+		return msg, true
+	case <-time.After(15 * time.Second):
+		p.t.Fatal("no response in 15s")
+		return nil, false
+	}
+}
+
+func (p *mcpProcess) result(id int, v any) {
+	p.t.Helper()
+	msg, ok := p.next()
+	if !ok {
+		p.t.Fatalf("server exited before answering %d", id)
+	}
+	if string(msg.ID) != fmt.Sprint(id) || msg.Error != nil {
+		p.t.Fatalf("want the result of %d, got id %s error %v", id, msg.ID, msg.Error)
+	}
+	if err := json.Unmarshal(msg.Result, v); err != nil {
+		p.t.Fatal(err)
+	}
+}
+
+func TestMCPServerProcess(t *testing.T) {
+	if testing.Short() {
+		t.Skip("starts react -mcp as a subprocess")
+	}
+	// 每条记录 4s: 同一个工具最多同时 2 个调用, 第三个等 2s 后被限流
+	p := startMCP(t, "REACT_CHAOS=item-latency=4s")
+
+	p.send(1, "initialize", map[string]any{
+		"protocolVersion": mcp.ProtocolVersion,
+		"capabilities":    map[string]any{},
+		"clientInfo":      map[string]any{"name": "test", "version": "1"},
+	})
+	var initRes mcp.InitializeResult
+	p.result(1, &initRes)
+	if initRes.ProtocolVersion != mcp.ProtocolVersion || initRes.ServerInfo.Name != "eino-exp-restaurants" {
+		t.Errorf("initialize = %+v", initRes)
+	}
+	p.send(0, "notifications/initialized", nil)
+
+	p.send(2, "tools/list", nil)
+	var list mcp.ListToolsResult
+	p.result(2, &list)
+	var names []string
+	for _, tl := range list.Tools {
+		names = append(names, tl.Name)
+	}
+	slices.Sort(names)
+	if !slices.Equal(names, []string{"query_dishes", "query_restaurants"}) {
+		t.Errorf("tools/list = %v", names)
+	}
+
+	// 参数错误: 结果是 isError, stdout 中没有多余的输出
+	p.send(3, "tools/call", map[string]any{"name": "query_dishes", "arguments": map[string]any{"restaurant_id": 1001}})
+	var bad mcp.CallToolResult
+	p.result(3, &bad)
+	if !bad.IsError {
+		t.Errorf("invalid arguments: want isError, got %+v", bad)
+	}
+
+	for id := 4; id <= 6; id++ {
+		p.send(id, "tools/call", map[string]any{"name": "query_restaurants", "arguments": map[string]any{"location": "beijing", "topn": id - 3}})
+	}
+
+	// 被限流的调用先返回, 限流报告给模型的结果对 MCP 客户端是 isError
+	msg, ok := p.next()
+	if !ok {
+		t.Fatal("server exited")
+	}
+	var throttled mcp.CallToolResult
+	if err := json.Unmarshal(msg.Result, &throttled); err != nil {
+		t.Fatal(err)
+	}
+	if !throttled.IsError || !strings.Contains(throttled.Text(), "throttled") {
+		t.Errorf("want a throttled result with isError, got %+v", throttled)
+	}
+
+	// 取消其余两个调用, 它们不再有响应
+	cancelled := map[string]bool{}
+	for _, id := range []string{"4", "5", "6"} {
+		if id != string(msg.ID) {
+			cancelled[id] = true
+			p.send(0, "notifications/cancelled", map[string]any{"requestId": json.RawMessage(id), "reason": "test"})
+		}
+	}
+
+	// stdin 结束后 server 等正在执行的调用结束再退出, 被取消的调用应该很快结束并且没有响应
+	start := time.Now()
+	_ = p.stdin.Close()
+	for {
+		msg, ok := p.next()
+		if !ok {
+			break
+		}
+		if cancelled[string(msg.ID)] {
+			t.Errorf("cancelled call %s was answered: %s", msg.ID, msg.Result)
+		}
+	}
+	if err := p.cmd.Wait(); err != nil {
+		t.Errorf("server exited with %v", err)
+	}
+	if d := time.Since(start); d > 3*time.Second {
+		t.Errorf("server took %s to exit, the cancelled calls kept running", d)
+	}
+}
@@ -0,0 +1,50 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+
+	"github.com/galihrivanto/eino-exp/internal/logs"
+	"github.com/galihrivanto/eino-exp/internal/openapitool"
+)
+
+// loadOpenAPITools 读取 -openapi 的规范, 每个 operation 作为一个调用该服务的工具.
+func (a *app) loadOpenAPITools(ctx context.Context) error {
+	doc, err := openapitool.Load(ctx, a.conf.OpenAPI)
+	if err != nil {
+		return err
+	}
+
+	conf := &openapitool.Config{BaseURL: a.conf.OpenAPIBaseURL}
+	if conf.BaseURL == "" {
+		conf.BaseURL = openapitool.ServerURL(doc, a.conf.OpenAPI)
+	}
+	if a.conf.OpenAPIToken != "" {
+		conf.Credentials = map[string]string{"*": a.conf.OpenAPIToken}
+	}
+
+	tools, err := openapitool.New(ctx, doc, conf)
+	if err != nil {
+		return err
+	}
+	if err = a.addRemoteTools(ctx, a.conf.OpenAPI, tools); err != nil {
+		return err
+	}
+	logs.Infof("openapi %s: %d tools calling %s", a.conf.OpenAPI, len(tools), conf.BaseURL)
+	return nil
+}
@@ -20,125 +20,287 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"flag"
 	"fmt"
 	"io"
-	"log"
+	"os"
+	"time"
 
-	"github.com/cloudwego/eino-ext/components/model/ollama"
 	"github.com/cloudwego/eino/callbacks"
-	"github.com/cloudwego/eino/components/tool"
 	"github.com/cloudwego/eino/compose"
 	"github.com/cloudwego/eino/flow/agent"
-	"github.com/cloudwego/eino/flow/agent/react"
 	"github.com/cloudwego/eino/schema"
 
+	"github.com/galihrivanto/eino-exp/internal/agentrun"
+	"github.com/galihrivanto/eino-exp/internal/grounding"
 	"github.com/galihrivanto/eino-exp/internal/logs"
 	"github.com/galihrivanto/eino-exp/react/tools"
 )
 
 func main() {
+	os.Exit(run(os.Args[1:]))
+}
 
-	ctx := context.Background()
-	chatModel, err := ollama.NewChatModel(ctx, &ollama.ChatModelConfig{
-		BaseURL: "http://localhost:11434", // Ollama 服务地址
-		Model:   "qwen2:7b",               // 模型名称
-	})
+func run(args []string) int {
+	conf, err := parseConfig(args)
+	if errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
 	if err != nil {
-		log.Fatalf("create ollama chat model failed: %v", err)
+		logs.Errorf("%v", err)
+		return exitUsage
 	}
 
-	// prepare tools
-	restaurantTool := tools.GetRestaurantTool() // 查询餐厅信息的工具
-	dishTool := tools.GetDishTool()             // 查询餐厅菜品信息的工具
+	ctx := context.Background()
+	if conf.ListSessions || conf.DeleteSession != "" {
+		if err = manageSessions(ctx, conf); err != nil {
+			logs.Errorf("%v", err)
+			return exitError
+		}
+		return exitOK
+	}
 
-	// prepare persona (system prompt) (optional)
-	persona := `# Character:
-You are an assistant who helps users recommend restaurants and dishes. According to the needs of users, you can query restaurant information and recommend dishes, and query restaurant information and recommend dishes.
-`
+	if conf.MCP {
+		if err = serveMCP(ctx, conf); err != nil {
+			logs.Errorf("%v", err)
+			return exitError
+		}
+		return exitOK
+	}
 
-	// replace tool call checker with a custom one: check all trunks until you get a tool call
-	// because some models(claude or doubao 1.5-pro 32k) do not return tool call in the first response
-	// uncomment the following code to enable it
-	toolCallChecker := func(ctx context.Context, sr *schema.StreamReader[*schema.Message]) (bool, error) {
-		defer sr.Close()
-		for {
-			msg, err := sr.Recv()
-			if err != nil {
-				if errors.Is(err, io.EOF) {
-					// finish
-					break
-				}
+	var question string
+	if !conf.Interactive && conf.Serve == "" {
+		if question, err = conf.question(); err != nil {
+			logs.Errorf("%v", err)
+			return exitUsage
+		}
+	}
 
-				return false, err
-			}
+	a, err := newApp(ctx, conf)
+	if err != nil {
+		logs.Errorf("%v", err)
+		return exitSetup
+	}
+	defer a.close()
 
-			if len(msg.ToolCalls) > 0 {
-				return true, nil
-			}
+	var opts []agent.AgentOption
+	if conf.Verbose {
+		opts = append(opts, agent.WithComposeOptions(compose.WithCallbacks(&LoggerCallback{})))
+	}
+
+	if conf.Serve != "" {
+		if err = a.serve(ctx, opts...); err != nil {
+			logs.Errorf("%v", err)
+			return exitError
 		}
-		return false, nil
+		return exitOK
 	}
 
-	ragent, err := react.NewAgent(ctx, &react.AgentConfig{
-		Model: chatModel,
-		ToolsConfig: compose.ToolsNodeConfig{
-			Tools: []tool.BaseTool{restaurantTool, dishTool},
-		},
+	if conf.Interactive {
+		if err = a.repl(ctx, opts...); err != nil {
+			logs.Errorf("%v", err)
+			return exitError
+		}
+		return exitOK
+	}
 
-		MessageModifier:       react.NewPersonaModifier(persona),
-		StreamToolCallChecker: toolCallChecker,
-	})
+	input := append(a.history(), schema.UserMessage(question))
+	answer, err := a.answer(ctx, input, opts...)
 	if err != nil {
-		logs.Errorf("failed to create agent: %v", err)
-		return
+		logs.Errorf("%v", err)
+		return exitError
+	}
+	if err = a.saveSession(ctx, append(input, answer)); err != nil {
+		logs.Errorf("%v", err)
+		return exitError
 	}
 
-	// if you want ping/pong, use Generate
-	// msg, err := agent.Generate(ctx, []*schema.Message{
-	// 	{
-	// 		Role:    schema.User,
-	// 		Content: "我在北京，给我推荐一些菜，需要有口味辣一点的菜，至少推荐有 2 家餐厅",
-	// 	},
-	// }, react.WithCallbacks(&myCallback{}))
-	// if err != nil {
-	// 	log.Printf("failed to generate: %v\n", err)
-	// 	return
-	// }
-	// fmt.Println(msg.String())
-
-	sr, err := ragent.Stream(ctx, []*schema.Message{
-		{
-			Role:    schema.User,
-			Content: "I am in Beijing, please recommend some dishes that are spicy, at least 2 restaurants",
-		},
-	}, agent.WithComposeOptions(compose.WithCallbacks(&LoggerCallback{})))
-	if err != nil {
-		logs.Errorf("failed to stream: %v", err)
-		return
+	if conf.Verbose {
+		logs.Infof("tool cache: %+v", a.cache.Stats())
 	}
+	return exitOK
+}
 
-	defer sr.Close() // remember to close the stream
+// answer 回答一轮; -grounding 时检查回答是否只引用了工具返回的数据, 否则把问题告诉模型重新回答.
+func (a *app) answer(ctx context.Context, input []*schema.Message, opts ...agent.AgentOption) (*schema.Message, error) {
+	// the budget covers the whole turn, retries included
+	ctx, run := a.guard.Start(ctx)
+	defer func() {
+		if reason := run.Stopped(); reason != "" {
+			calls, refused := run.ToolCalls()
+			logs.Infof("stopped early after %d tool calls (%d refused) in %s: %s", calls, refused, run.Elapsed().Round(time.Millisecond), reason)
+		}
+	}()
 
-	logs.Infof("\n\n===== start streaming =====\n\n")
+	if a.grounding == nil {
+		return a.answerOnce(ctx, input, opts...)
+	}
 
-	for {
-		msg, err := sr.Recv()
+	// the answer is checked against the tool results of this turn only
+	a.grounding.Reset()
+	opts = append(opts, agent.WithComposeOptions(compose.WithCallbacks(a.grounding.Handler())))
+	msgs := input
+	for attempt := 0; ; attempt++ {
+		answer, err := a.answerOnce(ctx, msgs, opts...)
 		if err != nil {
-			if errors.Is(err, io.EOF) {
-				// finish
-				break
-			}
-			// error
-			logs.Infof("failed to recv: %v", err)
-			return
+			return nil, err
+		}
+
+		report := grounding.Check(answer.Content, a.grounding.Results())
+		fmt.Printf("\n%s\n", report)
+		if report.Grounded() || attempt >= a.conf.GroundingRetries {
+			return answer, nil
 		}
 
+		logs.Errorf("the answer cites data no tool returned, asking again")
+		msgs = append(msgs[:len(msgs):len(msgs)], answer, report.Feedback())
+	}
+}
+
+// answerOnce 调用 agent 回答; -output recommendation 时校验结构化的回答, 不符合 schema 时重新提问.
+func (a *app) answerOnce(ctx context.Context, input []*schema.Message, opts ...agent.AgentOption) (*schema.Message, error) {
+	if a.recommend == nil {
+		return a.ask(ctx, input, opts...)
+	}
+
+	res, err := a.recommend.Run(ctx, input, a.conf.OutputRetries, func(ctx context.Context, input []*schema.Message) (*schema.Message, error) {
+		return a.ask(ctx, input, opts...)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	printRecommendation(res.Value)
+	return res.Answer, nil
+}
+
+// printRecommendation 打印校验后的推荐结果.
+func printRecommendation(rec *tools.Recommendation) {
+	fmt.Printf("\n%s\n", rec.Summary)
+	for _, rest := range rec.Restaurants {
+		fmt.Printf("\n* %s [%s]: %s\n", rest.Name, rest.ID, rest.Reason)
+		for _, dish := range rest.Dishes {
+			fmt.Printf("    - %s [%s] %v %s: %s\n", dish.Name, dish.ID, dish.Price, dish.Currency, dish.Reason)
+		}
+	}
+}
+
+// ask 调用 agent 并打印回答, 返回完整的回答消息.
+func (a *app) ask(ctx context.Context, input []*schema.Message, opts ...agent.AgentOption) (*schema.Message, error) {
+	var (
+		res *agentrun.Result
+		err error
+	)
+	switch a.conf.Mode {
+	case modeGenerate:
+		// ping/pong
+		if res, err = agentrun.Generate(ctx, a.agent, input, opts...); err == nil {
+			fmt.Println(res.Message.Content)
+		}
+	case modeEvents:
+		res, err = a.events(ctx, input, opts...)
+	default:
+		res, err = a.stream(ctx, input, opts...)
+	}
+
+	// the trace of a failed run shows how far it got
+	a.trace(res)
+	if err != nil {
+		return nil, err
+	}
+	return res.Message, nil
+}
+
+// stream 流式调用 agent, 逐字打印回答.
+func (a *app) stream(ctx context.Context, input []*schema.Message, opts ...agent.AgentOption) (*agentrun.Result, error) {
+	if a.conf.Verbose {
+		logs.Infof("\n\n===== start streaming =====\n\n")
+	}
+
+	res, err := agentrun.Stream(ctx, a.agent, input, func(msg *schema.Message) {
 		// 打字机打印
 		logs.Tokenf("%v", msg.Content)
+	}, opts...)
+	fmt.Println()
+	if err != nil {
+		return res, err
+	}
+
+	if a.conf.Verbose {
+		logs.Infof("\n\n===== finished =====\n")
+	}
+	return res, nil
+}
+
+// events 流式调用 agent, 打印回答的同时显示每一轮模型调用和工具调用.
+func (a *app) events(ctx context.Context, input []*schema.Message, opts ...agent.AgentOption) (*agentrun.Result, error) {
+	midLine := false // 上一个 token 之后还没有换行
+	newline := func() {
+		if midLine {
+			fmt.Println()
+			midLine = false
+		}
 	}
 
-	logs.Infof("\n\n===== finished =====\n")
+	for ev := range agentrun.Events(ctx, a.agent, input, opts...) {
+		switch ev.Type {
+		case agentrun.EventModelStart:
+			newline()
+			logs.Infof("model turn %d", ev.Turn)
+		case agentrun.EventToken:
+			logs.Tokenf("%v", ev.Content)
+			midLine = true
+		case agentrun.EventToolCallRequested:
+			newline()
+			logs.Infof("calling %s(%s)", ev.Tool, ev.Arguments)
+		case agentrun.EventToolResult:
+			logs.Infof("%s returned %d bytes", ev.Tool, len(ev.Result))
+		case agentrun.EventToolError:
+			logs.Errorf("%s failed: %s", ev.Tool, ev.Error)
+		case agentrun.EventFinal:
+			newline()
+			return ev.Trace, nil
+		case agentrun.EventError:
+			newline()
+			return ev.Trace, errors.New(ev.Error)
+		}
+	}
 
+	// the channel is only closed early when ctx is cancelled
+	return nil, ctx.Err()
+}
+
+// trace 打印 (-trace) 或者以 JSON 追加到文件 (-trace-file) 一次 agent 调用的 trace.
+func (a *app) trace(res *agentrun.Result) {
+	if res == nil {
+		return
+	}
+	if a.conf.Trace {
+		fmt.Printf("\n%s", res)
+	}
+	if a.conf.TraceFile == "" {
+		return
+	}
+
+	b, err := json.Marshal(res)
+	if err == nil {
+		err = appendLine(a.conf.TraceFile, b)
+	}
+	if err != nil {
+		logs.Errorf("failed to write trace: %v", err)
+	}
+}
+
+func appendLine(path string, line []byte) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
 }
 
 type LoggerCallback struct {
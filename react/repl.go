@@ -0,0 +1,229 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+
+	"github.com/cloudwego/eino/flow/agent"
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/galihrivanto/eino-exp/internal/logs"
+)
+
+const replHelp = `commands:
+  /reset            start a new conversation
+  /history          print the conversation so far
+  /save <file>      save the conversation as json
+  /persona [file]   print the persona, or load a new one from file
+  /tools [a,b]      print the enabled tools, or enable the given ones
+  /session          print the current session ID
+  /help             print this help
+  /exit             quit (Ctrl-D works too)
+Ctrl-C cancels the answer being generated without leaving the session.`
+
+// repl 多轮对话, 每轮的问题和回答都保存在 history 中, 作为下一轮的输入.
+type repl struct {
+	app     *app
+	opts    []agent.AgentOption
+	history []*schema.Message
+
+	mu     sync.Mutex
+	cancel context.CancelFunc // 取消正在生成的回答, 空闲时为 nil
+}
+
+func (a *app) repl(ctx context.Context, opts ...agent.AgentOption) error {
+	r := &repl{app: a, opts: opts, history: a.history()}
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt)
+	defer signal.Stop(sigCh)
+	go r.handleInterrupts(sigCh)
+
+	fmt.Println("restaurant agent, type /help for commands")
+	for {
+		fmt.Print("> ")
+		line, err := a.stdin.ReadLine(ctx)
+		if err != nil {
+			fmt.Println()
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "/") {
+			quit, err := r.command(ctx, line)
+			if err != nil {
+				logs.Errorf("%v", err)
+			}
+			if quit {
+				return nil
+			}
+			continue
+		}
+
+		r.turn(ctx, line)
+	}
+}
+
+func (r *repl) handleInterrupts(sigCh <-chan os.Signal) {
+	for range sigCh {
+		r.mu.Lock()
+		cancel := r.cancel
+		r.mu.Unlock()
+
+		if cancel != nil {
+			cancel()
+			continue
+		}
+		fmt.Print("\n(type /exit or press Ctrl-D to quit)\n> ")
+	}
+}
+
+// turn 问一轮, 被取消或失败时这轮问题不会留在 history 中.
+func (r *repl) turn(ctx context.Context, question string) {
+	turnCtx, cancel := context.WithCancel(ctx)
+	r.mu.Lock()
+	r.cancel = cancel
+	r.mu.Unlock()
+
+	defer func() {
+		r.mu.Lock()
+		r.cancel = nil
+		r.mu.Unlock()
+		cancel()
+	}()
+
+	input := append(r.history, schema.UserMessage(question))
+	answer, err := r.app.answer(turnCtx, input, r.opts...)
+	if err != nil {
+		if errors.Is(turnCtx.Err(), context.Canceled) {
+			fmt.Println("\n[cancelled]")
+			return
+		}
+		logs.Errorf("%v", err)
+		return
+	}
+
+	r.history = append(input, answer)
+	if err = r.app.saveSession(ctx, r.history); err != nil {
+		logs.Errorf("%v", err)
+	}
+}
+
+func (r *repl) command(ctx context.Context, line string) (quit bool, err error) {
+	name, arg, _ := strings.Cut(line, " ")
+	arg = strings.TrimSpace(arg)
+
+	switch name {
+	case "/exit", "/quit":
+		return true, nil
+	case "/help":
+		fmt.Println(replHelp)
+	case "/session":
+		if r.app.sess == nil {
+			fmt.Println("no session, start with -session <id> to keep the conversation")
+			return false, nil
+		}
+		fmt.Printf("session %s (%s)\n", r.app.sess.ID, r.app.conf.SessionStore)
+	case "/reset":
+		r.history = nil
+		if err = r.app.saveSession(ctx, nil); err != nil {
+			return false, err
+		}
+		fmt.Println("conversation cleared")
+	case "/history":
+		if len(r.history) == 0 {
+			fmt.Println("(empty)")
+		}
+		for _, msg := range r.history {
+			fmt.Printf("[%s] %s\n", msg.Role, msg.Content)
+		}
+	case "/save":
+		if arg == "" {
+			return false, errors.New("usage: /save <file>")
+		}
+		b, err := json.MarshalIndent(r.history, "", "  ")
+		if err != nil {
+			return false, err
+		}
+		if err = os.WriteFile(arg, b, 0o644); err != nil {
+			return false, err
+		}
+		fmt.Printf("saved %d messages to %s\n", len(r.history), arg)
+	case "/persona":
+		if arg == "" {
+			fmt.Println(r.app.persona)
+			return false, nil
+		}
+		persona, err := (&config{PersonaFile: arg}).persona()
+		if err != nil {
+			return false, err
+		}
+		old := r.app.persona
+		r.app.persona = persona
+		if err = r.app.rebuild(ctx); err != nil {
+			r.app.persona = old
+			return false, err
+		}
+		r.app.conf.PersonaFile = arg
+		if err = r.app.saveSession(ctx, r.history); err != nil {
+			return false, err
+		}
+		fmt.Printf("persona loaded from %s\n", arg)
+	case "/tools":
+		if arg == "" {
+			fmt.Printf("enabled: %s\navailable: %s\n", strings.Join(r.app.conf.Tools, ","), strings.Join(toolNamesAll(), ","))
+			return false, nil
+		}
+		var names []string
+		for _, n := range strings.Split(arg, ",") {
+			if n = strings.TrimSpace(n); n == "" {
+				continue
+			}
+			if _, ok := availableTools[n]; !ok {
+				return false, fmt.Errorf("unknown tool %q", n)
+			}
+			names = append(names, n)
+		}
+		old := r.app.conf.Tools
+		r.app.conf.Tools = names
+		if err = r.app.rebuild(ctx); err != nil {
+			r.app.conf.Tools = old
+			return false, err
+		}
+		fmt.Printf("enabled: %s\n", strings.Join(names, ","))
+	default:
+		return false, fmt.Errorf("unknown command %s, type /help", name)
+	}
+
+	return false, nil
+}
@@ -0,0 +1,126 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/cloudwego/eino-ext/components/model/ollama"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/flow/agent"
+
+	"github.com/galihrivanto/eino-exp/internal/chatserver"
+	"github.com/galihrivanto/eino-exp/internal/openai"
+	"github.com/galihrivanto/eino-exp/internal/session"
+	"github.com/galihrivanto/eino-exp/internal/writercritic"
+)
+
+// OpenAI 接口中 agent 和 graph 的模型名, ollama 模型用 -model 的名字.
+const (
+	modelReactAgent   = "react-agent"
+	modelWriterCritic = "writer-critic"
+)
+
+// serve 以 HTTP 服务运行 agent, 直到收到 SIGINT 或 SIGTERM.
+func (a *app) serve(ctx context.Context, opts ...agent.AgentOption) error {
+	if a.store == nil {
+		store, err := session.Open(a.conf.SessionStore)
+		if err != nil {
+			return err
+		}
+		a.store = store
+	}
+
+	begin := func(ctx context.Context) context.Context {
+		// every request has its own tool call and time budget
+		ctx, _ = a.guard.Start(ctx)
+		return ctx
+	}
+
+	v1, err := a.openAIHandler(ctx, begin, opts...)
+	if err != nil {
+		return err
+	}
+
+	srv, err := chatserver.New(&chatserver.Config{
+		Agent:    a.agent,
+		Sessions: a.store,
+		Model:    a.conf.Model,
+		Persona:  a.persona,
+		Begin:    begin,
+		Options:  opts,
+		Ready:    a.ollamaReady,
+		Routes:   map[string]http.Handler{"/v1/": v1},
+	})
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return srv.Serve(ctx, a.conf.Serve)
+}
+
+// openAIHandler 提供 OpenAI 兼容的 /v1 接口, model 字段选择 react agent, writer/critic graph
+// 或者直接调用 ollama 模型 (可以传入工具, 工具调用返回给客户端).
+func (a *app) openAIHandler(ctx context.Context, begin func(context.Context) context.Context, opts ...agent.AgentOption) (http.Handler, error) {
+	newModel := func(ctx context.Context) (model.ChatModel, error) {
+		return ollama.NewChatModel(ctx, &ollama.ChatModelConfig{
+			BaseURL: a.conf.BaseURL,
+			Model:   a.conf.Model,
+		})
+	}
+
+	// the graph binds no tools, one model serves all requests
+	llm, err := newModel(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("create ollama chat model failed: %w", err)
+	}
+	jokes, err := writercritic.New(ctx, llm, writercritic.DefaultRounds)
+	if err != nil {
+		return nil, err
+	}
+
+	return openai.NewHandler(
+		openai.Model{ID: modelReactAgent, Backend: openai.AgentBackend(a.agent, begin, opts...)},
+		openai.Model{ID: modelWriterCritic, Backend: openai.GraphBackend(jokes)},
+		openai.Model{ID: a.conf.Model, OwnedBy: "ollama", Backend: openai.ChatModelBackend(newModel)},
+	)
+}
+
+// ollamaReady 检查 ollama 服务是否可以访问.
+func (a *app) ollamaReady(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(a.conf.BaseURL, "/")+"/api/version", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("ollama is not reachable: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("ollama returned %s", resp.Status)
+	}
+	return nil
+}
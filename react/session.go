@@ -0,0 +1,133 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/galihrivanto/eino-exp/internal/logs"
+	"github.com/galihrivanto/eino-exp/internal/session"
+)
+
+func defaultSessionStore() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "file:.sessions"
+	}
+	return "file:" + filepath.Join(home, ".eino-exp", "sessions")
+}
+
+// openSession 加载 -session 指定的会话, 不存在时新建; "new" 表示新建一个随机 ID 的会话.
+func (a *app) openSession(ctx context.Context) error {
+	store, err := session.Open(a.conf.SessionStore)
+	if err != nil {
+		return err
+	}
+	a.store = store
+
+	id := a.conf.Session
+	if id == "new" {
+		id = session.NewID()
+	}
+
+	a.sess, err = store.Load(ctx, id)
+	if errors.Is(err, session.ErrNotFound) {
+		a.sess = &session.Session{ID: id}
+		err = nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// a resumed session keeps its persona unless another one is given
+	if a.conf.PersonaFile == "" && a.sess.Persona != "" {
+		a.persona = a.sess.Persona
+	}
+
+	logs.Infof("session %s, %d messages", a.sess.ID, len(a.sess.Messages))
+	return nil
+}
+
+// history returns the messages of the current session, nil without a session.
+func (a *app) history() []*schema.Message {
+	if a.sess == nil {
+		return nil
+	}
+	return a.sess.Messages
+}
+
+// saveSession 保存会话的消息, 没有启用会话时什么都不做.
+func (a *app) saveSession(ctx context.Context, msgs []*schema.Message) error {
+	if a.sess == nil {
+		return nil
+	}
+
+	a.sess.Model = a.conf.Model
+	a.sess.Persona = a.persona
+	a.sess.Messages = msgs
+	if err := a.store.Save(ctx, a.sess); err != nil {
+		return fmt.Errorf("save session %s: %w", a.sess.ID, err)
+	}
+	return nil
+}
+
+func (a *app) close() {
+	if a.store != nil {
+		_ = a.store.Close()
+	}
+	for _, c := range a.mcpClients {
+		_ = c.Close()
+	}
+}
+
+// manageSessions handles -list-sessions and -delete-session, which don't need the model.
+func manageSessions(ctx context.Context, conf *config) error {
+	store, err := session.Open(conf.SessionStore)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	if conf.DeleteSession != "" {
+		if err = store.Delete(ctx, conf.DeleteSession); err != nil {
+			return err
+		}
+		fmt.Printf("deleted session %s\n", conf.DeleteSession)
+		return nil
+	}
+
+	infos, err := store.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tMODEL\tMESSAGES\tCREATED\tUPDATED")
+	for _, info := range infos {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\n", info.ID, info.Model, info.MessageCount,
+			info.CreatedAt.Format(time.DateTime), info.UpdatedAt.Format(time.DateTime))
+	}
+	return w.Flush()
+}
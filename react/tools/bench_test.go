@@ -0,0 +1,96 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/cloudwego/eino/components/tool"
+)
+
+// go test ./react/tools -run '^$' -bench . -dataset restaurants.json
+var benchDataset = flag.String("dataset", "", "dataset generated by react/gendata for the benchmarks, one of the default size is generated when empty")
+
+var benchFormats = []Format{FormatJSON, FormatPrettyJSON, FormatMarkdown, FormatLines}
+
+// loadBenchDataset 加载 -dataset 的数据集, 没有指定时用 react/gendata 生成一个; 结束后恢复内置的数据.
+// 返回数据集中的地点和餐厅 ID.
+func loadBenchDataset(b *testing.B) (locations, restaurantIDs []string) {
+	b.Helper()
+	path := *benchDataset
+	if path == "" {
+		path = filepath.Join(b.TempDir(), "restaurants.json")
+		if out, err := exec.Command("go", "run", "../gendata", "-out", path).CombinedOutput(); err != nil {
+			b.Fatalf("generate dataset: %v\n%s", err, out)
+		}
+	}
+	if err := LoadDatabase(path); err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { database.load(getData()) })
+
+	database.mu.RLock()
+	defer database.mu.RUnlock()
+	locations = append(locations, database.locations...)
+	for id := range database.restaurantByID {
+		restaurantIDs = append(restaurantIDs, id)
+	}
+	sort.Strings(restaurantIDs)
+	return locations, restaurantIDs
+}
+
+// benchTool 对每种输出格式压测 t, args 返回第 i 次调用的参数.
+func benchTool(b *testing.B, t tool.InvokableTool, args func(i int) any, opts ...tool.Option) {
+	ctx := context.Background()
+	for _, format := range benchFormats {
+		b.Run(string(format), func(b *testing.B) {
+			opts := append([]tool.Option{WithFormat(format)}, opts...)
+			b.ReportAllocs()
+
+			size := 0
+			for i := 0; i < b.N; i++ {
+				in, _ := json.Marshal(args(i))
+				out, err := t.InvokableRun(ctx, string(in), opts...)
+				if err != nil {
+					b.Fatal(err)
+				}
+				size += len(out)
+			}
+			b.ReportMetric(float64(size)/float64(b.N), "bytes/result")
+		})
+	}
+}
+
+func BenchmarkQueryRestaurants(b *testing.B) {
+	locations, _ := loadBenchDataset(b)
+	benchTool(b, GetRestaurantTool(), func(i int) any {
+		return &QueryRestaurantsParam{Location: locations[i%len(locations)], Topn: 20}
+	})
+}
+
+func BenchmarkQueryDishes(b *testing.B) {
+	_, ids := loadBenchDataset(b)
+	benchTool(b, GetDishTool(), func(i int) any {
+		return &QueryDishesParam{RestaurantID: ids[i%len(ids)], Topn: 20}
+	}, WithCurrency("USD"))
+}
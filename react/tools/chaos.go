@@ -0,0 +1,390 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrInjected is returned by the chaos service when it injects an error.
+var ErrInjected = errors.New("chaos: injected backend error")
+
+// Fault 注入的故障类型.
+type Fault string
+
+const (
+	FaultNone    Fault = ""
+	FaultError   Fault = "error"   // 返回 ErrInjected
+	FaultTimeout Fault = "timeout" // 挂起直到 ctx 超时或 ChaosConfig.Timeout
+	FaultEmpty   Fault = "empty"   // 返回空结果
+	FaultPartial Fault = "partial" // 只返回部分结果
+	FaultCorrupt Fault = "corrupt" // 结果中的字段被破坏
+	// FaultMalformed 后端的响应不完整: 工具的输出在中途截断, 例如 JSON 缺少结尾
+	FaultMalformed Fault = "malformed"
+)
+
+// Latency 生成每次调用的延迟.
+type Latency func(r *rand.Rand) time.Duration
+
+// FixedLatency delays every call by d.
+func FixedLatency(d time.Duration) Latency {
+	return func(*rand.Rand) time.Duration { return d }
+}
+
+// UniformLatency delays calls uniformly between min and max.
+func UniformLatency(min, max time.Duration) Latency {
+	return func(r *rand.Rand) time.Duration {
+		if max <= min {
+			return min
+		}
+		return min + time.Duration(r.Int63n(int64(max-min)))
+	}
+}
+
+// NormalLatency delays calls following a normal distribution, negative samples are clamped to 0.
+func NormalLatency(mean, stddev time.Duration) Latency {
+	return func(r *rand.Rand) time.Duration {
+		return time.Duration(math.Max(0, r.NormFloat64()*float64(stddev)+float64(mean)))
+	}
+}
+
+// ExponentialLatency delays calls following an exponential distribution, which gives a long tail.
+func ExponentialLatency(mean time.Duration) Latency {
+	return func(r *rand.Rand) time.Duration {
+		return time.Duration(r.ExpFloat64() * float64(mean))
+	}
+}
+
+// ChaosConfig 故障注入的配置, 各个 Rate 为 0 - 1 之间的概率, 按 Error, Timeout, Empty, Partial, Corrupt, Malformed 的顺序判定,
+// 每次调用最多注入一种故障.
+type ChaosConfig struct {
+	// Seed 随机数种子, 相同的种子和相同的调用顺序会得到相同的故障序列, 便于复现.
+	// 每次调用的随机数由种子和调用的序号决定, 并行的调用之间互不影响.
+	Seed int64
+
+	Latency     Latency // 每次调用的延迟, 为空时不延迟
+	ItemLatency Latency // 每条记录之前的延迟, 模拟分页返回结果的慢后端, 为空时不延迟
+
+	ErrorRate     float64
+	TimeoutRate   float64
+	EmptyRate     float64
+	PartialRate   float64
+	CorruptRate   float64
+	MalformedRate float64
+
+	// Timeout 超时故障在 ctx 没有 deadline 时挂起的时长, 默认 30s.
+	Timeout time.Duration
+
+	// OnFault 每次调用后回调注入的故障, 可用于日志或测试断言.
+	OnFault func(method string, fault Fault)
+}
+
+// ParseChaos parses a comma separated chaos spec of key=value pairs into a config:
+// seed, the rates error, timeout, empty, partial, corrupt and malformed between 0 and 1,
+// and latency and item-latency as a duration, e.g. 50ms, or a uniform range, e.g. 50ms-200ms.
+//
+//	seed=7,error=0.1,malformed=0.05,latency=100ms-500ms
+func ParseChaos(spec string) (*ChaosConfig, error) {
+	conf := &ChaosConfig{}
+	rates := map[string]*float64{
+		"error":     &conf.ErrorRate,
+		"timeout":   &conf.TimeoutRate,
+		"empty":     &conf.EmptyRate,
+		"partial":   &conf.PartialRate,
+		"corrupt":   &conf.CorruptRate,
+		"malformed": &conf.MalformedRate,
+	}
+
+	var total float64
+	for _, kv := range strings.Split(spec, ",") {
+		if kv = strings.TrimSpace(kv); kv == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok {
+			return nil, fmt.Errorf("chaos: %q is not key=value", kv)
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+
+		var err error
+		switch key {
+		case "seed":
+			conf.Seed, err = strconv.ParseInt(value, 10, 64)
+		case "latency":
+			conf.Latency, err = parseLatency(value)
+		case "item-latency":
+			conf.ItemLatency, err = parseLatency(value)
+		default:
+			rate, found := rates[key]
+			if !found {
+				return nil, fmt.Errorf("chaos: unknown key %q", key)
+			}
+			if *rate, err = strconv.ParseFloat(value, 64); err == nil && (*rate < 0 || *rate > 1) {
+				err = errors.New("want a rate between 0 and 1")
+			}
+			total += *rate
+		}
+		if err != nil {
+			return nil, fmt.Errorf("chaos: %s: %w", key, err)
+		}
+	}
+
+	if total > 1 {
+		return nil, fmt.Errorf("chaos: the rates add up to %g, more than 1", total)
+	}
+	return conf, nil
+}
+
+// parseLatency 解析固定延迟 50ms 或均匀分布的延迟 50ms-200ms.
+func parseLatency(s string) (Latency, error) {
+	lo, hi, isRange := strings.Cut(s, "-")
+	min, err := time.ParseDuration(lo)
+	if err != nil {
+		return nil, err
+	}
+	if !isRange {
+		return FixedLatency(min), nil
+	}
+
+	max, err := time.ParseDuration(hi)
+	if err != nil {
+		return nil, err
+	}
+	if max < min {
+		return nil, fmt.Errorf("range %s is reversed", s)
+	}
+	return UniformLatency(min, max), nil
+}
+
+type chaosService struct {
+	inner RestaurantService
+	conf  ChaosConfig
+
+	mu    sync.Mutex
+	calls uint64 // 已开始的调用数
+}
+
+// NewChaosService wraps svc with a fault-injecting decorator.
+func NewChaosService(svc RestaurantService, conf *ChaosConfig) RestaurantService {
+	c := *conf
+	if c.Timeout <= 0 {
+		c.Timeout = 30 * time.Second
+	}
+
+	return &chaosService{inner: svc, conf: c}
+}
+
+// next 返回下一次调用的随机数, 由种子和调用的序号决定.
+func (cs *chaosService) next() *rand.Rand {
+	cs.mu.Lock()
+	n := cs.calls
+	cs.calls++
+	cs.mu.Unlock()
+
+	// splitmix64, 相邻的种子和序号得到不相关的序列
+	z := uint64(cs.conf.Seed) + (n+1)*0x9e3779b97f4a7c15
+	z = (z ^ z>>30) * 0xbf58476d1ce4e5b9
+	z = (z ^ z>>27) * 0x94d049bb133111eb
+	return rand.New(rand.NewSource(int64(z ^ z>>31)))
+}
+
+func (cs *chaosService) QueryRestaurants(ctx context.Context, in *QueryRestaurantsParam) iter.Seq2[Restaurant, error] {
+	return func(yield func(Restaurant, error) bool) {
+		rnd := cs.next()
+		fault, cut, err := cs.before(ctx, rnd, "QueryRestaurants")
+		if err != nil {
+			yield(Restaurant{}, err)
+			return
+		}
+
+		inject(ctx, cs.itemDelay(rnd), cs.inner.QueryRestaurants(ctx, in), fault, cut, in.Topn, func(i int, r *Restaurant) {
+			r.ID = ""
+			r.Name = fmt.Sprintf("\ufffd%x", i)
+			r.Score = -1
+		})(yield)
+	}
+}
+
+func (cs *chaosService) QueryDishes(ctx context.Context, in *QueryDishesParam) iter.Seq2[Dish, error] {
+	return func(yield func(Dish, error) bool) {
+		rnd := cs.next()
+		fault, cut, err := cs.before(ctx, rnd, "QueryDishes")
+		if err != nil {
+			yield(Dish{}, err)
+			return
+		}
+
+		inject(ctx, cs.itemDelay(rnd), cs.inner.QueryDishes(ctx, in), fault, cut, in.Topn, func(_ int, d *Dish) {
+			d.Name = ""
+			d.Price = -d.Price
+			d.Currency = "XXX"
+		})(yield)
+	}
+}
+
+// inject 在结果上注入故障: empty 不产出记录, partial 只产出前 cut 比例的记录, corrupt 破坏每条记录,
+// malformed 在记录之后产出 malformedPayload; 每条记录之前按 ItemLatency 延迟.
+func inject[T any](ctx context.Context, itemDelay func() time.Duration, seq iter.Seq2[T, error], fault Fault, cut float64, topn int, corrupt func(int, *T)) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		if fault == FaultEmpty {
+			return
+		}
+
+		keep := int(cut * float64(topn))
+
+		i := 0
+		for item, err := range seq {
+			if err != nil {
+				yield(item, err)
+				return
+			}
+			if fault == FaultPartial && i >= keep {
+				return
+			}
+			if err = sleep(ctx, itemDelay()); err != nil {
+				yield(item, err)
+				return
+			}
+			if fault == FaultCorrupt {
+				corrupt(i, &item)
+			}
+			if !yield(item, nil) {
+				return
+			}
+			i++
+		}
+
+		if fault == FaultMalformed {
+			var zero T
+			yield(zero, &malformedPayload{cut: cut})
+		}
+	}
+}
+
+// malformedPayload 由 malformed 故障在记录之后产出. 工具不把它当作错误, 而是输出在 cut 比例处截断的结果,
+// 就像后端的响应只传回了一部分.
+type malformedPayload struct {
+	cut float64
+}
+
+func (m *malformedPayload) Error() string {
+	return "chaos: malformed payload"
+}
+
+// isMalformed 报告 err 是否是 malformed 故障.
+func isMalformed(err error) bool {
+	var m *malformedPayload
+	return errors.As(err, &m)
+}
+
+// splitMalformed 把 malformed 故障从 err 中分离出来: 返回截断输出的函数和其余的错误.
+func splitMalformed(err error) (truncate func(string) string, rest error) {
+	var m *malformedPayload
+	if !errors.As(err, &m) {
+		return func(out string) string { return out }, err
+	}
+	return func(out string) string {
+		// 至少去掉最后一个字节, 让结果一定不完整
+		return out[:min(int(m.cut*float64(len(out))), max(len(out)-1, 0))]
+	}, nil
+}
+
+// itemDelay 返回每条记录之前的延迟, 用本次调用的随机数.
+func (cs *chaosService) itemDelay(rnd *rand.Rand) func() time.Duration {
+	return func() time.Duration {
+		if cs.conf.ItemLatency == nil {
+			return 0
+		}
+		return cs.conf.ItemLatency(rnd)
+	}
+}
+
+// before 抽取本次调用的故障并执行延迟, 返回需要在结果上注入的故障; cut 是 partial 故障保留结果的比例.
+func (cs *chaosService) before(ctx context.Context, rnd *rand.Rand, method string) (fault Fault, cut float64, err error) {
+	var delay time.Duration
+	if cs.conf.Latency != nil {
+		delay = cs.conf.Latency(rnd)
+	}
+	fault = cs.pickFault(rnd)
+	cut = rnd.Float64()
+
+	if cs.conf.OnFault != nil {
+		cs.conf.OnFault(method, fault)
+	}
+
+	if err = sleep(ctx, delay); err != nil {
+		return fault, cut, err
+	}
+
+	switch fault {
+	case FaultError:
+		return fault, cut, fmt.Errorf("%s: %w", method, ErrInjected)
+	case FaultTimeout:
+		timeoutCtx, cancel := context.WithTimeout(ctx, cs.conf.Timeout)
+		defer cancel()
+		<-timeoutCtx.Done()
+		return fault, cut, fmt.Errorf("%s: chaos timeout: %w", method, timeoutCtx.Err())
+	}
+
+	return fault, cut, nil
+}
+
+func (cs *chaosService) pickFault(rnd *rand.Rand) Fault {
+	p := rnd.Float64()
+	for _, f := range []struct {
+		fault Fault
+		rate  float64
+	}{
+		{FaultError, cs.conf.ErrorRate},
+		{FaultTimeout, cs.conf.TimeoutRate},
+		{FaultEmpty, cs.conf.EmptyRate},
+		{FaultPartial, cs.conf.PartialRate},
+		{FaultCorrupt, cs.conf.CorruptRate},
+		{FaultMalformed, cs.conf.MalformedRate},
+	} {
+		if p < f.rate {
+			return f.fault
+		}
+		p -= f.rate
+	}
+	return FaultNone
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
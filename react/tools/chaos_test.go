@@ -0,0 +1,182 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// faultSequence 用 seed 运行 n 次查询, 返回注入的故障序列.
+func faultSequence(t *testing.T, seed int64, n int) []Fault {
+	t.Helper()
+	var faults []Fault
+	svc := NewChaosService(DefaultService(), &ChaosConfig{
+		Seed:          seed,
+		ErrorRate:     0.15,
+		EmptyRate:     0.15,
+		PartialRate:   0.15,
+		CorruptRate:   0.15,
+		MalformedRate: 0.15,
+		OnFault:       func(_ string, f Fault) { faults = append(faults, f) },
+	})
+	restaurants, dishes := NewRestaurantTool(svc), NewDishTool(svc)
+
+	for i := 0; i < n; i++ {
+		// 注入的错误不影响故障序列, 这里只关心序列
+		if i%2 == 0 {
+			_, _ = restaurants.InvokableRun(context.Background(), `{"location":"beijing","topn":3}`)
+		} else {
+			_, _ = dishes.InvokableRun(context.Background(), `{"restaurant_id":"1001"}`)
+		}
+	}
+	return faults
+}
+
+func TestChaosSameSeedSameFaults(t *testing.T) {
+	const n = 50
+	first := faultSequence(t, 42, n)
+	if len(first) != n {
+		t.Fatalf("got %d faults, want %d", len(first), n)
+	}
+	if again := faultSequence(t, 42, n); !slices.Equal(first, again) {
+		t.Errorf("same seed gave different faults:\n%v\n%v", first, again)
+	}
+	if other := faultSequence(t, 43, n); slices.Equal(first, other) {
+		t.Errorf("seeds 42 and 43 gave the same faults: %v", first)
+	}
+
+	kinds := map[Fault]bool{}
+	for _, f := range first {
+		kinds[f] = true
+	}
+	for _, f := range []Fault{FaultNone, FaultError, FaultEmpty, FaultPartial, FaultCorrupt, FaultMalformed} {
+		if !kinds[f] {
+			t.Errorf("no %q fault in %d calls: %v", f, n, first)
+		}
+	}
+}
+
+// outcomes 用 seed 运行 n 次查询, parallel 时同时运行, 返回排好序的每次调用的结果.
+func outcomes(t *testing.T, seed int64, n int, parallel bool) []string {
+	t.Helper()
+	svc := NewChaosService(DefaultService(), &ChaosConfig{
+		Seed:          seed,
+		ErrorRate:     0.15,
+		EmptyRate:     0.15,
+		PartialRate:   0.15,
+		CorruptRate:   0.15,
+		MalformedRate: 0.15,
+		// 每条记录的延迟也要抽取随机数
+		ItemLatency: UniformLatency(time.Microsecond, 20*time.Microsecond),
+	})
+
+	res := make([]string, n)
+	call := func(i int) {
+		var items, corrupt int
+		var last error
+		for d, err := range svc.QueryDishes(context.Background(), &QueryDishesParam{RestaurantID: "1001", Topn: 5}) {
+			if err != nil {
+				last = err
+				break
+			}
+			items++
+			if d.Currency == "XXX" {
+				corrupt++
+			}
+		}
+		res[i] = fmt.Sprintf("%d items, %d corrupt, %v", items, corrupt, last)
+	}
+
+	var wg sync.WaitGroup
+	for i := range n {
+		if !parallel {
+			call(i)
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			call(i)
+		}()
+	}
+	wg.Wait()
+	slices.Sort(res)
+	return res
+}
+
+func TestChaosParallelSameFaults(t *testing.T) {
+	const n = 40
+	want := outcomes(t, 7, n, false)
+	for range 3 {
+		if got := outcomes(t, 7, n, true); !slices.Equal(got, want) {
+			t.Fatalf("parallel calls changed the faults of seed 7:\n%q\n%q", got, want)
+		}
+	}
+}
+
+func TestChaosMalformed(t *testing.T) {
+	const args = `{"location":"beijing","topn":3}`
+	svc := NewChaosService(DefaultService(), &ChaosConfig{MalformedRate: 1})
+
+	want, err := GetRestaurantTool().InvokableRun(context.Background(), args)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := NewRestaurantTool(svc).InvokableRun(context.Background(), args)
+	if err != nil {
+		t.Fatalf("malformed output should not be an error: %v", err)
+	}
+	if json.Valid([]byte(got)) || !strings.HasPrefix(want, got) {
+		t.Errorf("want a truncated prefix of\n%s\ngot\n%s", want, got)
+	}
+
+	streamed := strings.Join(readAll(t, NewRestaurantStreamTool(svc), args), "")
+	if json.Valid([]byte(streamed)) || !strings.HasPrefix(want, streamed) {
+		t.Errorf("want the stream to end without the closing bracket of\n%s\ngot\n%s", want, streamed)
+	}
+}
+
+func TestParseChaos(t *testing.T) {
+	conf, err := ParseChaos(" seed=7, error=0.1,malformed=0.2,latency=10ms-20ms,item-latency=5ms ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conf.Seed != 7 || conf.ErrorRate != 0.1 || conf.MalformedRate != 0.2 {
+		t.Errorf("got %+v", conf)
+	}
+	if d := conf.Latency(rand.New(rand.NewSource(1))); d < 10*time.Millisecond || d >= 20*time.Millisecond {
+		t.Errorf("latency %s out of range", d)
+	}
+	if d := conf.ItemLatency(nil); d != 5*time.Millisecond {
+		t.Errorf("item latency = %s, want 5ms", d)
+	}
+
+	for _, spec := range []string{"error", "error=2", "unknown=1", "latency=fast", "latency=2s-1s", "error=0.6,empty=0.6"} {
+		if _, err := ParseChaos(spec); err == nil {
+			t.Errorf("ParseChaos(%q) should fail", spec)
+		}
+	}
+}
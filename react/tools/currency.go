@@ -0,0 +1,225 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tools
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	defaultCurrency = "CNY"
+	defaultLocale   = "en-US"
+)
+
+// 离线汇率表, 可以通过 LoadExchangeRates 替换.
+//
+//go:embed rates.json
+var defaultRates []byte
+
+// ExchangeRates is an offline exchange-rate table, every rate is the amount of
+// that currency equal to one unit of Base.
+type ExchangeRates struct {
+	Base    string             `json:"base"`
+	Updated string             `json:"updated"`
+	Rates   map[string]float64 `json:"rates"`
+}
+
+var (
+	ratesMu sync.RWMutex
+	rates   *ExchangeRates
+)
+
+func init() {
+	r, err := parseExchangeRates(defaultRates)
+	if err != nil {
+		panic(fmt.Sprintf("invalid embedded exchange rates: %v", err))
+	}
+	rates = r
+}
+
+// LoadExchangeRates replaces the exchange-rate table with the one in the given file.
+func LoadExchangeRates(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	r, err := parseExchangeRates(data)
+	if err != nil {
+		return fmt.Errorf("load exchange rates from %s: %w", path, err)
+	}
+
+	ratesMu.Lock()
+	rates = r
+	ratesMu.Unlock()
+	return nil
+}
+
+func parseExchangeRates(data []byte) (*ExchangeRates, error) {
+	r := &ExchangeRates{}
+	if err := json.Unmarshal(data, r); err != nil {
+		return nil, err
+	}
+
+	r.Base = strings.ToUpper(r.Base)
+	normalized := make(map[string]float64, len(r.Rates))
+	for code, rate := range r.Rates {
+		if rate <= 0 {
+			return nil, fmt.Errorf("rate of %s must be positive", code)
+		}
+		normalized[strings.ToUpper(code)] = rate
+	}
+	r.Rates = normalized
+
+	if _, ok := r.Rates[r.Base]; !ok {
+		r.Rates[r.Base] = 1
+	}
+	return r, nil
+}
+
+// ConvertAmount converts amount from one currency to another using the loaded table.
+func ConvertAmount(amount float64, from, to string) (float64, error) {
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+	if from == to {
+		return amount, nil
+	}
+
+	ratesMu.RLock()
+	defer ratesMu.RUnlock()
+
+	fromRate, ok := rates.Rates[from]
+	if !ok {
+		return 0, fmt.Errorf("no exchange rate for currency %s", from)
+	}
+	toRate, ok := rates.Rates[to]
+	if !ok {
+		return 0, fmt.Errorf("no exchange rate for currency %s", to)
+	}
+
+	return amount / fromRate * toRate, nil
+}
+
+// HasExchangeRate reports whether the loaded table has a rate for the currency.
+func HasExchangeRate(currency string) bool {
+	ratesMu.RLock()
+	defer ratesMu.RUnlock()
+	_, ok := rates.Rates[strings.ToUpper(currency)]
+	return ok
+}
+
+// Currencies returns the sorted codes of the currencies in the loaded table.
+func Currencies() []string {
+	ratesMu.RLock()
+	defer ratesMu.RUnlock()
+
+	codes := make([]string, 0, len(rates.Rates))
+	for code := range rates.Rates {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// currencyInfo 币种的符号和小数位数.
+type currencyInfo struct {
+	Symbol string
+	Digits int
+}
+
+var currencies = map[string]currencyInfo{
+	"USD": {Symbol: "$", Digits: 2},
+	"CNY": {Symbol: "¥", Digits: 2},
+	"EUR": {Symbol: "€", Digits: 2},
+	"GBP": {Symbol: "£", Digits: 2},
+	"JPY": {Symbol: "¥", Digits: 0},
+	"IDR": {Symbol: "Rp", Digits: 0},
+	"SGD": {Symbol: "S$", Digits: 2},
+	"HKD": {Symbol: "HK$", Digits: 2},
+}
+
+// localeInfo 区域的数字分隔符和币种符号位置.
+type localeInfo struct {
+	Group        string
+	Decimal      string
+	SymbolAfter  bool // 符号放在金额之后, 如 "12,50 €"
+	SymbolSpaced bool // 符号和金额之间是否有空格
+}
+
+var locales = map[string]localeInfo{
+	"en-US": {Group: ",", Decimal: "."},
+	"en-GB": {Group: ",", Decimal: "."},
+	"zh-CN": {Group: ",", Decimal: "."},
+	"ja-JP": {Group: ",", Decimal: "."},
+	"de-DE": {Group: ".", Decimal: ",", SymbolAfter: true, SymbolSpaced: true},
+	"fr-FR": {Group: " ", Decimal: ",", SymbolAfter: true, SymbolSpaced: true},
+	"id-ID": {Group: ".", Decimal: ","},
+}
+
+// FormatPrice formats amount of the currency according to the locale, e.g.
+// FormatPrice(1234.5, "EUR", "de-DE") returns "1.234,50 €".
+// Unknown locales fall back to en-US, unknown currencies use the code as symbol.
+func FormatPrice(amount float64, currency, locale string) string {
+	currency = strings.ToUpper(currency)
+	cur, ok := currencies[currency]
+	if !ok {
+		cur = currencyInfo{Symbol: currency, Digits: 2}
+	}
+	loc, ok := locales[locale]
+	if !ok {
+		loc = locales[defaultLocale]
+	}
+
+	pow := math.Pow10(cur.Digits)
+	rounded := math.Round(math.Abs(amount)*pow) / pow
+	neg := amount < 0 && rounded != 0 // 舍入为 0 时不显示负号
+	text := strconv.FormatFloat(rounded, 'f', cur.Digits, 64)
+
+	intPart, fracPart, _ := strings.Cut(text, ".")
+	var sb strings.Builder
+	for i, c := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			sb.WriteString(loc.Group)
+		}
+		sb.WriteRune(c)
+	}
+	number := sb.String()
+	if fracPart != "" {
+		number += loc.Decimal + fracPart
+	}
+
+	sep := ""
+	if loc.SymbolSpaced {
+		sep = " "
+	}
+	if loc.SymbolAfter {
+		number = number + sep + cur.Symbol
+	} else {
+		number = cur.Symbol + sep + number
+	}
+	if neg {
+		number = "-" + number
+	}
+	return number
+}
@@ -0,0 +1,143 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+
+package tools
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestFormatPrice(t *testing.T) {
+	for _, tt := range []struct {
+		amount           float64
+		currency, locale string
+		want             string
+	}{
+		{1234.5, "EUR", "de-DE", "1.234,50 €"},
+		{1234.5, "EUR", "fr-FR", "1\u202f234,50 €"},
+		{1234567.891, "USD", "en-US", "$1,234,567.89"},
+		{1234567, "IDR", "id-ID", "Rp1.234.567"},
+		{-1234.5, "CNY", "zh-CN", "-¥1,234.50"},
+		{-3.5, "EUR", "de-DE", "-3,50 €"},
+		{0, "USD", "en-US", "$0.00"},
+		{100, "USD", "en-US", "$100.00"},
+		{100000, "GBP", "en-GB", "£100,000.00"},
+		// 没有小数的币种四舍五入到整数
+		{1234.5, "JPY", "ja-JP", "¥1,235"},
+		{1234.4, "JPY", "ja-JP", "¥1,234"},
+		// 四舍五入进位到更高的位数和分组
+		{0.125, "USD", "en-US", "$0.13"},
+		{999.999, "USD", "en-US", "$1,000.00"},
+		{-0.001, "USD", "en-US", "$0.00"},
+		// 币种不区分大小写, 未知币种用代码作为符号, 未知区域使用 en-US
+		{12, "sgd", "en-US", "S$12.00"},
+		{12, "XYZ", "en-US", "XYZ12.00"},
+		{12, "XYZ", "de-DE", "12,00 XYZ"},
+		{1234.5, "EUR", "xx-XX", "€1,234.50"},
+		{1234.5, "EUR", "", "€1,234.50"},
+	} {
+		if got := FormatPrice(tt.amount, tt.currency, tt.locale); got != tt.want {
+			t.Errorf("FormatPrice(%v, %q, %q) = %q, want %q", tt.amount, tt.currency, tt.locale, got, tt.want)
+		}
+	}
+}
+
+func TestConvertAmount(t *testing.T) {
+	for _, tt := range []struct {
+		amount   float64
+		from, to string
+		want     float64
+	}{
+		{100, "USD", "CNY", 723.74},
+		{723.74, "CNY", "USD", 100},
+		{100, "CNY", "EUR", 100 / 7.2374 * 0.9187},
+		{1000, "JPY", "IDR", 1000 / 148.62 * 16405},
+		{-10, "USD", "GBP", -7.729},
+		{0, "EUR", "USD", 0},
+		{42, "usd", "cny", 42 * 7.2374},
+		// 相同币种不需要汇率
+		{42, "XYZ", "xyz", 42},
+	} {
+		got, err := ConvertAmount(tt.amount, tt.from, tt.to)
+		if err != nil {
+			t.Errorf("ConvertAmount(%v, %s, %s): %v", tt.amount, tt.from, tt.to, err)
+			continue
+		}
+		if math.Abs(got-tt.want) > 1e-9*math.Max(1, math.Abs(tt.want)) {
+			t.Errorf("ConvertAmount(%v, %s, %s) = %v, want %v", tt.amount, tt.from, tt.to, got, tt.want)
+		}
+	}
+
+	for _, pair := range [][2]string{{"XYZ", "USD"}, {"USD", "XYZ"}, {"", "USD"}} {
+		if _, err := ConvertAmount(1, pair[0], pair[1]); err == nil {
+			t.Errorf("ConvertAmount(1, %q, %q) succeeded without a rate", pair[0], pair[1])
+		}
+	}
+}
+
+func TestLoadExchangeRates(t *testing.T) {
+	ratesMu.RLock()
+	saved := rates
+	ratesMu.RUnlock()
+	t.Cleanup(func() {
+		ratesMu.Lock()
+		rates = saved
+		ratesMu.Unlock()
+	})
+
+	if want := []string{"CNY", "EUR", "GBP", "HKD", "IDR", "JPY", "SGD", "USD"}; !slices.Equal(Currencies(), want) {
+		t.Errorf("Currencies() = %v, want %v", Currencies(), want)
+	}
+
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	for _, content := range []string{`not json`, `{"base":"EUR","rates":{"USD":0}}`, `{"base":"EUR","rates":{"USD":-1}}`} {
+		if err := LoadExchangeRates(write("bad.json", content)); err == nil {
+			t.Errorf("loaded %s", content)
+		}
+	}
+	if err := LoadExchangeRates(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("loaded a missing file")
+	}
+
+	// 代码统一为大写, 基准币种的汇率默认为 1
+	if err := LoadExchangeRates(write("rates.json", `{"base":"eur","rates":{"usd":1.25,"Chf":0.5}}`)); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"CHF", "EUR", "USD"}; !slices.Equal(Currencies(), want) {
+		t.Errorf("Currencies() = %v, want %v", Currencies(), want)
+	}
+	for code, want := range map[string]bool{"usd": true, "EUR": true, "chf": true, "CNY": false, "": false} {
+		if got := HasExchangeRate(code); got != want {
+			t.Errorf("HasExchangeRate(%q) = %v, want %v", code, got, want)
+		}
+	}
+	if got, err := ConvertAmount(10, "USD", "CHF"); err != nil || got != 4 {
+		t.Errorf("ConvertAmount(10, USD, CHF) = %v, %v, want 4", got, err)
+	}
+}
@@ -0,0 +1,268 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+)
+
+// Format 工具结果的输出格式, 长列表时 json 的重复 key 很浪费上下文, 可以换成更紧凑的格式.
+type Format string
+
+const (
+	FormatJSON       Format = "json"        // 紧凑 json, 默认格式
+	FormatPrettyJSON Format = "pretty_json" // 带缩进的 json
+	FormatMarkdown   Format = "markdown"    // markdown 表格
+	FormatLines      Format = "lines"       // 首行列出字段, 每行一条记录, 字段用 | 分隔
+)
+
+// ParseFormat parses the name of a format, e.g. from a flag.
+func ParseFormat(name string) (Format, error) {
+	switch f := Format(strings.ToLower(name)); f {
+	case FormatJSON, FormatPrettyJSON, FormatMarkdown, FormatLines:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown output format %q", name)
+}
+
+// formatResult 把工具结果按 options 中的格式序列化, 超出 MaxChars 时截断并提示省略的条数.
+func formatResult[T any](items []T, o *options) (string, error) {
+	r, err := newRenderer(o.Format, items)
+	if err != nil {
+		return "", err
+	}
+
+	rows := make([]string, 0, len(items))
+	for _, item := range items {
+		row, err := r.row(item)
+		if err != nil {
+			return "", err
+		}
+		rows = append(rows, row)
+	}
+
+	full := r.join(rows)
+	if o.MaxChars <= 0 || chars(full) <= o.MaxChars {
+		return full, nil
+	}
+
+	// 累加前 n 条的长度, 找到加上结尾和省略提示后不超过预算的最多条数.
+	// 省略提示可能比最后一条记录还长, 所以上面先看了全部记录是否放得下
+	keep, size := -1, chars(r.open())
+	for n := 0; n < len(rows); n++ {
+		if n > 0 {
+			size += chars(r.sep(n-1)) + chars(rows[n-1])
+		}
+		if size+chars(r.close(n))+chars(omittedNotice(len(rows)-n)) > o.MaxChars {
+			break
+		}
+		keep = n
+	}
+	if keep >= 0 {
+		return r.join(rows[:keep]) + omittedNotice(len(rows)-keep), nil
+	}
+
+	// 预算连结构和省略提示都放不下, 只输出截断到预算内的提示
+	notice := strings.TrimPrefix(omittedNotice(len(rows)), "\n")
+	return notice[:min(len(notice), o.MaxChars)], nil
+}
+
+// chars 按 Unicode 字符而不是字节计算长度, MaxChars 限制的是字符数.
+func chars(s string) int {
+	return utf8.RuneCountInString(s)
+}
+
+func omittedNotice(n int) string {
+	return fmt.Sprintf("\n(%d more omitted)", n)
+}
+
+type renderer struct {
+	format  Format
+	columns []column
+}
+
+type column struct {
+	name  string
+	index int
+}
+
+// newRenderer 表格的列由 items 决定, 流式输出时 items 是第一条记录.
+func newRenderer[T any](format Format, items []T) (*renderer, error) {
+	if format == "" {
+		format = FormatJSON
+	}
+	if _, err := ParseFormat(string(format)); err != nil {
+		return nil, err
+	}
+
+	r := &renderer{format: format}
+	if format == FormatMarkdown || format == FormatLines {
+		r.columns = tableColumns(reflect.ValueOf(items))
+	}
+	return r, nil
+}
+
+// tableColumns 按 json tag 取结构体的字段作为列, omitempty 的字段在所有记录中都为空时不输出.
+func tableColumns(items reflect.Value) []column {
+	typ := items.Type().Elem()
+	if typ.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var columns []column
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		if strings.Contains(opts, "omitempty") {
+			used := false
+			for j := 0; j < items.Len() && !used; j++ {
+				used = !items.Index(j).Field(i).IsZero()
+			}
+			if !used {
+				continue
+			}
+		}
+
+		columns = append(columns, column{name: name, index: i})
+	}
+
+	return columns
+}
+
+func (r *renderer) row(v any) (string, error) {
+	item := reflect.ValueOf(v)
+
+	switch r.format {
+	case FormatPrettyJSON:
+		b, err := json.MarshalIndent(item.Interface(), "  ", "  ")
+		return "  " + string(b), err
+	case FormatMarkdown, FormatLines:
+		if len(r.columns) == 0 {
+			return fmt.Sprint(item.Interface()), nil
+		}
+
+		cells := make([]string, 0, len(r.columns))
+		for _, c := range r.columns {
+			cells = append(cells, cellText(item.Field(c.index).Interface(), r.format))
+		}
+		if r.format == FormatMarkdown {
+			return "| " + strings.Join(cells, " | ") + " |", nil
+		}
+		return strings.Join(cells, "|"), nil
+	default:
+		b, err := json.Marshal(item.Interface())
+		return string(b), err
+	}
+}
+
+func (r *renderer) join(rows []string) string {
+	var sb strings.Builder
+	sb.WriteString(r.open())
+	for i, row := range rows {
+		sb.WriteString(r.sep(i))
+		sb.WriteString(row)
+	}
+	sb.WriteString(r.close(len(rows)))
+	return sb.String()
+}
+
+// open 返回第一条记录之前的内容, 如 json 的 "[" 或表头.
+func (r *renderer) open() string {
+	names := make([]string, 0, len(r.columns))
+	for _, c := range r.columns {
+		names = append(names, c.name)
+	}
+
+	switch r.format {
+	case FormatMarkdown:
+		if len(names) == 0 {
+			return ""
+		}
+		return "| " + strings.Join(names, " | ") + " |\n|" + strings.Repeat(" --- |", len(names))
+	case FormatLines:
+		if len(names) == 0 {
+			return ""
+		}
+		return "fields: " + strings.Join(names, "|")
+	default:
+		return "["
+	}
+}
+
+// sep 返回第 i 条记录之前的分隔符.
+func (r *renderer) sep(i int) string {
+	switch r.format {
+	case FormatPrettyJSON:
+		if i == 0 {
+			return "\n"
+		}
+		return ",\n"
+	case FormatMarkdown, FormatLines:
+		if i == 0 && len(r.columns) == 0 {
+			return ""
+		}
+		return "\n"
+	default:
+		if i == 0 {
+			return ""
+		}
+		return ","
+	}
+}
+
+// close 返回 n 条记录之后的内容.
+func (r *renderer) close(n int) string {
+	switch r.format {
+	case FormatPrettyJSON:
+		if n == 0 {
+			return "]"
+		}
+		return "\n]"
+	case FormatJSON:
+		return "]"
+	default:
+		return ""
+	}
+}
+
+func cellText(v any, format Format) string {
+	s := fmt.Sprint(v)
+	if f, ok := v.(float64); ok {
+		s = fmt.Sprintf("%.2f", f)
+	}
+
+	s = strings.ReplaceAll(s, "\n", " ")
+	if format == FormatMarkdown {
+		return strings.ReplaceAll(s, "|", `\|`)
+	}
+	return strings.ReplaceAll(s, "|", "/")
+}
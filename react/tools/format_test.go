@@ -0,0 +1,85 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tools
+
+import "testing"
+
+var allFormats = []Format{FormatJSON, FormatPrettyJSON, FormatMarkdown, FormatLines}
+
+func TestFormatResultMaxChars(t *testing.T) {
+	datasets := map[string][]Dish{
+		"ascii": {
+			{Name: "mapo tofu", Desc: "spicy", Price: 28, Score: 9},
+			{Name: "kung pao chicken", Desc: "peanuts", Price: 38, Score: 8},
+			{Name: "twice cooked pork", Desc: "", Price: 42, Score: 7},
+		},
+		// 中文每个字符 3 个字节, 预算按字符计算
+		"chinese": {
+			{Name: "麻婆豆腐", Desc: "麻辣", Price: 28, Score: 9},
+			{Name: "宫保鸡丁", Desc: "花生", Price: 38, Score: 8},
+			{Name: "回锅肉", Desc: "", Price: 42, Score: 7},
+		},
+	}
+
+	for name, dishes := range datasets {
+		for _, format := range allFormats {
+			full, err := formatResult(dishes, &options{Format: format})
+			if err != nil {
+				t.Fatal(err)
+			}
+			r, _ := newRenderer(format, dishes)
+
+			for budget := 1; budget <= chars(full)+1; budget++ {
+				got, err := formatResult(dishes, &options{Format: format, MaxChars: budget})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if chars(got) > budget {
+					t.Errorf("%s %s with %d chars: got %d chars: %q", name, format, budget, chars(got), got)
+				}
+
+				// 和逐条减少的写法结果相同
+				want := full
+				if chars(full) > budget {
+					want = omittedNotice(len(dishes))[1:]
+					want = want[:min(len(want), budget)]
+					for n := len(dishes) - 1; n >= 0; n-- {
+						rows := make([]string, 0, n)
+						for _, d := range dishes[:n] {
+							row, _ := r.row(d)
+							rows = append(rows, row)
+						}
+						if out := r.join(rows) + omittedNotice(len(dishes)-n); chars(out) <= budget {
+							want = out
+							break
+						}
+					}
+				}
+				if got != want {
+					t.Errorf("%s %s with %d chars: got %q, want %q", name, format, budget, got, want)
+				}
+			}
+		}
+	}
+
+	// 放得下全部字符的预算不截断, 即使字节数超出
+	dishes := datasets["chinese"]
+	full, _ := formatResult(dishes, &options{Format: FormatLines})
+	if got, _ := formatResult(dishes, &options{Format: FormatLines, MaxChars: chars(full)}); got != full || len(full) <= chars(full) {
+		t.Errorf("got %q, want the whole result %q", got, full)
+	}
+}
@@ -0,0 +1,74 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tools
+
+import (
+	"fmt"
+
+	"github.com/cloudwego/eino/components/tool"
+)
+
+// options 是餐厅工具的调用参数, 创建工具时传入的作为默认值, 每次调用时传入的会覆盖默认值.
+type options struct {
+	Currency string // 用户希望看到的币种, 如 USD, 为空时只展示原币种
+	Locale   string // 金额的格式化区域, 如 en-US, de-DE
+	Format   Format // 结果的输出格式
+	MaxChars int    // 结果的最大字符数 (Unicode 字符, 不是字节), 超出时截断, <= 0 表示不限制
+}
+
+// WithCurrency sets the currency the user wants prices converted into.
+func WithCurrency(currency string) tool.Option {
+	return tool.WrapImplSpecificOptFn(func(o *options) {
+		o.Currency = currency
+	})
+}
+
+// WithLocale sets the locale used to format prices, e.g. "en-US" or "de-DE".
+func WithLocale(locale string) tool.Option {
+	return tool.WrapImplSpecificOptFn(func(o *options) {
+		o.Locale = locale
+	})
+}
+
+// WithFormat sets the output format of the tool result.
+func WithFormat(format Format) tool.Option {
+	return tool.WrapImplSpecificOptFn(func(o *options) {
+		o.Format = format
+	})
+}
+
+// WithMaxChars limits the size of the tool result in characters, not bytes. Records
+// that do not fit are dropped and replaced by a "N more omitted" notice.
+func WithMaxChars(n int) tool.Option {
+	return tool.WrapImplSpecificOptFn(func(o *options) {
+		o.MaxChars = n
+	})
+}
+
+// getOptions 先应用创建工具时的默认参数, 再应用本次调用的参数.
+func getOptions(defaults []tool.Option, opts ...tool.Option) *options {
+	o := tool.GetImplSpecificOptions(&options{
+		Locale: defaultLocale,
+		Format: FormatJSON,
+	}, defaults...)
+	return tool.GetImplSpecificOptions(o, opts...)
+}
+
+// optionsKey 把工具的默认 option 和调用的 option 合并后的效果渲染成字符串, key 相同的调用输出相同.
+func optionsKey(defaults, opts []tool.Option) string {
+	return fmt.Sprintf("%+v", *getOptions(defaults, opts...))
+}
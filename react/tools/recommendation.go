@@ -0,0 +1,51 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tools
+
+import (
+	_ "embed"
+)
+
+// RecommendationSchema is the JSON schema of Recommendation.
+//
+//go:embed recommendation.schema.json
+var RecommendationSchema []byte
+
+// Recommendation 结构化的推荐结果, 前端可以据此渲染餐厅卡片.
+type Recommendation struct {
+	Summary     string                  `json:"summary"`
+	Restaurants []RecommendedRestaurant `json:"restaurants"`
+}
+
+// RecommendedRestaurant 推荐的餐厅, ID 来自 query_restaurants.
+type RecommendedRestaurant struct {
+	ID     string            `json:"id"`
+	Name   string            `json:"name"`
+	Place  string            `json:"place,omitempty"`
+	Score  int               `json:"score,omitempty"`
+	Reason string            `json:"reason"`
+	Dishes []RecommendedDish `json:"dishes"`
+}
+
+// RecommendedDish 推荐的菜品, ID 来自 query_dishes.
+type RecommendedDish struct {
+	ID       string  `json:"id"`
+	Name     string  `json:"name"`
+	Price    float64 `json:"price"`
+	Currency string  `json:"currency"`
+	Reason   string  `json:"reason"`
+}
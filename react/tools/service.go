@@ -18,8 +18,14 @@ package tools
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"iter"
+	"os"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 )
 
 // fake service 模拟的后端服务的 service
@@ -29,20 +35,64 @@ var restService = &fakeService{
 }
 
 // fake database.
-var database = &restaurantDatabase{
-	restaurantByID:        make(map[string]restaurantDataItem),
-	restaurantsByLocation: make(map[string][]restaurantDataItem),
+var database = &restaurantDatabase{}
+
+// datasetVersion 每次加载数据集时递增, 缓存等依赖数据集的组件可以据此失效.
+var datasetVersion atomic.Uint64
+
+// DatasetVersion returns a number that changes every time the restaurant dataset is (re)loaded.
+func DatasetVersion() uint64 {
+	return datasetVersion.Load()
 }
 
 func init() {
 	// prepare database
-	restData := getData()
-	for location, rests := range restData {
-		for _, rest := range rests {
-			database.restaurantByID[rest.ID] = rest
-			database.restaurantsByLocation[location] = append(database.restaurantsByLocation[location], rest)
+	database.load(getData())
+}
+
+// LoadDatabase replaces the restaurant data with the dataset file at path.
+// The file is a json object of location => restaurants, the same layout as getData,
+// restaurants and dishes are expected to be sorted by score.
+func LoadDatabase(path string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	restData := make(map[string][]restaurantDataItem)
+	if err = json.Unmarshal(b, &restData); err != nil {
+		return fmt.Errorf("load dataset from %s: %w", path, err)
+	}
+
+	database.load(restData)
+	return nil
+}
+
+// RestaurantService 餐厅工具依赖的后端服务, fakeService 是它的默认实现,
+// 可以用 NewChaosService 之类的装饰器包装.
+//
+// 结果按得到的顺序逐条产出, 流式的工具收到一条就输出一条, 不用等整个查询结束.
+// 出错时产出错误并结束.
+type RestaurantService interface {
+	QueryRestaurants(ctx context.Context, in *QueryRestaurantsParam) iter.Seq2[Restaurant, error]
+	QueryDishes(ctx context.Context, in *QueryDishesParam) iter.Seq2[Dish, error]
+}
+
+// DefaultService returns the fake backend service used by GetRestaurantTool and GetDishTool.
+func DefaultService() RestaurantService {
+	return restService
+}
+
+// collect 读完查询的结果, 遇到错误时返回错误和之前读到的记录.
+func collect[T any](seq iter.Seq2[T, error]) ([]T, error) {
+	var items []T
+	for item, err := range seq {
+		if err != nil {
+			return items, err
 		}
+		items = append(items, item)
 	}
+	return items, nil
 }
 
 // ====== fake service ======
@@ -51,47 +101,68 @@ type fakeService struct {
 }
 
 // QueryRestaurants 查询一个 location 的餐厅列表.
-func (ft *fakeService) QueryRestaurants(ctx context.Context, in *QueryRestaurantsParam) (out []Restaurant, err error) {
-	rests, err := ft.repo.GetRestaurantsByLocation(ctx, in.Location, in.Topn)
-	if err != nil {
-		return nil, err
-	}
-
-	res := make([]Restaurant, 0, len(rests))
-	for _, rest := range rests {
+func (ft *fakeService) QueryRestaurants(ctx context.Context, in *QueryRestaurantsParam) iter.Seq2[Restaurant, error] {
+	return func(yield func(Restaurant, error) bool) {
+		rests, err := ft.repo.GetRestaurantsByLocation(ctx, in.Location, in.Topn)
+		if err != nil {
+			yield(Restaurant{}, err)
+			return
+		}
 
-		res = append(res, Restaurant{
-			ID:    rest.ID,
-			Name:  rest.Name,
-			Place: rest.Place,
-			Score: rest.Score,
-		})
+		for _, rest := range rests {
+			if err = ctx.Err(); err != nil {
+				yield(Restaurant{}, err)
+				return
+			}
+			if !yield(Restaurant{
+				ID:       rest.ID,
+				Name:     rest.Name,
+				Place:    rest.Place,
+				Score:    rest.Score,
+				Currency: rest.currency(),
+			}, nil) {
+				return
+			}
+		}
 	}
-
-	return res, nil
 }
 
 // QueryDishes 根据餐厅的 id, 查询餐厅的菜品列表.
-func (ft *fakeService) QueryDishes(ctx context.Context, in *QueryDishesParam) (res []Dish, err error) {
-	dishes, err := ft.repo.GetDishesByRestaurant(ctx, in.RestaurantID, in.Topn)
-	if err != nil {
-		return nil, err
-	}
+func (ft *fakeService) QueryDishes(ctx context.Context, in *QueryDishesParam) iter.Seq2[Dish, error] {
+	return func(yield func(Dish, error) bool) {
+		rest, err := ft.repo.GetRestaurantByID(ctx, in.RestaurantID)
+		if err != nil {
+			yield(Dish{}, err)
+			return
+		}
 
-	res = make([]Dish, 0, len(dishes))
-	for _, dish := range dishes {
-		res = append(res, Dish{
-			Name:  dish.Name,
-			Desc:  dish.Desc,
-			Price: dish.Price,
-			Score: dish.Score,
-		})
-	}
+		dishes, err := ft.repo.GetDishesByRestaurant(ctx, in.RestaurantID, in.Topn)
+		if err != nil {
+			yield(Dish{}, err)
+			return
+		}
 
-	return res, nil
+		for _, dish := range dishes {
+			if err = ctx.Err(); err != nil {
+				yield(Dish{}, err)
+				return
+			}
+			if !yield(Dish{
+				ID:       dish.ID,
+				Name:     dish.Name,
+				Desc:     dish.Desc,
+				Price:    dish.Price,
+				Currency: rest.currency(),
+				Score:    dish.Score,
+			}, nil) {
+				return
+			}
+		}
+	}
 }
 
 type restaurantDishDataItem struct {
+	ID    string `json:"id"` // 为空时按餐厅 ID 和排名生成, 如 1001-2
 	Name  string `json:"name"`
 	Desc  string `json:"desc"`
 	Price int    `json:"price"`
@@ -99,37 +170,110 @@ type restaurantDishDataItem struct {
 }
 
 type restaurantDataItem struct {
-	ID    string `json:"id"`
-	Name  string `json:"name"`
-	Desc  string `json:"desc"`
-	Place string `json:"place"`
-	Score int    `json:"score"` // 0 - 10
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Desc     string `json:"desc"`
+	Place    string `json:"place"`
+	Score    int    `json:"score"`    // 0 - 10
+	Currency string `json:"currency"` // 菜品价格的币种, 如 CNY
 
 	Dishes []restaurantDishDataItem `json:"dishes"` // 餐厅中的菜
 }
 
+func (r restaurantDataItem) currency() string {
+	if r.Currency == "" {
+		return defaultCurrency
+	}
+	return r.Currency
+}
+
 type restaurantDatabase struct {
+	mu                    sync.RWMutex
 	restaurantByID        map[string]restaurantDataItem   // id => restaurantDataItem
-	restaurantsByLocation map[string][]restaurantDataItem // location => []restaurantDataItem
+	restaurantsByLocation map[string][]restaurantDataItem // lower case location => []restaurantDataItem
+	locations             []string                        // sorted lower case locations, for fuzzy matching
+}
+
+func (rd *restaurantDatabase) load(restData map[string][]restaurantDataItem) {
+	restaurantByID := make(map[string]restaurantDataItem)
+	restaurantsByLocation := make(map[string][]restaurantDataItem, len(restData))
+	locations := make([]string, 0, len(restData))
+
+	for location, rests := range restData {
+		key := strings.ToLower(location)
+		if _, ok := restaurantsByLocation[key]; !ok {
+			locations = append(locations, key)
+		}
+		for _, rest := range rests {
+			rest.Dishes = withDishIDs(rest.ID, rest.Dishes)
+			restaurantByID[rest.ID] = rest
+			restaurantsByLocation[key] = append(restaurantsByLocation[key], rest)
+		}
+	}
+	sort.Strings(locations)
+
+	rd.mu.Lock()
+	defer rd.mu.Unlock()
+	rd.restaurantByID = restaurantByID
+	rd.restaurantsByLocation = restaurantsByLocation
+	rd.locations = locations
+	datasetVersion.Add(1)
+}
+
+// withDishIDs returns a copy of dishes with the missing IDs filled in.
+func withDishIDs(restaurantID string, dishes []restaurantDishDataItem) []restaurantDishDataItem {
+	res := make([]restaurantDishDataItem, len(dishes))
+	for i, dish := range dishes {
+		if dish.ID == "" {
+			dish.ID = fmt.Sprintf("%s-%d", restaurantID, i+1)
+		}
+		res[i] = dish
+	}
+	return res
 }
 
 func (rd *restaurantDatabase) GetRestaurantsByLocation(ctx context.Context, location string, topn int) ([]restaurantDataItem, error) {
-	for locationName, rests := range rd.restaurantsByLocation {
-		if strings.Contains(locationName, location) || strings.Contains(location, locationName) {
+	rd.mu.RLock()
+	defer rd.mu.RUnlock()
 
-			res := make([]restaurantDataItem, 0, len(rests))
-			for i := 0; i < topn && i < len(rests); i++ {
-				res = append(res, rests[i])
+	key := strings.ToLower(location)
+	rests, ok := rd.restaurantsByLocation[key]
+	if !ok {
+		for _, locationName := range rd.locations {
+			if strings.Contains(locationName, key) || strings.Contains(key, locationName) {
+				rests, ok = rd.restaurantsByLocation[locationName], true
+				break
 			}
-
-			return res, nil
 		}
 	}
+	if !ok {
+		return nil, fmt.Errorf("location %s not found", location)
+	}
+
+	res := make([]restaurantDataItem, 0, len(rests))
+	for i := 0; i < topn && i < len(rests); i++ {
+		res = append(res, rests[i])
+	}
 
-	return nil, fmt.Errorf("location %s not found", location)
+	return res, nil
+}
+
+func (rd *restaurantDatabase) GetRestaurantByID(ctx context.Context, restaurantID string) (restaurantDataItem, error) {
+	rd.mu.RLock()
+	defer rd.mu.RUnlock()
+
+	rest, ok := rd.restaurantByID[restaurantID]
+	if !ok {
+		return restaurantDataItem{}, fmt.Errorf("restaurant %s not found", restaurantID)
+	}
+
+	return rest, nil
 }
 
 func (rd *restaurantDatabase) GetDishesByRestaurant(ctx context.Context, restaurantID string, topn int) ([]restaurantDishDataItem, error) {
+	rd.mu.RLock()
+	defer rd.mu.RUnlock()
+
 	rest, ok := rd.restaurantByID[restaurantID]
 	if !ok {
 		return nil, fmt.Errorf("restaurant %s not found", restaurantID)
@@ -148,11 +292,12 @@ func getData() map[string][]restaurantDataItem { // nolint: byted_s_too_many_lin
 	return map[string][]restaurantDataItem{
 		"Beijing": {
 			{
-				ID:    "1001",
-				Name:  "Cloud Edge Restaurant",
-				Place: "Beijing",
-				Desc:  "This is Cloud Edge Restaurant in Beijing, with diverse flavors",
-				Score: 3,
+				ID:       "1001",
+				Name:     "Cloud Edge Restaurant",
+				Place:    "Beijing",
+				Desc:     "This is Cloud Edge Restaurant in Beijing, with diverse flavors",
+				Score:    3,
+				Currency: "CNY",
 				Dishes: []restaurantDishDataItem{
 					{
 						Name:  "Braised Pork",
@@ -192,11 +337,12 @@ func getData() map[string][]restaurantDataItem { // nolint: byted_s_too_many_lin
 				},
 			},
 			{
-				ID:    "1002",
-				Name:  "Jufu Mansion Restaurant",
-				Place: "Beijing",
-				Desc:  "Jufu Mansion Restaurant in Beijing, many food stalls waiting for you to explore",
-				Score: 5,
+				ID:       "1002",
+				Name:     "Jufu Mansion Restaurant",
+				Place:    "Beijing",
+				Desc:     "Jufu Mansion Restaurant in Beijing, many food stalls waiting for you to explore",
+				Score:    5,
+				Currency: "CNY",
 				Dishes: []restaurantDishDataItem{
 					{
 						Name:  "Braised Spare Ribs",
@@ -225,11 +371,12 @@ func getData() map[string][]restaurantDataItem { // nolint: byted_s_too_many_lin
 				},
 			},
 			{
-				ID:    "1003",
-				Name:  "Flower Shadow Restaurant",
-				Place: "Shanghai",
-				Desc:  "Very luxurious Flower Shadow Restaurant, delicious and affordable",
-				Score: 10,
+				ID:       "1003",
+				Name:     "Flower Shadow Restaurant",
+				Place:    "Shanghai",
+				Desc:     "Very luxurious Flower Shadow Restaurant, delicious and affordable",
+				Score:    10,
+				Currency: "CNY",
 				Dishes: []restaurantDishDataItem{
 					{
 						Name:  "Super Braised Pork",
@@ -254,11 +401,12 @@ func getData() map[string][]restaurantDataItem { // nolint: byted_s_too_many_lin
 		},
 		"Shanghai": {
 			{
-				ID:    "2001",
-				Name:  "Hongbin Elegant Restaurant",
-				Place: "Shanghai",
-				Desc:  "This is Hongbin Elegant Restaurant in Shanghai, with diverse flavors",
-				Score: 3,
+				ID:       "2001",
+				Name:     "Hongbin Elegant Restaurant",
+				Place:    "Shanghai",
+				Desc:     "This is Hongbin Elegant Restaurant in Shanghai, with diverse flavors",
+				Score:    3,
+				Currency: "CNY",
 				Dishes: []restaurantDishDataItem{
 					{
 						Name:  "Sweet and Sour Tomatoes",
@@ -275,11 +423,12 @@ func getData() map[string][]restaurantDataItem { // nolint: byted_s_too_many_lin
 				},
 			},
 			{
-				ID:    "2002",
-				Name:  "Food Drunk Gang Base",
-				Desc:  "Focused on sweet and sour flavors, worth having",
-				Place: "Shanghai",
-				Score: 5,
+				ID:       "2002",
+				Name:     "Food Drunk Gang Base",
+				Desc:     "Focused on sweet and sour flavors, worth having",
+				Place:    "Shanghai",
+				Score:    5,
+				Currency: "CNY",
 				Dishes: []restaurantDishDataItem{
 					{
 						Name:  "Sweet and Sour Watermelon",
@@ -296,11 +445,12 @@ func getData() map[string][]restaurantDataItem { // nolint: byted_s_too_many_lin
 				},
 			},
 			{
-				ID:    "2010",
-				Name:  "So Good You'll Stamp Your Feet Restaurant",
-				Desc:  "This is the So Good You'll Stamp Your Feet Restaurant, hidden in a place you can't find, waiting for destined customers to explore. Mainly Sichuan cuisine, with generous amounts of chili and Sichuan pepper.",
-				Place: "It's where it isn't",
-				Score: 10,
+				ID:       "2010",
+				Name:     "So Good You'll Stamp Your Feet Restaurant",
+				Desc:     "This is the So Good You'll Stamp Your Feet Restaurant, hidden in a place you can't find, waiting for destined customers to explore. Mainly Sichuan cuisine, with generous amounts of chili and Sichuan pepper.",
+				Place:    "It's where it isn't",
+				Score:    10,
+				Currency: "CNY",
 				Dishes: []restaurantDishDataItem{
 					{
 						Name:  "Unbeatable Spicy Shrimp",
@@ -0,0 +1,230 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"iter"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+)
+
+// StreamTool is a restaurant tool that can be streamed as well as invoked. In a ToolsNode
+// it streams when the agent streams and is invoked otherwise.
+type StreamTool interface {
+	tool.InvokableTool
+	tool.StreamableTool
+}
+
+// GetRestaurantStreamTool returns the streaming variant of query_restaurants.
+func GetRestaurantStreamTool(opts ...tool.Option) StreamTool {
+	return NewRestaurantStreamTool(restService, opts...)
+}
+
+// GetDishStreamTool returns the streaming variant of query_dishes.
+func GetDishStreamTool(opts ...tool.Option) StreamTool {
+	return NewDishStreamTool(restService, opts...)
+}
+
+// NewRestaurantStreamTool returns the streaming variant of query_restaurants backed by the given service.
+func NewRestaurantStreamTool(svc RestaurantService, opts ...tool.Option) StreamTool {
+	return &ToolStreamRestaurants{
+		ToolQueryRestaurants{backService: svc, opts: opts},
+	}
+}
+
+// NewDishStreamTool returns the streaming variant of query_dishes backed by the given service.
+func NewDishStreamTool(svc RestaurantService, opts ...tool.Option) StreamTool {
+	return &ToolStreamDishes{
+		ToolQueryDishes{backService: svc, opts: opts},
+	}
+}
+
+// ToolStreamRestaurants 流式的 query_restaurants, 后端每返回一家餐厅就输出一家, 拼接后与 InvokableRun 的结果一致.
+type ToolStreamRestaurants struct {
+	invokable ToolQueryRestaurants
+}
+
+func (t *ToolStreamRestaurants) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	return t.invokable.Info(ctx)
+}
+
+func (t *ToolStreamRestaurants) InvokableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (string, error) {
+	return t.invokable.InvokableRun(ctx, argumentsInJSON, opts...)
+}
+
+func (t *ToolStreamRestaurants) OptionsKey(opts []tool.Option) string {
+	return t.invokable.OptionsKey(opts)
+}
+
+func (t *ToolStreamRestaurants) StreamableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (*schema.StreamReader[string], error) {
+	// 解析参数
+	p := &QueryRestaurantsParam{}
+	if err := json.Unmarshal([]byte(argumentsInJSON), p); err != nil {
+		return nil, err
+	}
+	if p.Topn == 0 {
+		p.Topn = defaultRestaurantTopn
+	}
+
+	o := getOptions(t.invokable.opts, opts...)
+	if _, err := ParseFormat(string(o.Format)); err != nil {
+		return nil, err
+	}
+
+	// 后端请求放到 goroutine 中, 调用方可以立即开始读取
+	return streamResult(ctx, o, p.Topn, t.invokable.backService.QueryRestaurants(ctx, p), nil), nil
+}
+
+// ToolStreamDishes 流式的 query_dishes, 后端每返回一道菜就输出一道, 拼接后与 InvokableRun 的结果一致.
+type ToolStreamDishes struct {
+	invokable ToolQueryDishes
+}
+
+func (t *ToolStreamDishes) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	return t.invokable.Info(ctx)
+}
+
+func (t *ToolStreamDishes) InvokableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (string, error) {
+	return t.invokable.InvokableRun(ctx, argumentsInJSON, opts...)
+}
+
+func (t *ToolStreamDishes) OptionsKey(opts []tool.Option) string {
+	return t.invokable.OptionsKey(opts)
+}
+
+func (t *ToolStreamDishes) StreamableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (*schema.StreamReader[string], error) {
+	// 解析参数
+	p := &QueryDishesParam{}
+	if err := json.Unmarshal([]byte(argumentsInJSON), p); err != nil {
+		return nil, err
+	}
+	if p.Topn == 0 {
+		p.Topn = defaultDishTopn
+	}
+
+	o := getOptions(t.invokable.opts, opts...)
+	if _, err := ParseFormat(string(o.Format)); err != nil {
+		return nil, err
+	}
+
+	return streamResult(ctx, o, p.Topn, t.invokable.backService.QueryDishes(ctx, p), func(d *Dish) error {
+		return localizePrice(d, o)
+	}), nil
+}
+
+// streamResult 在后台读取查询结果, 每得到一条就按格式写入流; ctx 取消或读取方关闭流时停止.
+// chaos service 注入 malformed 故障时, 流在最后一条记录之后结束, 没有结尾.
+//
+// 表格的列由第一条记录决定. 有 MaxChars 时不知道后面还有多少条, 按 limit 为省略提示预留位置,
+// 因此可能比 InvokableRun 少输出一条.
+func streamResult[T any](ctx context.Context, o *options, limit int, seq iter.Seq2[T, error], prepare func(*T) error) *schema.StreamReader[string] {
+	sr, sw := schema.Pipe[string](1)
+
+	go func() {
+		defer sw.Close()
+
+		next, stop := iter.Pull2(seq)
+		defer stop()
+
+		send := func(chunk string) bool {
+			select {
+			case <-ctx.Done():
+				sw.Send("", ctx.Err())
+				return false
+			default:
+			}
+			return !sw.Send(chunk, nil)
+		}
+
+		var (
+			r    *renderer
+			size int
+			n    int // 已经输出的记录数
+		)
+		for {
+			item, err, ok := next()
+			malformed := ok && isMalformed(err)
+			if malformed {
+				// 后端的响应不完整, 输出到这里为止, 没有结尾
+				ok, err = false, nil
+			}
+			if ok && err == nil && prepare != nil {
+				err = prepare(&item)
+			}
+			if err != nil {
+				sw.Send("", err)
+				return
+			}
+
+			if r == nil {
+				var first []T
+				if ok {
+					first = []T{item}
+				}
+				if r, err = newRenderer(o.Format, first); err != nil {
+					sw.Send("", err)
+					return
+				}
+				head := r.open()
+				if !send(head) {
+					return
+				}
+				size = chars(head)
+			}
+			if !ok {
+				if !malformed {
+					send(r.close(n))
+				}
+				return
+			}
+
+			row, err := r.row(item)
+			if err != nil {
+				sw.Send("", err)
+				return
+			}
+
+			chunk := r.sep(n) + row
+			if o.MaxChars > 0 {
+				// 已经发出的内容无法撤回, 因此要为结尾和省略提示预留位置
+				need := size + chars(chunk) + chars(r.close(n+1))
+				if n+1 < limit {
+					need += chars(omittedNotice(limit - n - 1))
+				}
+				if need > o.MaxChars {
+					omitted := 1
+					for _, err, ok := next(); ok && err == nil; _, err, ok = next() {
+						omitted++
+					}
+					send(r.close(n) + omittedNotice(omitted))
+					return
+				}
+			}
+
+			size += chars(chunk)
+			if !send(chunk) {
+				return
+			}
+			n++
+		}
+	}()
+
+	return sr
+}
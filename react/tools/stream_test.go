@@ -0,0 +1,121 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tools
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/components/tool"
+)
+
+func readAll(t *testing.T, st tool.StreamableTool, args string, opts ...tool.Option) []string {
+	t.Helper()
+	sr, err := st.StreamableRun(context.Background(), args, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sr.Close()
+
+	var chunks []string
+	for {
+		chunk, err := sr.Recv()
+		if errors.Is(err, io.EOF) {
+			return chunks
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		chunks = append(chunks, chunk)
+	}
+}
+
+func TestStreamMatchesInvoke(t *testing.T) {
+	tests := []struct {
+		name string
+		st   StreamTool
+		args string
+		opts []tool.Option
+	}{
+		{"restaurants json", GetRestaurantStreamTool(), `{"location":"beijing","topn":3}`, nil},
+		{"restaurants markdown", GetRestaurantStreamTool(WithFormat(FormatMarkdown)), `{"location":"beijing"}`, nil},
+		{"dishes lines", GetDishStreamTool(WithFormat(FormatLines)), `{"restaurant_id":"1001"}`, nil},
+		{"dishes currency", GetDishStreamTool(), `{"restaurant_id":"1001"}`, []tool.Option{WithCurrency("USD"), WithFormat(FormatPrettyJSON)}},
+		{"no results", GetRestaurantStreamTool(WithFormat(FormatMarkdown)), `{"location":"beijing","topn":-1}`, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want, err := tt.st.InvokableRun(context.Background(), tt.args, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Join(readAll(t, tt.st, tt.args, tt.opts...), ""); got != want {
+				t.Errorf("streamed\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestStreamBeforeBackendFinishes(t *testing.T) {
+	const delay = 50 * time.Millisecond
+	svc := NewChaosService(DefaultService(), &ChaosConfig{ItemLatency: FixedLatency(delay)})
+	st := NewRestaurantStreamTool(svc)
+
+	start := time.Now()
+	sr, err := st.StreamableRun(context.Background(), `{"location":"beijing","topn":3}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sr.Close()
+
+	var firstRecord time.Duration
+	for {
+		chunk, err := sr.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if firstRecord == 0 && strings.Contains(chunk, `"id"`) {
+			firstRecord = time.Since(start)
+		}
+	}
+	total := time.Since(start)
+
+	if firstRecord == 0 || firstRecord >= 2*delay {
+		t.Errorf("first record after %s, want it after about %s", firstRecord, delay)
+	}
+	if total < 3*delay {
+		t.Errorf("stream ended after %s, want at least %s", total, 3*delay)
+	}
+}
+
+func TestStreamMaxChars(t *testing.T) {
+	st := GetDishStreamTool(WithMaxChars(200))
+	got := strings.Join(readAll(t, st, `{"restaurant_id":"1001","topn":5}`), "")
+	if chars(got) > 200 {
+		t.Errorf("got %d chars, want at most 200: %s", chars(got), got)
+	}
+	if !strings.Contains(got, "more omitted") {
+		t.Errorf("want an omitted notice: %s", got)
+	}
+}
@@ -19,26 +19,54 @@ package tools
 import (
 	"context"
 	"encoding/json"
-	"fmt"
+	"strings"
 
 	"github.com/cloudwego/eino/components/tool"
 	"github.com/cloudwego/eino/schema"
 )
 
-func GetRestaurantTool() tool.InvokableTool {
+const (
+	defaultRestaurantTopn = 3
+	defaultDishTopn       = 5
+)
+
+// ArgumentDefaults returns the values the tools use for omitted arguments, keyed by tool name.
+func ArgumentDefaults() map[string]map[string]any {
+	return map[string]map[string]any{
+		"query_restaurants": {"topn": defaultRestaurantTopn},
+		"query_dishes":      {"topn": defaultDishTopn},
+	}
+}
+
+// GetRestaurantTool returns the query_restaurants tool, opts are used as defaults of every call.
+func GetRestaurantTool(opts ...tool.Option) tool.InvokableTool {
+	return NewRestaurantTool(restService, opts...)
+}
+
+// GetDishTool returns the query_dishes tool, opts are used as defaults of every call.
+func GetDishTool(opts ...tool.Option) tool.InvokableTool {
+	return NewDishTool(restService, opts...)
+}
+
+// NewRestaurantTool returns the query_restaurants tool backed by the given service.
+func NewRestaurantTool(svc RestaurantService, opts ...tool.Option) tool.InvokableTool {
 	return &ToolQueryRestaurants{
-		backService: restService,
+		backService: svc,
+		opts:        opts,
 	}
 }
 
-func GetDishTool() tool.InvokableTool {
+// NewDishTool returns the query_dishes tool backed by the given service.
+func NewDishTool(svc RestaurantService, opts ...tool.Option) tool.InvokableTool {
 	return &ToolQueryDishes{
-		backService: restService,
+		backService: svc,
+		opts:        opts,
 	}
 }
 
 type ToolQueryRestaurants struct {
-	backService *fakeService // fake service
+	backService RestaurantService
+	opts        []tool.Option
 }
 
 func (t *ToolQueryRestaurants) Info(ctx context.Context) (*schema.ToolInfo, error) {
@@ -72,22 +100,25 @@ func (t *ToolQueryRestaurants) InvokableRun(ctx context.Context, argumentsInJSON
 		return "", err
 	}
 	if p.Topn == 0 {
-		p.Topn = 3
+		p.Topn = defaultRestaurantTopn
 	}
 
 	// 请求后端服务
-	rests, err := t.backService.QueryRestaurants(ctx, p)
+	rests, err := collect(t.backService.QueryRestaurants(ctx, p))
+	truncate, err := splitMalformed(err)
 	if err != nil {
 		return "", err
 	}
 
 	// 序列化结果
-	res, err := json.Marshal(rests)
-	if err != nil {
-		return "", err
-	}
+	out, err := formatResult(rests, getOptions(t.opts, opts...))
+	return truncate(out), err
+}
 
-	return string(res), nil
+// OptionsKey renders the options of the tool merged with opts, result caches use it to
+// tell calls with different options apart, see toolmw.OptionsKeyer.
+func (t *ToolQueryRestaurants) OptionsKey(opts []tool.Option) string {
+	return optionsKey(t.opts, opts)
 }
 
 type QueryRestaurantsParam struct {
@@ -96,16 +127,18 @@ type QueryRestaurantsParam struct {
 }
 
 type Restaurant struct {
-	ID    string `json:"id"`
-	Name  string `json:"name"`
-	Place string `json:"place"`
-	Desc  string `json:"desc"`
-	Score int    `json:"score"`
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Place    string `json:"place"`
+	Desc     string `json:"desc"`
+	Score    int    `json:"score"`
+	Currency string `json:"currency"`
 }
 
 // ToolQueryDishes.
 type ToolQueryDishes struct {
-	backService *fakeService // fake service
+	backService RestaurantService
+	opts        []tool.Option
 }
 
 func (t *ToolQueryDishes) Info(ctx context.Context) (*schema.ToolInfo, error) {
@@ -131,27 +164,34 @@ func (t *ToolQueryDishes) InvokableRun(ctx context.Context, argumentsInJSON stri
 	p := &QueryDishesParam{}
 	err := json.Unmarshal([]byte(argumentsInJSON), p)
 	if err != nil {
-		fmt.Println(argumentsInJSON)
 		return "", err
 	}
 
 	if p.Topn == 0 {
-		p.Topn = 5
+		p.Topn = defaultDishTopn
 	}
 
 	// 请求后端服务
-	rests, err := t.backService.QueryDishes(ctx, p)
+	rests, err := collect(t.backService.QueryDishes(ctx, p))
+	truncate, err := splitMalformed(err)
 	if err != nil {
 		return "", err
 	}
 
-	// 序列化结果
-	res, err := json.Marshal(rests)
-	if err != nil {
+	// 价格本地化
+	o := getOptions(t.opts, opts...)
+	if err = localizePrices(rests, o); err != nil {
 		return "", err
 	}
 
-	return string(res), nil
+	// 序列化结果
+	out, err := formatResult(rests, o)
+	return truncate(out), err
+}
+
+// OptionsKey renders the options of the tool merged with opts, see ToolQueryRestaurants.OptionsKey.
+func (t *ToolQueryDishes) OptionsKey(opts []tool.Option) string {
+	return optionsKey(t.opts, opts)
 }
 
 type QueryDishesParam struct {
@@ -160,8 +200,43 @@ type QueryDishesParam struct {
 }
 
 type Dish struct {
-	Name  string `json:"name"`
-	Desc  string `json:"desc"`
-	Price int    `json:"price"`
-	Score int    `json:"score"`
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Desc      string `json:"desc"`
+	Price     int    `json:"price"`
+	Currency  string `json:"currency"`
+	PriceText string `json:"price_text"` // 按 locale 格式化的原币种价格
+	Score     int    `json:"score"`
+
+	// 换算成用户币种后的价格, 只有通过 WithCurrency 指定了币种时才有值
+	UserPrice     float64 `json:"user_price,omitempty"`
+	UserCurrency  string  `json:"user_currency,omitempty"`
+	UserPriceText string  `json:"user_price_text,omitempty"`
+}
+
+// localizePrices 按调用参数格式化菜品价格, 并换算成用户指定的币种.
+func localizePrices(dishes []Dish, o *options) error {
+	for i := range dishes {
+		if err := localizePrice(&dishes[i], o); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func localizePrice(d *Dish, o *options) error {
+	d.PriceText = FormatPrice(float64(d.Price), d.Currency, o.Locale)
+	if o.Currency == "" {
+		return nil
+	}
+
+	amount, err := ConvertAmount(float64(d.Price), d.Currency, o.Currency)
+	if err != nil {
+		return err
+	}
+
+	d.UserCurrency = strings.ToUpper(o.Currency)
+	d.UserPrice = amount
+	d.UserPriceText = FormatPrice(amount, d.UserCurrency, o.Locale)
+	return nil
 }
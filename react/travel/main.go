@@ -0,0 +1,118 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// travel 是一个分层 agent 的例子: 旅行规划 agent 把找餐厅和菜的任务交给餐厅推荐 agent,
+// 后者作为 find_food 工具运行, 它的 trace 显示在 find_food 这次调用的下面.
+//
+//	go run ./react/travel -question "Plan a day in Beijing for me, I love spicy food" -trace
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/cloudwego/eino-ext/components/model/ollama"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/flow/agent/react"
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/galihrivanto/eino-exp/internal/agentrun"
+	"github.com/galihrivanto/eino-exp/internal/agenttool"
+	"github.com/galihrivanto/eino-exp/internal/logs"
+	"github.com/galihrivanto/eino-exp/react/tools"
+)
+
+const (
+	plannerPersona = `# Character:
+You are a travel planner. You plan trips day by day with the places to visit and where to eat.
+You don't know restaurants yourself: for every meal, ask find_food with the city and what the traveller likes, and use the restaurants and dishes it recommends.
+`
+	restaurantPersona = `# Character:
+You are an assistant who helps users recommend restaurants and dishes. According to the needs of users, you can query restaurant information and recommend dishes.
+`
+)
+
+func main() {
+	var (
+		baseURL  = flag.String("base-url", "http://localhost:11434", "ollama endpoint")
+		name     = flag.String("model", "qwen2:7b", "model of both agents")
+		question = flag.String("question", "Plan a day in Beijing for me, I love spicy food.", "what to plan")
+		trace    = flag.Bool("trace", false, "print the trace of the planner with the restaurant agent's runs nested under find_food")
+	)
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	llm, err := ollama.NewChatModel(ctx, &ollama.ChatModelConfig{BaseURL: *baseURL, Model: *name})
+	if err != nil {
+		logs.Fatalf("create ollama chat model failed: %v", err)
+	}
+
+	restaurants, err := newAgent(ctx, llm, restaurantPersona, tools.GetRestaurantTool(), tools.GetDishTool())
+	if err != nil {
+		logs.Fatalf("create restaurant agent failed: %v", err)
+	}
+	findFood, err := agenttool.New(restaurants, &agenttool.Config{
+		Name: "find_food",
+		Desc: "Ask the restaurant expert for restaurants and dishes in a city. It answers with the restaurants, their dishes and why they fit.",
+		Params: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"location": {
+				Type:     schema.String,
+				Desc:     "the city to eat in",
+				Required: true,
+			},
+			agenttool.RequestParam: {
+				Type:     schema.String,
+				Desc:     "what to find, e.g. a spicy dinner for two, with the traveller's tastes and budget",
+				Required: true,
+			},
+		}),
+	})
+	if err != nil {
+		logs.Fatalf("create find_food tool failed: %v", err)
+	}
+
+	planner, err := newAgent(ctx, llm, plannerPersona, findFood)
+	if err != nil {
+		logs.Fatalf("create planner agent failed: %v", err)
+	}
+
+	res, err := agentrun.Generate(ctx, planner, []*schema.Message{schema.UserMessage(*question)})
+	if err != nil {
+		if *trace && res != nil {
+			fmt.Fprint(os.Stderr, res)
+		}
+		logs.Fatalf("plan failed: %v", err)
+	}
+	fmt.Println(res.Message.Content)
+	if *trace {
+		fmt.Fprint(os.Stderr, "\n", res)
+	}
+}
+
+func newAgent(ctx context.Context, llm model.ChatModel, persona string, list ...tool.BaseTool) (*react.Agent, error) {
+	return react.NewAgent(ctx, &react.AgentConfig{
+		Model:           llm,
+		ToolsConfig:     compose.ToolsNodeConfig{Tools: list},
+		MessageModifier: react.NewPersonaModifier(persona),
+	})
+}
@@ -2,24 +2,24 @@ package main
 
 import (
 	"context"
-	"log"
+	"fmt"
 
 	"github.com/cloudwego/eino/components/model"
 	"github.com/cloudwego/eino/schema"
 )
 
-func generate(ctx context.Context, llm model.ChatModel, in []*schema.Message) *schema.Message {
+func generate(ctx context.Context, llm model.ChatModel, in []*schema.Message) (*schema.Message, error) {
 	result, err := llm.Generate(ctx, in)
 	if err != nil {
-		log.Fatalf("llm generate failed: %v", err)
+		return nil, fmt.Errorf("llm generate failed: %w", err)
 	}
-	return result
+	return result, nil
 }
 
-func stream(ctx context.Context, llm model.ChatModel, in []*schema.Message) *schema.StreamReader[*schema.Message] {
+func stream(ctx context.Context, llm model.ChatModel, in []*schema.Message) (*schema.StreamReader[*schema.Message], error) {
 	result, err := llm.Stream(ctx, in)
 	if err != nil {
-		log.Fatalf("llm generate failed: %v", err)
+		return nil, fmt.Errorf("llm generate failed: %w", err)
 	}
-	return result
+	return result, nil
 }
@@ -2,28 +2,76 @@ package main
 
 import (
 	"context"
+	"flag"
 	"log"
+
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/galihrivanto/eino-exp/internal/session"
 )
 
 func main() {
-	ctx := context.Background()
+	sessionID := flag.String("session", "", "session ID, the conversation is resumed from and saved to it when set")
+	sessionStore := flag.String("session-store", "file:.sessions", "where sessions are kept, file:<dir> or bolt:<file>")
+	flag.Parse()
+
+	// 错误返回到这里再退出, 保证 run 中 defer 的关闭会话存储已经执行
+	if err := run(context.Background(), *sessionID, *sessionStore); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(ctx context.Context, sessionID, sessionStore string) error {
+	// 加载会话历史
+	var (
+		store   session.Store
+		sess    *session.Session
+		history []*schema.Message
+	)
+	if sessionID != "" {
+		var err error
+		if store, sess, err = loadSession(ctx, sessionStore, sessionID); err != nil {
+			return err
+		}
+		defer store.Close()
+		history = sess.Messages
+	}
 
 	// 使用模版创建messages
 	log.Printf("===create messages===\n")
-	messages := createMessagesFromTemplate()
+	messages, err := createMessagesFromTemplate(history)
+	if err != nil {
+		return err
+	}
 	log.Printf("messages: %+v\n\n", messages)
 
 	// 创建llm
 	log.Printf("===create llm===\n")
 
-	cm := createOllamaChatModel(ctx)
+	cm, err := createOllamaChatModel(ctx)
+	if err != nil {
+		return err
+	}
 	log.Printf("create llm success\n\n")
 
 	log.Printf("===llm generate===\n")
-	result := generate(ctx, cm, messages)
+	result, err := generate(ctx, cm, messages)
+	if err != nil {
+		return err
+	}
 	log.Printf("result: %+v\n\n", result)
 
+	if sess != nil {
+		// 模板的最后一条消息是这一轮的问题
+		if err = saveSession(ctx, store, sess, modelName, messages[len(messages)-1], result); err != nil {
+			return err
+		}
+	}
+
 	log.Printf("===llm stream generate===\n")
-	streamResult := stream(ctx, cm, messages)
-	reportStream(streamResult)
+	streamResult, err := stream(ctx, cm, messages)
+	if err != nil {
+		return err
+	}
+	return reportStream(streamResult)
 }
@@ -2,19 +2,21 @@ package main
 
 import (
 	"context"
-	"log"
+	"fmt"
 
 	"github.com/cloudwego/eino-ext/components/model/ollama"
 	"github.com/cloudwego/eino/components/model"
 )
 
-func createOllamaChatModel(ctx context.Context) model.ChatModel {
+const modelName = "deepseek-r1:latest"
+
+func createOllamaChatModel(ctx context.Context) (model.ChatModel, error) {
 	chatModel, err := ollama.NewChatModel(ctx, &ollama.ChatModelConfig{
 		BaseURL: "http://localhost:11434", // Ollama 服务地址
-		Model:   "deepseek-r1:latest",     // 模型名称
+		Model:   modelName,                // 模型名称
 	})
 	if err != nil {
-		log.Fatalf("create ollama chat model failed: %v", err)
+		return nil, fmt.Errorf("create ollama chat model failed: %w", err)
 	}
-	return chatModel
+	return chatModel, nil
 }
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/galihrivanto/eino-exp/internal/session"
+)
+
+// loadSession 打开会话存储并加载会话, 会话不存在时返回一个新的空会话.
+func loadSession(ctx context.Context, spec, id string) (session.Store, *session.Session, error) {
+	store, err := session.Open(spec)
+	if err != nil {
+		return nil, nil, fmt.Errorf("open session store failed: %w", err)
+	}
+
+	sess, err := store.Load(ctx, id)
+	if errors.Is(err, session.ErrNotFound) {
+		return store, &session.Session{ID: id}, nil
+	}
+	if err != nil {
+		store.Close()
+		return nil, nil, fmt.Errorf("load session failed: %w", err)
+	}
+	return store, sess, nil
+}
+
+// saveSession 把这一轮的问题和回答追加到会话中保存. 系统消息和新会话里模拟的对话历史由模板生成, 不保存.
+func saveSession(ctx context.Context, store session.Store, sess *session.Session, model string, question, answer *schema.Message) error {
+	sess.Model = model
+	sess.Messages = append(sess.Messages, question, answer)
+	if err := store.Save(ctx, sess); err != nil {
+		return fmt.Errorf("save session failed: %w", err)
+	}
+	log.Printf("session %s saved, %d messages\n", sess.ID, len(sess.Messages))
+	return nil
+}
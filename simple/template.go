@@ -2,7 +2,7 @@ package main
 
 import (
 	"context"
-	"log"
+	"fmt"
 
 	"github.com/cloudwego/eino/components/prompt"
 	"github.com/cloudwego/eino/schema"
@@ -22,24 +22,29 @@ func createTemplate() prompt.ChatTemplate {
 	)
 }
 
-func createMessagesFromTemplate() []*schema.Message {
+// createMessagesFromTemplate 使用给定的对话历史, 为空时使用模拟的两轮对话.
+func createMessagesFromTemplate(history []*schema.Message) ([]*schema.Message, error) {
 	template := createTemplate()
 
-	// 使用模板生成消息
-	messages, err := template.Format(context.Background(), map[string]any{
-		"role":     "Programmer Encouragement",
-		"style":    "positive, warm, and professional",
-		"question": "My code is always reporting errors, and I feel very frustrated, what should I do?",
+	if len(history) == 0 {
 		// 对话历史（这个例子里模拟两轮对话历史）
-		"chat_history": []*schema.Message{
+		history = []*schema.Message{
 			schema.UserMessage("Hello"),
 			schema.AssistantMessage("Hey! I'm your programmer encouragement! Remember, every great programmer grows from debugging. What can I help you with?", nil),
 			schema.UserMessage("I feel like my code is too bad"),
 			schema.AssistantMessage("Every programmer has gone through this stage! What's important is that you're constantly learning and improving. Let's look at the code together. I believe it will get better through refactoring and optimization. Remember, Rome wasn't built in a day, and code quality is improved through continuous improvement.", nil),
-		},
+		}
+	}
+
+	// 使用模板生成消息
+	messages, err := template.Format(context.Background(), map[string]any{
+		"role":         "Programmer Encouragement",
+		"style":        "positive, warm, and professional",
+		"question":     "My code is always reporting errors, and I feel very frustrated, what should I do?",
+		"chat_history": history,
 	})
 	if err != nil {
-		log.Fatalf("format template failed: %v\n", err)
+		return nil, fmt.Errorf("format template failed: %w", err)
 	}
-	return messages
+	return messages, nil
 }
@@ -31,16 +31,12 @@ import (
 	"github.com/cloudwego/eino/utils/callbacks"
 
 	"github.com/galihrivanto/eino-exp/internal/logs"
+	"github.com/galihrivanto/eino-exp/internal/writercritic"
 )
 
 func main() {
 	ctx := context.Background()
 
-	type state struct {
-		currentRound int
-		msgs         []*schema.Message
-	}
-
 	llm, err := ollama.NewChatModel(ctx, &ollama.ChatModelConfig{
 		BaseURL: "http://localhost:11434", // Ollama 服务地址
 		Model:   "deepseek-r1:latest",     // 模型名称
@@ -49,41 +45,7 @@ func main() {
 		log.Fatalf("create ollama chat model failed: %v", err)
 	}
 
-	g := compose.NewGraph[[]*schema.Message, *schema.Message](compose.WithGenLocalState(func(ctx context.Context) *state { return &state{} }))
-	_ = g.AddChatModelNode("writer", llm, compose.WithStatePreHandler(func(ctx context.Context, input []*schema.Message, state *state) ([]*schema.Message, error) {
-		state.currentRound++
-		state.msgs = append(state.msgs, input...)
-		input = append([]*schema.Message{schema.SystemMessage("you are a writer who writes jokes and revise it according to the critic's feedback. Prepend your joke with your name which is \"writer: \"")}, state.msgs...)
-		return input, nil
-	}), compose.WithNodeName("writer"))
-	_ = g.AddChatModelNode("critic", llm, compose.WithStatePreHandler(func(ctx context.Context, input []*schema.Message, state *state) ([]*schema.Message, error) {
-		state.msgs = append(state.msgs, input...)
-		input = append([]*schema.Message{schema.SystemMessage("you are a critic who ONLY gives feedback about jokes, emphasizing on funniness. Prepend your feedback with your name which is \"critic: \"")}, state.msgs...)
-		return input, nil
-	}), compose.WithNodeName("critic"))
-	_ = g.AddLambdaNode("toList1", compose.ToList[*schema.Message]())
-	_ = g.AddLambdaNode("toList2", compose.ToList[*schema.Message]())
-
-	_ = g.AddEdge(compose.START, "writer")
-	_ = g.AddBranch("writer", compose.NewStreamGraphBranch(func(ctx context.Context, input *schema.StreamReader[*schema.Message]) (string, error) {
-		input.Close()
-
-		var result string = "toList1"
-		err := compose.ProcessState(ctx, func(ctx context.Context, state *state) error {
-			if state.currentRound >= 3 {
-				result = compose.END
-			}
-
-			return nil
-		})
-
-		return result, err
-	}, map[string]bool{compose.END: true, "toList1": true}))
-	_ = g.AddEdge("toList1", "critic")
-	_ = g.AddEdge("critic", "toList2")
-	_ = g.AddEdge("toList2", "writer")
-
-	runner, err := g.Compile(ctx)
+	runner, err := writercritic.New(ctx, llm, writercritic.DefaultRounds)
 	if err != nil {
 		logs.Fatalf("compile error: %v", err)
 	}